@@ -0,0 +1,44 @@
+package common
+
+import (
+	"encoding/json"
+)
+
+// 任务备份，GET /job/export 导出，POST /job/import 恢复
+type JobArchive struct {
+	Version    int                `json:"version"`    // 备份格式版本
+	ExportTime int64              `json:"exportTime"` // 导出时间，毫秒
+	Revision   int64              `json:"revision"`   // 导出时etcd的revision，所有任务都是这个版本的快照
+	Jobs       []*JobArchiveEntry `json:"jobs"`
+}
+
+// 备份中的一个任务，任务本身原样保存，加上etcd中的元信息
+// 任务的创建和修改时间在Job中，恢复时沿用；任务没有单独的暂停状态，不需要额外保存
+type JobArchiveEntry struct {
+	Job            *Job  `json:"job"`
+	CreateRevision int64 `json:"createRevision"` // 创建时的revision
+	ModRevision    int64 `json:"modRevision"`    // 最近一次修改的revision
+	Version        int64 `json:"version"`        // 修改次数
+}
+
+// 恢复备份的结果
+type JobImportResult struct {
+	Mode        string   `json:"mode"`        // 冲突处理方式 skip / overwrite / fail
+	Created     []string `json:"created"`     // 新建的任务
+	Overwritten []string `json:"overwritten"` // 覆盖的任务
+	Skipped     []string `json:"skipped"`     // 已存在且内容不同，skip模式下保留的任务
+	Unchanged   int      `json:"unchanged"`   // 已存在且内容相同的任务数
+	Revision    int64    `json:"revision"`    // 写入后的etcd revision
+}
+
+// 解析备份文件
+func UnpackJobArchive(value []byte) (archive *JobArchive, err error) {
+	archive = &JobArchive{}
+	if err = json.Unmarshal(value, archive); err != nil {
+		return
+	}
+	if archive.Version != JOB_ARCHIVE_VERSION {
+		err = ERR_UNSUPPORTED_ARCHIVE_VERSION
+	}
+	return
+}
@@ -1,21 +1,198 @@
 package common
 
 const (
+	// 版本号，worker注册时上报
+	VERSION = "1.0.0"
+
+	// 任务目录 /cron/jobs/命名空间/任务名
 	JOB_SAVE_DIR = "/cron/jobs/"
 
+	// 命名空间配额 /cron/namespaces/命名空间，master启动时按配置写入，worker执行任务时读取
+	JOB_NAMESPACE_DIR = "/cron/namespaces/"
+
+	// 命名空间并发执行槽位 /cron/quota/命名空间/槽位序号，带租约，执行结束后释放
+	JOB_QUOTA_DIR = "/cron/quota/"
+
+	// 默认命名空间，没有指定命名空间的任务和升级前的老任务都属于它
+	DEFAULT_NAMESPACE = "default"
+
 	// 任务强杀目录
 	JOB_KILLER_DIR = "/cron/killer/"
 
 	// 锁路径
 	JOB_LOCK_DIR = "/cron/lock/"
 
-	// 服务注册目录
+	// 服务注册目录 /cron/workers/workerID
 	JOB_WORKER_DIR = "/cron/workers/"
 
+	// 节点维护标记目录 /cron/cordon/workerID，value为cordon或drain
+	JOB_CORDON_DIR = "/cron/cordon/"
+
+	// 手动触发任务目录 /cron/run/任务名
+	JOB_RUN_DIR = "/cron/run/"
+
+	// 任务最近一次执行结果 /cron/result/任务名，worker写入，master监听后发送通知
+	JOB_RESULT_DIR = "/cron/result/"
+
+	// 正在执行的任务 /cron/running/任务名/execId，worker开始执行时写入，带租约，执行结束后删除
+	JOB_RUNNING_DIR = "/cron/running/"
+
+	// master选主 /cron/election/master，当选的master负责发送通知
+	MASTER_ELECTION_KEY = "/cron/election/master"
+
+	// 所有key的公共前缀，事件流监听整个目录，保证事件按revision有序
+	CRON_DIR = "/cron/"
+
 	// 保存任务事件
 	JOB_EVENT_SAVE = 1
 	// 删除任务事件
 	JOB_EVENT_DELETE = 2
 	// 杀死任务
 	JOB_EVENT_KILL = 3
+	// 立即执行一次任务
+	JOB_EVENT_RUN = 4
+
+	// 单节点执行（默认），抢到锁的worker执行
+	JOB_MODE_SINGLE = "single"
+	// 广播执行，每个worker都执行一次
+	JOB_MODE_BROADCAST = "broadcast"
+
+	// 停止接收新任务
+	WORKER_FLAG_CORDON = "cordon"
+	// 停止接收新任务，并等待正在执行的任务结束
+	WORKER_FLAG_DRAIN = "drain"
+
+	// 节点状态：正常调度
+	WORKER_STATE_ACTIVE = "active"
+	// 节点状态：已禁止调度
+	WORKER_STATE_CORDONED = "cordoned"
+	// 节点状态：排空中，还有任务在执行
+	WORKER_STATE_DRAINING = "draining"
+	// 节点状态：已排空
+	WORKER_STATE_DRAINED = "drained"
+
+	// 日志状态：执行成功
+	LOG_STATUS_SUCCESS = "success"
+	// 日志状态：执行失败
+	LOG_STATUS_FAILED = "failed"
+
+	// 日志排序字段：任务开始执行时间
+	LOG_SORT_START_TIME = "startTime"
+	// 日志排序字段：计划调度时间
+	LOG_SORT_PLAN_TIME = "planTime"
+	// 日志排序字段：任务执行结束时间，日志在任务结束后写入，按结束时间正序可以跟踪新日志
+	LOG_SORT_END_TIME = "endTime"
+
+	// 任务日志保留策略：不限制，覆盖全局配置
+	LOG_RETENTION_UNLIMITED = -1
+
+	// 通知事件：执行失败
+	NOTIFY_EVENT_FAILURE = "failure"
+	// 通知事件：执行超时
+	NOTIFY_EVENT_TIMEOUT = "timeout"
+	// 通知事件：连续失败达到N次
+	NOTIFY_EVENT_CONSECUTIVE_FAILURES = "consecutive_failures"
+	// 通知事件：失败后恢复成功
+	NOTIFY_EVENT_RECOVERY = "recovery"
+	// 通知事件：超过expectSuccessWithin没有成功
+	NOTIFY_EVENT_OVERDUE = "overdue"
+	// 通知事件：超期后重新成功
+	NOTIFY_EVENT_OVERDUE_RECOVERY = "overdue_recovery"
+
+	// 通知渠道：通用webhook，body由模板生成
+	NOTIFY_TARGET_WEBHOOK = "webhook"
+	// 通知渠道：SMTP邮件
+	NOTIFY_TARGET_EMAIL = "email"
+	// 通知渠道：Slack兼容的incoming webhook
+	NOTIFY_TARGET_SLACK = "slack"
+
+	// 任务清单格式版本
+	JOB_MANIFEST_VERSION = 1
+
+	// 清单变更：新建任务
+	JOB_CHANGE_CREATE = "create"
+	// 清单变更：修改任务
+	JOB_CHANGE_UPDATE = "update"
+	// 清单变更：删除清单中没有的任务（prune）
+	JOB_CHANGE_DELETE = "delete"
+
+	// 任务备份格式版本
+	JOB_ARCHIVE_VERSION = 1
+
+	// 恢复备份时任务已存在：保留原任务
+	JOB_IMPORT_MODE_SKIP = "skip"
+	// 恢复备份时任务已存在：用备份覆盖
+	JOB_IMPORT_MODE_OVERWRITE = "overwrite"
+	// 恢复备份时任务已存在：整体失败，不写入任何任务
+	JOB_IMPORT_MODE_FAIL = "fail"
+
+	// 任务参数类型：字符串（默认）
+	JOB_PARAM_STRING = "string"
+	// 任务参数类型：整数
+	JOB_PARAM_INT = "int"
+	// 任务参数类型：浮点数
+	JOB_PARAM_FLOAT = "float"
+	// 任务参数类型：布尔
+	JOB_PARAM_BOOL = "bool"
+
+	// 任务列表排序：按全名（默认）
+	JOB_SORT_NAME = "name"
+	// 任务列表排序：按创建时间
+	JOB_SORT_CREATE_TIME = "createTime"
+	// 任务列表排序：按最近修改时间
+	JOB_SORT_UPDATE_TIME = "updateTime"
+	// 任务列表排序：按负责人
+	JOB_SORT_OWNER = "owner"
+
+	// 实时事件：任务保存
+	EVENT_JOB_SAVED = "job.saved"
+	// 实时事件：任务删除
+	EVENT_JOB_DELETED = "job.deleted"
+	// 实时事件：任务开始执行
+	EVENT_RUN_STARTED = "run.started"
+	// 实时事件：任务执行结束
+	EVENT_RUN_FINISHED = "run.finished"
+	// 实时事件：worker上线
+	EVENT_WORKER_JOINED = "worker.joined"
+	// 实时事件：worker下线
+	EVENT_WORKER_LEFT = "worker.left"
+
+	// 接口角色：只读
+	API_ROLE_VIEWER = "viewer"
+	// 接口角色：读写任务，手动执行和强杀
+	API_ROLE_EDITOR = "editor"
+	// 接口角色：另外可以管理worker
+	API_ROLE_ADMIN = "admin"
+
+	// REST接口(/api/v1)的错误码
+	API_ERR_INVALID_BODY                 = "INVALID_BODY"
+	API_ERR_INVALID_PARAMETER            = "INVALID_PARAMETER"
+	API_ERR_NAME_MISMATCH                = "NAME_MISMATCH"
+	API_ERR_JOB_NAME_REQUIRED            = "JOB_NAME_REQUIRED"
+	API_ERR_INVALID_JOB_MODE             = "INVALID_JOB_MODE"
+	API_ERR_NOTIFY_TARGET_NOT_FOUND      = "NOTIFY_TARGET_NOT_FOUND"
+	API_ERR_INVALID_STATS_WINDOW         = "INVALID_STATS_WINDOW"
+	API_ERR_UNSUPPORTED_MANIFEST_VERSION = "UNSUPPORTED_MANIFEST_VERSION"
+	API_ERR_DUPLICATE_JOB_NAME           = "DUPLICATE_JOB_NAME"
+	API_ERR_UNSUPPORTED_ARCHIVE_VERSION  = "UNSUPPORTED_ARCHIVE_VERSION"
+	API_ERR_INVALID_IMPORT_MODE          = "INVALID_IMPORT_MODE"
+	API_ERR_UNAUTHORIZED                 = "UNAUTHORIZED"
+	API_ERR_NOT_FOUND                    = "NOT_FOUND"
+	API_ERR_JOB_NOT_FOUND                = "JOB_NOT_FOUND"
+	API_ERR_WORKER_NOT_FOUND             = "WORKER_NOT_FOUND"
+	API_ERR_METHOD_NOT_ALLOWED           = "METHOD_NOT_ALLOWED"
+	API_ERR_APPLY_CONFLICT               = "APPLY_CONFLICT"
+	API_ERR_TOO_MANY_CHANGES             = "TOO_MANY_CHANGES"
+	API_ERR_JOB_CONFLICT                 = "JOB_CONFLICT"
+	API_ERR_INTERNAL                     = "INTERNAL_ERROR"
+	API_ERR_INVALID_NAMESPACE            = "INVALID_NAMESPACE"
+	API_ERR_INVALID_JOB_NAME             = "INVALID_JOB_NAME"
+	API_ERR_FORBIDDEN                    = "FORBIDDEN"
+	API_ERR_QUOTA_EXCEEDED               = "QUOTA_EXCEEDED"
+	API_ERR_INVALID_JOB_SORT             = "INVALID_JOB_SORT"
+	API_ERR_INVALID_LOG_SORT             = "INVALID_LOG_SORT"
+	API_ERR_LOG_KEYWORD_UNSCOPED         = "LOG_KEYWORD_UNSCOPED"
+	API_ERR_INVALID_LOG_RETENTION        = "INVALID_LOG_RETENTION"
+	API_ERR_INVALID_JOB_PARAM            = "INVALID_JOB_PARAM"
+	API_ERR_INVALID_COMMAND_TEMPLATE     = "INVALID_COMMAND_TEMPLATE"
 )
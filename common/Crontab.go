@@ -0,0 +1,337 @@
+package common
+
+import (
+	"github.com/gorhill/cronexpr"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 导入crontab的选项
+type CrontabImportOptions struct {
+	System     bool   // 系统crontab（/etc/crontab、/etc/cron.d/*），时间后面多一个用户字段
+	NamePrefix string // 生成的任务名称前缀
+	Namespace  string // 生成的任务所属的命名空间，为空表示default
+}
+
+// crontab中的一行无法转换或者转换后行为可能不同
+type CrontabIssue struct {
+	Line   int    `json:"line"`   // 行号，从1开始
+	Text   string `json:"text"`   // 原始内容
+	Reason string `json:"reason"` // 原因
+}
+
+// 导入结果
+type CrontabImportResult struct {
+	Jobs     []*Job          `json:"jobs"`              // 生成的任务
+	Skipped  []*CrontabIssue `json:"skipped"`           // 无法转换的行
+	Warnings []*CrontabIssue `json:"warnings"`          // 已转换，但执行效果可能和crontab不同
+	Applied  *ApplyResult    `json:"applied,omitempty"` // 同时保存时的应用结果
+
+	jobLines map[string]*CrontabIssue // 任务全名 -> 生成该任务的行
+}
+
+var (
+	// 环境变量赋值 NAME=value，等号两边可以有空格
+	crontabEnvPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(.*)$`)
+
+	// @宏对应的cron表达式，@reboot没有对应的定时调度
+	crontabMacros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+
+	// 推导任务名称时跳过的命令前缀
+	crontabWrapperCommands = map[string]bool{
+		"sudo": true, "nice": true, "ionice": true, "nohup": true, "env": true, "exec": true, "time": true,
+	}
+
+	// 解释器，任务名称取脚本名
+	crontabInterpreters = map[string]bool{
+		"sh": true, "bash": true, "python": true, "python2": true, "python3": true,
+		"perl": true, "php": true, "ruby": true, "node": true,
+	}
+
+	// 任务名称中不允许的字符
+	crontabNameInvalidChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+)
+
+// 解析crontab文件，生成任务
+// 环境变量赋值对之后的任务生效，通过export加在命令前面；
+// 命令中第一个未转义的%之后的内容作为标准输入，其余的%转为换行，\%表示%本身
+func ParseCrontab(content string, options *CrontabImportOptions) (result *CrontabImportResult) {
+	var (
+		lines     []string
+		line      string
+		text      string
+		lineNo    int
+		i         int
+		matches   []string
+		envs      []string // export语句
+		fields    []string
+		cronExpr  string
+		value     string
+		user      string
+		command   string
+		stdin     []string
+		job       *Job
+		name      string
+		usedNames map[string]int
+		err       error
+	)
+	result = &CrontabImportResult{
+		Jobs:     make([]*Job, 0),
+		Skipped:  make([]*CrontabIssue, 0),
+		Warnings: make([]*CrontabIssue, 0),
+		jobLines: make(map[string]*CrontabIssue),
+	}
+	envs = make([]string, 0)
+	usedNames = make(map[string]int)
+	lines = strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+	for i, text = range lines {
+		lineNo = i + 1
+		line = strings.TrimSpace(text)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// 环境变量
+		if matches = crontabEnvPattern.FindStringSubmatch(line); matches != nil {
+			switch matches[1] {
+			case "MAILTO", "MAILFROM":
+				result.Warnings = append(result.Warnings, &CrontabIssue{Line: lineNo, Text: text, Reason: "不支持邮件发送输出，请使用任务的失败通知"})
+			case "SHELL":
+				if value = unquoteCrontabValue(matches[2]); value != "/bin/sh" && value != "/bin/bash" {
+					result.Warnings = append(result.Warnings, &CrontabIssue{Line: lineNo, Text: text, Reason: "任务固定使用/bin/bash执行"})
+				}
+			case "CRON_TZ":
+				result.Warnings = append(result.Warnings, &CrontabIssue{Line: lineNo, Text: text, Reason: "不支持按任务设置时区，按worker所在时区调度"})
+			default:
+				envs = append(envs, "export "+matches[1]+"="+shellQuote(unquoteCrontabValue(matches[2])))
+			}
+			continue
+		}
+
+		// 调度时间
+		if strings.HasPrefix(line, "@") {
+			fields = splitCrontabFields(line, 1)
+			if len(fields) < 2 {
+				result.Skipped = append(result.Skipped, &CrontabIssue{Line: lineNo, Text: text, Reason: "缺少命令"})
+				continue
+			}
+			if fields[0] == "@reboot" {
+				result.Skipped = append(result.Skipped, &CrontabIssue{Line: lineNo, Text: text, Reason: "不支持@reboot"})
+				continue
+			}
+			if cronExpr = crontabMacros[fields[0]]; cronExpr == "" {
+				result.Skipped = append(result.Skipped, &CrontabIssue{Line: lineNo, Text: text, Reason: "不支持的宏 " + fields[0]})
+				continue
+			}
+			command = fields[1]
+		} else {
+			if fields = splitCrontabFields(line, 5); len(fields) < 6 {
+				result.Skipped = append(result.Skipped, &CrontabIssue{Line: lineNo, Text: text, Reason: "字段不足，无法识别"})
+				continue
+			}
+			cronExpr = strings.Join(fields[:5], " ")
+			command = fields[5]
+		}
+		if _, err = cronexpr.Parse(cronExpr); err != nil {
+			result.Skipped = append(result.Skipped, &CrontabIssue{Line: lineNo, Text: text, Reason: "cron表达式错误: " + err.Error()})
+			continue
+		}
+
+		// 系统crontab的用户字段
+		if options.System {
+			if fields = splitCrontabFields(command, 1); len(fields) < 2 {
+				result.Skipped = append(result.Skipped, &CrontabIssue{Line: lineNo, Text: text, Reason: "缺少用户或命令"})
+				continue
+			}
+			user, command = fields[0], fields[1]
+			if user != "root" {
+				result.Warnings = append(result.Warnings, &CrontabIssue{Line: lineNo, Text: text, Reason: "原任务以用户 " + user + " 执行，导入后以worker进程的用户执行"})
+			}
+		}
+
+		// 推导任务名称，重名的加上序号
+		name = options.NamePrefix + deriveCrontabJobName(command)
+		usedNames[name]++
+		if usedNames[name] > 1 {
+			name = name + "-" + strconv.Itoa(usedNames[name])
+		}
+
+		// %处理
+		command, stdin = splitCrontabPercent(command)
+		if len(stdin) > 0 {
+			command = "printf '%s\\n' " + shellQuoteAll(stdin) + " | {\n" + command + "\n}"
+		}
+		if len(envs) > 0 {
+			command = strings.Join(envs, "\n") + "\n" + command
+		}
+
+		job = &Job{
+			Namespace: options.Namespace,
+			Name:      name,
+			Command:   command,
+			CronExpr:  cronExpr,
+		}
+		result.Jobs = append(result.Jobs, job)
+		result.jobLines[job.FullName()] = &CrontabIssue{Line: lineNo, Text: text}
+	}
+	return
+}
+
+// 把没有导入的任务从Jobs移到Skipped，names为任务全名
+func (result *CrontabImportResult) SkipJobs(names []string, reason string) {
+	var (
+		skip  map[string]bool
+		name  string
+		jobs  []*Job
+		job   *Job
+		issue *CrontabIssue
+	)
+	if len(names) == 0 {
+		return
+	}
+	skip = make(map[string]bool)
+	for _, name = range names {
+		skip[name] = true
+	}
+	jobs = make([]*Job, 0, len(result.Jobs))
+	for _, job = range result.Jobs {
+		if !skip[job.FullName()] {
+			jobs = append(jobs, job)
+			continue
+		}
+		issue = &CrontabIssue{Reason: reason + ": " + job.FullName()}
+		if result.jobLines[job.FullName()] != nil {
+			issue.Line = result.jobLines[job.FullName()].Line
+			issue.Text = result.jobLines[job.FullName()].Text
+		}
+		result.Skipped = append(result.Skipped, issue)
+	}
+	result.Jobs = jobs
+	// 按行号排列，和解析时跳过的行混在一起
+	sort.SliceStable(result.Skipped, func(i, j int) bool {
+		return result.Skipped[i].Line < result.Skipped[j].Line
+	})
+}
+
+// 按空白拆出前n个字段，剩下的部分（保留原样）作为最后一个元素
+func splitCrontabFields(line string, n int) (fields []string) {
+	var (
+		i     int
+		index int
+	)
+	fields = make([]string, 0, n+1)
+	line = strings.TrimLeft(line, " \t")
+	for i = 0; i < n && line != ""; i++ {
+		if index = strings.IndexAny(line, " \t"); index < 0 {
+			fields = append(fields, line)
+			return
+		}
+		fields = append(fields, line[:index])
+		line = strings.TrimLeft(line[index:], " \t")
+	}
+	if line != "" {
+		fields = append(fields, line)
+	}
+	return
+}
+
+// 拆分命令中的%：第一个未转义的%之前是命令，之后按%拆成标准输入的各行，\%还原为%
+func splitCrontabPercent(command string) (cmd string, stdin []string) {
+	var (
+		builder strings.Builder
+		parts   []string
+		i       int
+	)
+	parts = make([]string, 0)
+	for i = 0; i < len(command); i++ {
+		if command[i] == '\\' && i+1 < len(command) && command[i+1] == '%' {
+			builder.WriteByte('%')
+			i++
+			continue
+		}
+		if command[i] == '%' {
+			parts = append(parts, builder.String())
+			builder.Reset()
+			continue
+		}
+		builder.WriteByte(command[i])
+	}
+	parts = append(parts, builder.String())
+	return strings.TrimSpace(parts[0]), parts[1:]
+}
+
+// 去掉环境变量值两边的引号
+func unquoteCrontabValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// 单引号转义，用于拼接shell命令
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+func shellQuoteAll(values []string) string {
+	var (
+		quoted []string
+		value  string
+	)
+	quoted = make([]string, 0, len(values))
+	for _, value = range values {
+		quoted = append(quoted, shellQuote(value))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// 根据命令推导任务名称：跳过环境变量赋值、sudo之类的前缀和cd/test条件，
+// 取可执行文件名（解释器则取脚本名），去掉扩展名
+func deriveCrontabJobName(command string) (name string) {
+	var (
+		tokens []string
+		token  string
+		i      int
+	)
+	tokens = strings.Fields(command)
+	for i = 0; i < len(tokens); i++ {
+		token = tokens[i]
+		switch {
+		case crontabEnvPattern.MatchString(token), crontabWrapperCommands[token], strings.HasPrefix(token, "-"):
+			continue
+		case token == "cd" || token == "test" || token == "[":
+			// 跳到下一个 && / || / ; 之后
+			for i++; i < len(tokens); i++ {
+				if tokens[i] == "&&" || tokens[i] == "||" || tokens[i] == ";" || strings.HasSuffix(tokens[i], ";") {
+					break
+				}
+			}
+			continue
+		case token == "&&" || token == "||" || token == ";" || token == "(" || token == "{":
+			continue
+		}
+		token = path.Base(strings.Trim(token, "()"))
+		if crontabInterpreters[token] {
+			continue
+		}
+		name = strings.TrimSuffix(token, path.Ext(token))
+		break
+	}
+	name = strings.Trim(crontabNameInvalidChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if name == "" {
+		name = "job"
+	}
+	return
+}
@@ -0,0 +1,110 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeriveCrontabJobName(t *testing.T) {
+	var (
+		cases = []struct {
+			command string
+			name    string
+		}{
+			{"/usr/local/bin/backup.sh --full", "backup"},
+			{"cd /opt/app && python3 run_report.py", "run_report"},
+			{"FOO=1 nice /bin/echo hi", "echo"},
+			{"test -x /usr/sbin/logrotate && /usr/sbin/logrotate /etc/logrotate.conf", "logrotate"},
+			{"Sync.Data", "sync"},
+			{"", "job"},
+		}
+		name string
+		i    int
+	)
+	for i = range cases {
+		if name = deriveCrontabJobName(cases[i].command); name != cases[i].name {
+			t.Errorf("%q: 推导出 %q，期望 %q", cases[i].command, name, cases[i].name)
+		}
+	}
+}
+
+func TestSplitCrontabPercent(t *testing.T) {
+	var (
+		cases = []struct {
+			command string
+			cmd     string
+			stdin   []string
+		}{
+			{"echo hello", "echo hello", []string{}},
+			{`date +\%Y\%m\%d`, "date +%Y%m%d", []string{}},
+			{"mail -s hi root%line1%line2", "mail -s hi root", []string{"line1", "line2"}},
+			{"cat %", "cat", []string{""}},
+		}
+		cmd   string
+		stdin []string
+		i     int
+	)
+	for i = range cases {
+		cmd, stdin = splitCrontabPercent(cases[i].command)
+		if cmd != cases[i].cmd || !reflect.DeepEqual(stdin, cases[i].stdin) {
+			t.Errorf("%q: 拆分为 %q %q，期望 %q %q", cases[i].command, cmd, stdin, cases[i].cmd, cases[i].stdin)
+		}
+	}
+}
+
+func TestParseCrontab(t *testing.T) {
+	var (
+		content = "SHELL=/bin/bash\n" +
+			"MAILTO=ops@example.com\n" +
+			"# 注释\n" +
+			"PATH=/usr/bin:/bin\n" +
+			"0 3 * * * /opt/backup.sh\n" +
+			"@daily /opt/backup.sh\n" +
+			"@reboot /opt/start.sh\n" +
+			"* * * cmd\n" +
+			"*/5 * * * * echo a%b\n"
+		result *CrontabImportResult
+		names  []string
+		lines  []int
+		i      int
+	)
+	result = ParseCrontab(content, &CrontabImportOptions{NamePrefix: "sys-", Namespace: "ops"})
+
+	for i = range result.Jobs {
+		names = append(names, result.Jobs[i].FullName())
+	}
+	if !reflect.DeepEqual(names, []string{"ops/sys-backup", "ops/sys-backup-2", "ops/sys-echo"}) {
+		t.Fatalf("生成的任务 %v", names)
+	}
+	if result.Jobs[0].CronExpr != "0 3 * * *" || result.Jobs[1].CronExpr != "0 0 * * *" {
+		t.Errorf("cron表达式 %q %q", result.Jobs[0].CronExpr, result.Jobs[1].CronExpr)
+	}
+	if result.Jobs[0].Command != "export PATH='/usr/bin:/bin'\n/opt/backup.sh" {
+		t.Errorf("环境变量没有加在命令前面: %q", result.Jobs[0].Command)
+	}
+	if result.Jobs[2].Command != "export PATH='/usr/bin:/bin'\nprintf '%s\\n' 'b' | {\necho a\n}" {
+		t.Errorf("%%没有转为标准输入: %q", result.Jobs[2].Command)
+	}
+	for i = range result.Skipped {
+		lines = append(lines, result.Skipped[i].Line)
+	}
+	if !reflect.DeepEqual(lines, []int{7, 8}) {
+		t.Errorf("跳过的行 %v，期望 [7 8]", lines)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Line != 2 {
+		t.Errorf("MAILTO没有提示: %+v", result.Warnings)
+	}
+
+	// 已存在的任务移到skipped，按行号排列
+	result.SkipJobs([]string{"ops/sys-backup"}, "任务已存在")
+	lines = lines[:0]
+	for i = range result.Skipped {
+		lines = append(lines, result.Skipped[i].Line)
+	}
+	if len(result.Jobs) != 2 || !reflect.DeepEqual(lines, []int{5, 7, 8}) {
+		t.Errorf("跳过已存在的任务后 任务%d个 跳过的行 %v", len(result.Jobs), lines)
+	}
+	if result.Skipped[0].Text != "0 3 * * * /opt/backup.sh" {
+		t.Errorf("跳过的任务没有带上原始内容: %+v", result.Skipped[0])
+	}
+}
@@ -6,4 +6,68 @@ var (
 	ERR_LOCK_ALREADY_REQUIRED = errors.New("锁已被占用")
 
 	ERR_NO_LOCAL_IP_FOUND = errors.New("没有找到网卡IP")
+
+	ERR_WORKER_NOT_FOUND = errors.New("节点不存在")
+
+	ERR_INVALID_JOB_MODE = errors.New("不支持的任务调度模式")
+
+	ERR_JOB_NOT_FOUND = errors.New("任务不存在")
+
+	ERR_JOB_NAME_REQUIRED = errors.New("任务名称不能为空")
+
+	ERR_INVALID_STATS_WINDOW = errors.New("统计时间窗口格式错误")
+
+	ERR_UNKNOWN_LOG_STORE = errors.New("不支持的日志存储类型")
+
+	ERR_UNKNOWN_LOG_FORMAT = errors.New("不支持的日志格式")
+
+	ERR_JOB_TIMEOUT = errors.New("任务执行超时")
+
+	ERR_UNKNOWN_NOTIFY_TARGET = errors.New("不支持的通知渠道")
+
+	ERR_NOTIFY_TARGET_NOT_FOUND = errors.New("通知渠道不存在")
+
+	ERR_UNAUTHORIZED = errors.New("未授权，请提供正确的token")
+
+	ERR_UNSUPPORTED_MANIFEST_VERSION = errors.New("不支持的任务清单版本")
+
+	ERR_DUPLICATE_JOB_NAME = errors.New("任务名称重复")
+
+	ERR_APPLY_CONFLICT = errors.New("任务在应用期间被修改，请重试")
+
+	ERR_TOO_MANY_CHANGES = errors.New("变更太多，超出etcd单个事务的操作数上限，请拆分后分批应用，或同时调大etcd的--max-txn-ops和master的etcdMaxTxnOps")
+
+	ERR_UNSUPPORTED_ARCHIVE_VERSION = errors.New("不支持的备份版本")
+
+	ERR_INVALID_IMPORT_MODE = errors.New("不支持的冲突处理方式")
+
+	ERR_JOB_IMPORT_CONFLICT = errors.New("任务已存在")
+
+	ERR_EVENT_REVISION_COMPACTED = errors.New("事件已被etcd压缩，无法从该revision继续")
+
+	ERR_INVALID_NAMESPACE = errors.New("命名空间只能包含小写字母、数字和-，且以字母或数字开头和结尾，最长63个字符")
+
+	ERR_INVALID_JOB_NAME = errors.New("任务名称不能包含/")
+
+	ERR_FORBIDDEN = errors.New("没有权限")
+
+	ERR_JOB_QUOTA_EXCEEDED = errors.New("超出命名空间的任务数上限")
+
+	ERR_RUN_QUOTA_EXCEEDED = errors.New("超出命名空间的并发执行上限")
+
+	ERR_NO_FREE_SLOT = errors.New("节点的执行槽位已满")
+
+	ERR_INVALID_JOB_PARAM = errors.New("任务参数错误")
+
+	ERR_INVALID_COMMAND_TEMPLATE = errors.New("命令模板错误")
+
+	ERR_LOG_REJECTED = errors.New("日志被存储拒绝，重试也不会成功")
+
+	ERR_INVALID_LOG_RETENTION = errors.New("日志保留策略错误，maxAge和maxCount为0表示使用全局配置，-1表示不限制")
+
+	ERR_LOG_KEYWORD_UNSCOPED = errors.New("按关键字搜索日志时必须指定任务名称或开始时间startFrom，避免扫描所有日志的输出")
+
+	ERR_INVALID_LOG_SORT = errors.New("不支持的日志排序字段，可选 startTime / endTime / planTime，前面加-表示倒序")
+
+	ERR_INVALID_JOB_SORT = errors.New("不支持的排序字段，可选 name / createTime / updateTime / owner，前面加-表示倒序")
 )
@@ -0,0 +1,199 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// 任务参数，定义了参数的任务命令按text/template渲染
+// 例如 "command": "backup.sh --db={{.Params.db | shellquote}} --date={{.PlanTime.Format \"2006-01-02\"}}"
+// 渲染结果交给 bash -c 执行，手动执行时传入的参数值原样替换，字符串参数要用 shellquote 加单引号转义，避免被当作shell语法
+type JobParam struct {
+	Name        string      `json:"name"`                  // 参数名，在命令中通过 {{.Params.参数名}} 引用
+	Type        string      `json:"type,omitempty"`        // string / int / float / bool，为空表示string
+	Default     interface{} `json:"default,omitempty"`     // 默认值，手动执行时可以覆盖
+	Description string      `json:"description,omitempty"` // 参数说明
+}
+
+// 渲染命令时可以引用的变量
+type CommandTemplateData struct {
+	Namespace string                 // 命名空间
+	JobName   string                 // 任务名称
+	Params    map[string]interface{} // 参数，默认值加上手动执行时覆盖的值
+	PlanTime  time.Time              // 计划调度时间，手动执行时为触发时间
+	ExecId    string                 // 本次执行的唯一ID
+	Attempt   int                    // 第几次尝试，从1开始；暂不支持失败重试，目前总是1
+}
+
+var (
+	// 参数名要能在模板中用 .Params.参数名 引用
+	paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// 命令模板中可以使用的函数
+	commandTemplateFuncs = template.FuncMap{
+		// 转成字符串后加单引号转义，作为一个完整的shell参数
+		"shellquote": func(value interface{}) string { return shellQuote(fmt.Sprint(value)) },
+	}
+)
+
+// 命令是否需要按模板渲染
+func (job *Job) IsTemplated() bool {
+	return job.Template || len(job.Params) > 0
+}
+
+// 把参数值转换为参数类型，字符串形式的值（表单、命令行）按类型解析
+func ConvertParamValue(param *JobParam, value interface{}) (converted interface{}, err error) {
+	var (
+		str       string
+		isString  bool
+		number    float64
+		isNumber  bool
+		intValue  int64
+		boolValue bool
+		ok        bool
+	)
+	str, isString = value.(string)
+	number, isNumber = value.(float64)
+	switch param.Type {
+	case "", JOB_PARAM_STRING:
+		if isString {
+			return str, nil
+		}
+	case JOB_PARAM_INT:
+		if isString {
+			if intValue, err = strconv.ParseInt(str, 10, 64); err == nil {
+				return intValue, nil
+			}
+		} else if isNumber && number == math.Trunc(number) {
+			return int64(number), nil
+		} else if intValue, ok = value.(int64); ok {
+			return intValue, nil
+		}
+	case JOB_PARAM_FLOAT:
+		if isString {
+			if number, err = strconv.ParseFloat(str, 64); err == nil {
+				return number, nil
+			}
+		} else if isNumber {
+			return number, nil
+		}
+	case JOB_PARAM_BOOL:
+		if isString {
+			if boolValue, err = strconv.ParseBool(str); err == nil {
+				return boolValue, nil
+			}
+		} else if boolValue, ok = value.(bool); ok {
+			return boolValue, nil
+		}
+	default:
+		return nil, fmt.Errorf("%w: 参数 %s 的类型 %s 不存在", ERR_INVALID_JOB_PARAM, param.Name, param.Type)
+	}
+	return nil, fmt.Errorf("%w: 参数 %s 的值 %v 不是 %s 类型", ERR_INVALID_JOB_PARAM, param.Name, value, param.Type)
+}
+
+// 参数的最终取值：默认值加上覆盖的值，覆盖了不存在的参数返回错误，没有默认值的参数取类型的零值
+func ResolveJobParams(job *Job, overrides map[string]interface{}) (params map[string]interface{}, err error) {
+	var (
+		param   *JobParam
+		defined map[string]*JobParam
+		name    string
+		value   interface{}
+	)
+	params = make(map[string]interface{})
+	defined = make(map[string]*JobParam)
+	for _, param = range job.Params {
+		defined[param.Name] = param
+		if value = param.Default; value == nil {
+			value = zeroParamValue(param.Type)
+		}
+		if params[param.Name], err = ConvertParamValue(param, value); err != nil {
+			return
+		}
+	}
+	for name, value = range overrides {
+		if param = defined[name]; param == nil {
+			return nil, fmt.Errorf("%w: 参数 %s 不存在", ERR_INVALID_JOB_PARAM, name)
+		}
+		if params[name], err = ConvertParamValue(param, value); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// 类型的零值
+func zeroParamValue(paramType string) interface{} {
+	switch paramType {
+	case JOB_PARAM_INT:
+		return int64(0)
+	case JOB_PARAM_FLOAT:
+		return float64(0)
+	case JOB_PARAM_BOOL:
+		return false
+	}
+	return ""
+}
+
+// 按模板渲染命令，引用不存在的参数或变量都是错误
+func renderCommand(job *Job, data *CommandTemplateData) (command string, err error) {
+	var (
+		tmpl *template.Template
+		buf  bytes.Buffer
+	)
+	if tmpl, err = template.New(job.Name).Option("missingkey=error").Funcs(commandTemplateFuncs).Parse(job.Command); err != nil {
+		return "", fmt.Errorf("%w: %v", ERR_INVALID_COMMAND_TEMPLATE, err)
+	}
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%w: %v", ERR_INVALID_COMMAND_TEMPLATE, err)
+	}
+	return buf.String(), nil
+}
+
+// 本次执行实际要运行的命令，不需要渲染的任务原样返回
+func RenderCommand(info *JobExecuteInfo) (command string, err error) {
+	var (
+		data *CommandTemplateData
+	)
+	if !info.Job.IsTemplated() {
+		return info.Job.Command, nil
+	}
+	data = &CommandTemplateData{
+		Namespace: NormalizeNamespace(info.Job.Namespace),
+		JobName:   info.Job.Name,
+		PlanTime:  info.PlanTime,
+		ExecId:    info.ExecId,
+		Attempt:   1, // 暂不支持失败重试，每次调度只执行一次
+	}
+	if data.Params, err = ResolveJobParams(info.Job, info.Params); err != nil {
+		return
+	}
+	return renderCommand(info.Job, data)
+}
+
+// 校验参数定义和命令模板，用默认值试渲染一次，保存时就能发现引用了不存在的参数
+func (job *Job) ValidateParams() (err error) {
+	var (
+		param *JobParam
+		names map[string]bool
+	)
+	if !job.IsTemplated() {
+		return
+	}
+	names = make(map[string]bool)
+	for _, param = range job.Params {
+		if !paramNamePattern.MatchString(param.Name) {
+			return fmt.Errorf("%w: 参数名 %q 只能包含字母、数字和下划线，且不能以数字开头", ERR_INVALID_JOB_PARAM, param.Name)
+		}
+		if names[param.Name] {
+			return fmt.Errorf("%w: 参数 %s 重复", ERR_INVALID_JOB_PARAM, param.Name)
+		}
+		names[param.Name] = true
+	}
+	_, err = RenderCommand(&JobExecuteInfo{Job: job, PlanTime: time.Now()})
+	return
+}
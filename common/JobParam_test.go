@@ -0,0 +1,110 @@
+package common
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConvertParamValue(t *testing.T) {
+	var (
+		cases = []struct {
+			paramType string
+			value     interface{}
+			converted interface{}
+			ok        bool
+		}{
+			{"", "abc", "abc", true},
+			{JOB_PARAM_STRING, float64(1), nil, false},
+			{JOB_PARAM_INT, "42", int64(42), true},
+			{JOB_PARAM_INT, float64(42), int64(42), true},
+			{JOB_PARAM_INT, float64(4.2), nil, false},
+			{JOB_PARAM_INT, int64(7), int64(7), true},
+			{JOB_PARAM_INT, "4x", nil, false},
+			{JOB_PARAM_FLOAT, "1.5", float64(1.5), true},
+			{JOB_PARAM_FLOAT, float64(2), float64(2), true},
+			{JOB_PARAM_BOOL, "true", true, true},
+			{JOB_PARAM_BOOL, false, false, true},
+			{JOB_PARAM_BOOL, "yes", nil, false},
+			{"date", "2020-01-01", nil, false},
+		}
+		converted interface{}
+		err       error
+		i         int
+	)
+	for i = range cases {
+		converted, err = ConvertParamValue(&JobParam{Name: "p", Type: cases[i].paramType}, cases[i].value)
+		if cases[i].ok != (err == nil) || (err != nil && !errors.Is(err, ERR_INVALID_JOB_PARAM)) {
+			t.Errorf("%s %#v: err = %v", cases[i].paramType, cases[i].value, err)
+			continue
+		}
+		if err == nil && converted != cases[i].converted {
+			t.Errorf("%s %#v: 得到 %#v，期望 %#v", cases[i].paramType, cases[i].value, converted, cases[i].converted)
+		}
+	}
+}
+
+func TestResolveJobParams(t *testing.T) {
+	var (
+		job = &Job{Name: "job1", Params: []*JobParam{
+			{Name: "db", Default: "orders"},
+			{Name: "days", Type: JOB_PARAM_INT, Default: float64(7)},
+			{Name: "dryRun", Type: JOB_PARAM_BOOL},
+		}}
+		cases = []struct {
+			name      string
+			overrides map[string]interface{}
+			params    map[string]interface{}
+			ok        bool
+		}{
+			{"默认值和零值", nil, map[string]interface{}{"db": "orders", "days": int64(7), "dryRun": false}, true},
+			{"覆盖", map[string]interface{}{"days": "30", "dryRun": "true"}, map[string]interface{}{"db": "orders", "days": int64(30), "dryRun": true}, true},
+			{"不存在的参数", map[string]interface{}{"table": "x"}, nil, false},
+			{"类型错误", map[string]interface{}{"days": "week"}, nil, false},
+		}
+		params map[string]interface{}
+		err    error
+		i      int
+	)
+	for i = range cases {
+		params, err = ResolveJobParams(job, cases[i].overrides)
+		if cases[i].ok != (err == nil) {
+			t.Errorf("%s: err = %v", cases[i].name, err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(params, cases[i].params) {
+			t.Errorf("%s: 得到 %v，期望 %v", cases[i].name, params, cases[i].params)
+		}
+	}
+}
+
+func TestRenderCommand(t *testing.T) {
+	var (
+		planTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		cases    = []struct {
+			name    string
+			job     *Job
+			params  map[string]interface{}
+			command string
+			err     error
+		}{
+			{"不渲染", &Job{Name: "job1", Command: "echo {{.JobName}}"}, nil, "echo {{.JobName}}", nil},
+			{"内置变量", &Job{Name: "job1", Namespace: "a", Command: "echo {{.Namespace}}/{{.JobName}} {{.ExecId}} {{.PlanTime.Format \"2006-01-02\"}}", Template: true}, nil, "echo a/job1 e1 2020-01-02", nil},
+			{"shellquote转义", &Job{Name: "job1", Command: "backup.sh --db={{.Params.db | shellquote}}", Params: []*JobParam{{Name: "db"}}}, map[string]interface{}{"db": "x'; rm -rf /"}, `backup.sh --db='x'\''; rm -rf /'`, nil},
+			{"shellquote非字符串", &Job{Name: "job1", Command: "sleep {{shellquote .Params.n}}", Params: []*JobParam{{Name: "n", Type: JOB_PARAM_INT, Default: float64(3)}}}, nil, "sleep '3'", nil},
+			{"引用不存在的参数", &Job{Name: "job1", Command: "echo {{.Params.x}}", Params: []*JobParam{{Name: "db"}}}, nil, "", ERR_INVALID_COMMAND_TEMPLATE},
+			{"Attempt固定为1", &Job{Name: "job1", Command: "echo {{.Attempt}}", Template: true}, nil, "echo 1", nil},
+			{"语法错误", &Job{Name: "job1", Command: "echo {{.JobName", Template: true}, nil, "", ERR_INVALID_COMMAND_TEMPLATE},
+		}
+		command string
+		err     error
+		i       int
+	)
+	for i = range cases {
+		command, err = RenderCommand(&JobExecuteInfo{Job: cases[i].job, PlanTime: planTime, ExecId: "e1", Params: cases[i].params})
+		if !errors.Is(err, cases[i].err) || command != cases[i].command {
+			t.Errorf("%s: 得到 %q %v，期望 %q %v", cases[i].name, command, err, cases[i].command, cases[i].err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package common
+
+import (
+	"sort"
+	"strings"
+)
+
+// 任务列表的查询条件
+type JobListQuery struct {
+	Tags    []string // 同时包含这些标签
+	Owner   string   // 负责人
+	Team    string   // 所属团队
+	Keyword string   // 在名称、说明、命令、负责人、团队和标签中搜索子串，不区分大小写
+	Sort    string   // 排序字段 name / createTime / updateTime / owner，前面加-表示倒序，为空按全名
+	Skip    int      // 从第几个开始
+	Limit   int      // 返回多少个，0表示不限制
+}
+
+// 任务是否符合查询条件
+func (query *JobListQuery) Match(job *Job) bool {
+	var (
+		tag     string
+		jobTag  string
+		found   bool
+		keyword string
+		fields  []string
+		field   string
+	)
+	if query.Owner != "" && job.Owner != query.Owner {
+		return false
+	}
+	if query.Team != "" && job.Team != query.Team {
+		return false
+	}
+	for _, tag = range query.Tags {
+		found = false
+		for _, jobTag = range job.Tags {
+			if jobTag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if query.Keyword == "" {
+		return true
+	}
+	keyword = strings.ToLower(query.Keyword)
+	fields = append([]string{job.FullName(), job.Description, job.Command, job.Owner, job.Team}, job.Tags...)
+	for _, field = range fields {
+		if strings.Contains(strings.ToLower(field), keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// 过滤、排序并分页，返回当前页和符合条件的总数
+func QueryJobs(jobList []*Job, query *JobListQuery) (page []*Job, total int, err error) {
+	var (
+		sortField string
+		desc      bool
+		less      func(a *Job, b *Job) bool
+		matched   []*Job
+		job       *Job
+		end       int
+	)
+	sortField = query.Sort
+	if strings.HasPrefix(sortField, "-") {
+		sortField = sortField[1:]
+		desc = true
+	}
+	switch sortField {
+	case "", JOB_SORT_NAME:
+		less = func(a *Job, b *Job) bool { return false }
+	case JOB_SORT_CREATE_TIME:
+		less = func(a *Job, b *Job) bool { return a.CreateTime < b.CreateTime }
+	case JOB_SORT_UPDATE_TIME:
+		less = func(a *Job, b *Job) bool { return a.UpdateTime < b.UpdateTime }
+	case JOB_SORT_OWNER:
+		less = func(a *Job, b *Job) bool { return a.Owner < b.Owner }
+	default:
+		err = ERR_INVALID_JOB_SORT
+		return
+	}
+
+	matched = make([]*Job, 0, len(jobList))
+	for _, job = range jobList {
+		if query.Match(job) {
+			matched = append(matched, job)
+		}
+	}
+	// 排序字段相同时按全名，保证翻页时顺序稳定
+	sort.SliceStable(matched, func(i, j int) bool {
+		var (
+			a = matched[i]
+			b = matched[j]
+		)
+		if desc {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return a.FullName() < b.FullName()
+	})
+
+	total = len(matched)
+	if query.Skip >= total {
+		return make([]*Job, 0), total, nil
+	}
+	end = total
+	if query.Limit > 0 && query.Skip+query.Limit < total {
+		end = query.Skip + query.Limit
+	}
+	return matched[query.Skip:end], total, nil
+}
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestJobListQueryMatch(t *testing.T) {
+	var (
+		job = &Job{
+			Namespace:   "infra",
+			Name:        "backup",
+			Command:     "backup.sh --db=orders",
+			Description: "Nightly backup",
+			Owner:       "alice",
+			Team:        "dba",
+			Tags:        []string{"db", "nightly"},
+		}
+		cases = []struct {
+			name  string
+			query JobListQuery
+			match bool
+		}{
+			{"空条件", JobListQuery{}, true},
+			{"负责人", JobListQuery{Owner: "alice"}, true},
+			{"其他负责人", JobListQuery{Owner: "bob"}, false},
+			{"团队", JobListQuery{Team: "ops"}, false},
+			{"同时包含所有标签", JobListQuery{Tags: []string{"db", "nightly"}}, true},
+			{"缺少一个标签", JobListQuery{Tags: []string{"db", "hourly"}}, false},
+			{"关键字不区分大小写", JobListQuery{Keyword: "NIGHTLY BACKUP"}, true},
+			{"关键字匹配全名", JobListQuery{Keyword: "infra/back"}, true},
+			{"关键字匹配命令", JobListQuery{Keyword: "orders"}, true},
+			{"关键字匹配标签", JobListQuery{Keyword: "nightl"}, true},
+			{"关键字不匹配", JobListQuery{Keyword: "restore"}, false},
+		}
+		i int
+	)
+	for i = range cases {
+		if cases[i].query.Match(job) != cases[i].match {
+			t.Errorf("%s: 期望 %v", cases[i].name, cases[i].match)
+		}
+	}
+}
+
+func TestQueryJobs(t *testing.T) {
+	var (
+		jobList = []*Job{
+			{Namespace: "b", Name: "job1", Owner: "carol", CreateTime: 3, UpdateTime: 30},
+			{Namespace: "a", Name: "job2", Owner: "alice", CreateTime: 1, UpdateTime: 30},
+			{Namespace: "a", Name: "job1", Owner: "bob", CreateTime: 2, UpdateTime: 10, Tags: []string{"db"}},
+		}
+		cases = []struct {
+			name  string
+			query JobListQuery
+			names []string
+			total int
+			err   error
+		}{
+			{"默认按全名", JobListQuery{}, []string{"a/job1", "a/job2", "b/job1"}, 3, nil},
+			{"按全名倒序", JobListQuery{Sort: "-name"}, []string{"b/job1", "a/job2", "a/job1"}, 3, nil},
+			{"按创建时间", JobListQuery{Sort: JOB_SORT_CREATE_TIME}, []string{"a/job2", "a/job1", "b/job1"}, 3, nil},
+			{"倒序时修改时间相同的按全名倒序", JobListQuery{Sort: "-" + JOB_SORT_UPDATE_TIME}, []string{"b/job1", "a/job2", "a/job1"}, 3, nil},
+			{"按负责人", JobListQuery{Sort: JOB_SORT_OWNER}, []string{"a/job2", "a/job1", "b/job1"}, 3, nil},
+			{"分页", JobListQuery{Skip: 1, Limit: 1}, []string{"a/job2"}, 3, nil},
+			{"最后一页不足limit", JobListQuery{Skip: 2, Limit: 5}, []string{"b/job1"}, 3, nil},
+			{"超出范围", JobListQuery{Skip: 3, Limit: 1}, []string{}, 3, nil},
+			{"过滤后的总数", JobListQuery{Tags: []string{"db"}}, []string{"a/job1"}, 1, nil},
+			{"不支持的排序字段", JobListQuery{Sort: "command"}, nil, 0, ERR_INVALID_JOB_SORT},
+		}
+		page  []*Job
+		total int
+		err   error
+		i     int
+		j     int
+	)
+	for i = range cases {
+		page, total, err = QueryJobs(jobList, &cases[i].query)
+		if err != cases[i].err {
+			t.Errorf("%s: err = %v，期望 %v", cases[i].name, err, cases[i].err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if total != cases[i].total || len(page) != len(cases[i].names) {
+			t.Errorf("%s: 得到 %d 个，总数 %d，期望 %v，总数 %d", cases[i].name, len(page), total, cases[i].names, cases[i].total)
+			continue
+		}
+		for j = range page {
+			if page[j].FullName() != cases[i].names[j] {
+				t.Errorf("%s: 第%d个是 %s，期望 %s", cases[i].name, j, page[j].FullName(), cases[i].names[j])
+			}
+		}
+	}
+}
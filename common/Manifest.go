@@ -0,0 +1,127 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"sigs.k8s.io/yaml"
+	"sort"
+)
+
+// 任务清单：声明式地描述一组任务，放在git中管理，通过apply同步到etcd
+// 支持YAML和JSON（JSON本身就是合法的YAML）
+//
+//	version: 1
+//	namespace: team-a
+//	jobs:
+//	  - name: job1
+//	    command: echo hello
+//	    cronExpr: "*/5 * * * * *"
+type JobManifest struct {
+	Version   int    `json:"version"`             // 清单格式版本，不填默认为1
+	Namespace string `json:"namespace,omitempty"` // 没有指定命名空间的任务属于该命名空间，为空表示default
+	Jobs      []*Job `json:"jobs"`
+}
+
+// 应用清单时单个任务的变更
+type JobChange struct {
+	Action string            `json:"action"`           // create / update / delete
+	Name   string            `json:"name"`             // 任务全名 命名空间/任务名称
+	Fields []*JobFieldChange `json:"fields,omitempty"` // update时变化的字段
+	OldJob *Job              `json:"oldJob,omitempty"` // update / delete 时的旧任务
+	NewJob *Job              `json:"newJob,omitempty"` // create / update 时的新任务
+}
+
+// 变化的字段，值为json编码，字段不存在时为空字符串
+type JobFieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// 应用清单的结果
+type ApplyResult struct {
+	Changes       []*JobChange `json:"changes"`            // 按 create / update / delete 和任务名称排序
+	Unchanged     int          `json:"unchanged"`          // 清单中没有变化的任务数
+	NotInManifest []string     `json:"notInManifest"`      // 清单涉及的命名空间中，不在清单中、因为没有prune而保留的任务
+	Existing      []string     `json:"existing,omitempty"` // 只新建时，已经存在因而没有写入的任务
+	Applied       bool         `json:"applied"`            // dryRun时为false
+	Revision      int64        `json:"revision"`           // 应用后的etcd revision
+}
+
+// 解析YAML或JSON格式的任务清单，未知字段视为错误，避免字段名拼错后被静默忽略
+func ParseManifest(content []byte) (manifest *JobManifest, err error) {
+	var (
+		jsonContent []byte
+		decoder     *json.Decoder
+	)
+	if jsonContent, err = yaml.YAMLToJSON(content); err != nil {
+		return
+	}
+	manifest = &JobManifest{}
+	decoder = json.NewDecoder(bytes.NewReader(jsonContent))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(manifest); err != nil {
+		return
+	}
+	if manifest.Version == 0 {
+		manifest.Version = JOB_MANIFEST_VERSION
+	}
+	if manifest.Version != JOB_MANIFEST_VERSION {
+		err = ERR_UNSUPPORTED_MANIFEST_VERSION
+	}
+	return
+}
+
+// 比较两个任务，返回按字段名排序的变化字段
+func DiffJob(oldJob *Job, newJob *Job) (fields []*JobFieldChange, err error) {
+	var (
+		oldFields map[string]json.RawMessage
+		newFields map[string]json.RawMessage
+		names     []string
+		name      string
+		oldValue  json.RawMessage
+		newValue  json.RawMessage
+		ok        bool
+	)
+	if oldFields, err = jobFields(oldJob); err != nil {
+		return
+	}
+	if newFields, err = jobFields(newJob); err != nil {
+		return
+	}
+	// 两边字段的并集
+	names = make([]string, 0, len(newFields))
+	for name = range newFields {
+		names = append(names, name)
+	}
+	for name = range oldFields {
+		if _, ok = newFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	fields = make([]*JobFieldChange, 0)
+	for _, name = range names {
+		oldValue = oldFields[name]
+		newValue = newFields[name]
+		// 同一个结构体编码出来的json是确定的，可以直接比较
+		if bytes.Equal(oldValue, newValue) {
+			continue
+		}
+		fields = append(fields, &JobFieldChange{Field: name, Old: string(oldValue), New: string(newValue)})
+	}
+	return
+}
+
+// 任务的顶层字段 -> json编码的值
+func jobFields(job *Job) (fields map[string]json.RawMessage, err error) {
+	var (
+		content []byte
+	)
+	if content, err = json.Marshal(job); err != nil {
+		return
+	}
+	err = json.Unmarshal(content, &fields)
+	return
+}
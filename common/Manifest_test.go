@@ -0,0 +1,98 @@
+package common
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseManifest(t *testing.T) {
+	var (
+		cases []struct {
+			name    string
+			content string
+			jobs    int
+			err     error
+			invalid bool
+		}
+		manifest *JobManifest
+		i        int
+		err      error
+	)
+	cases = []struct {
+		name    string
+		content string
+		jobs    int
+		err     error
+		invalid bool
+	}{
+		{"不填版本默认为1", `{"jobs":[{"name":"job1","command":"echo","cronExpr":"* * * * *"}]}`, 1, nil, false},
+		{"指定命名空间", `{"version":1,"namespace":"team-a","jobs":[]}`, 0, nil, false},
+		{"不支持的版本", `{"version":2,"jobs":[]}`, 0, ERR_UNSUPPORTED_MANIFEST_VERSION, false},
+		{"字段名拼错", `{"jobs":[{"name":"job1","comand":"echo"}]}`, 0, nil, true},
+	}
+	for i = range cases {
+		manifest, err = ParseManifest([]byte(cases[i].content))
+		if cases[i].invalid {
+			if err == nil {
+				t.Errorf("%s: 期望解析失败", cases[i].name)
+			}
+			continue
+		}
+		if cases[i].err != nil {
+			if !errors.Is(err, cases[i].err) {
+				t.Errorf("%s: 期望 %v，得到 %v", cases[i].name, cases[i].err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", cases[i].name, err)
+			continue
+		}
+		if manifest.Version != JOB_MANIFEST_VERSION || len(manifest.Jobs) != cases[i].jobs {
+			t.Errorf("%s: 版本 %d 任务数 %d", cases[i].name, manifest.Version, len(manifest.Jobs))
+		}
+	}
+}
+
+func TestDiffJob(t *testing.T) {
+	var (
+		base  = &Job{Name: "job1", Command: "echo 1", CronExpr: "* * * * *"}
+		cases []struct {
+			name   string
+			newJob *Job
+			fields []string
+		}
+		fieldArr []*JobFieldChange
+		i        int
+		j        int
+		err      error
+	)
+	cases = []struct {
+		name   string
+		newJob *Job
+		fields []string
+	}{
+		{"没有变化", &Job{Name: "job1", Command: "echo 1", CronExpr: "* * * * *"}, nil},
+		{"修改命令", &Job{Name: "job1", Command: "echo 2", CronExpr: "* * * * *"}, []string{"command"}},
+		{"新增字段并按字段名排序", &Job{Name: "job1", Command: "echo 1", CronExpr: "*/5 * * * *", Owner: "alice"}, []string{"cronExpr", "owner"}},
+		{"修改通知规则", &Job{Name: "job1", Command: "echo 1", CronExpr: "* * * * *", Notify: &JobNotify{OnFailure: true}}, []string{"notify"}},
+	}
+	for i = range cases {
+		if fieldArr, err = DiffJob(base, cases[i].newJob); err != nil {
+			t.Fatal(err)
+		}
+		if len(fieldArr) != len(cases[i].fields) {
+			t.Errorf("%s: 变化的字段 %d 个，期望 %v", cases[i].name, len(fieldArr), cases[i].fields)
+			continue
+		}
+		for j = range fieldArr {
+			if fieldArr[j].Field != cases[i].fields[j] {
+				t.Errorf("%s: 第%d个变化字段 %s，期望 %s", cases[i].name, j, fieldArr[j].Field, cases[i].fields[j])
+			}
+		}
+	}
+	// 字段不存在时旧值为空字符串
+	if fieldArr, _ = DiffJob(base, cases[2].newJob); fieldArr[1].Old != "" || fieldArr[1].New != `"alice"` {
+		t.Errorf("新增字段的旧值和新值不对: %+v", fieldArr[1])
+	}
+}
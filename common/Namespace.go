@@ -0,0 +1,66 @@
+package common
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 命名空间配额，master.json中配置，master启动时写入 /cron/namespaces/命名空间 供worker读取
+type NamespaceQuota struct {
+	MaxJobs           int `json:"maxJobs"`           // 最多任务数，0表示不限制
+	MaxConcurrentRuns int `json:"maxConcurrentRuns"` // 整个集群同时执行的任务数，0表示不限制
+}
+
+var (
+	// 和k8s的命名空间规则一致
+	namespacePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+)
+
+// 校验命名空间名称
+func ValidateNamespace(namespace string) error {
+	if !namespacePattern.MatchString(namespace) {
+		return ERR_INVALID_NAMESPACE
+	}
+	return nil
+}
+
+// 空命名空间表示默认命名空间
+func NormalizeNamespace(namespace string) string {
+	if namespace == "" {
+		return DEFAULT_NAMESPACE
+	}
+	return namespace
+}
+
+// 任务全名 命名空间/任务名称，作为etcd key、日志和监控指标中任务的标识
+func JobFullName(namespace string, name string) string {
+	return NormalizeNamespace(namespace) + "/" + name
+}
+
+// 拆分任务全名，没有命名空间的是升级前的老任务，属于默认命名空间
+func SplitJobFullName(fullName string) (namespace string, name string) {
+	var (
+		index int
+	)
+	if index = strings.Index(fullName, "/"); index < 0 {
+		return DEFAULT_NAMESPACE, fullName
+	}
+	return fullName[:index], fullName[index+1:]
+}
+
+// 只有全名的任务（删除、强杀、手动执行事件）
+func JobFromFullName(fullName string) (job *Job) {
+	job = &Job{}
+	job.Namespace, job.Name = SplitJobFullName(fullName)
+	return
+}
+
+// 任务全名
+func (job *Job) FullName() string {
+	return JobFullName(job.Namespace, job.Name)
+}
+
+// 从etcd的key中提取命名空间  /cron/namespaces/default -> default
+func ExtractNamespaceName(namespaceKey string) string {
+	return strings.TrimPrefix(namespaceKey, JOB_NAMESPACE_DIR)
+}
@@ -2,6 +2,8 @@ package common
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"github.com/gorhill/cronexpr"
 	"strings"
@@ -10,25 +12,110 @@ import (
 
 // 定时任务
 type Job struct {
-	Name     string `json:"name"`     // 任务名称
-	Command  string `json:"command"`  //shell命令
-	CronExpr string `json:"cronExpr"` // cron表达式
+	Namespace string `json:"namespace,omitempty"` // 命名空间，为空表示default
+	Name      string `json:"name"`                // 任务名称，同一命名空间内唯一
+	Command   string `json:"command"`             //shell命令
+	CronExpr  string `json:"cronExpr"`            // cron表达式
+	Mode      string `json:"mode"`                // 调度模式：空/single 抢锁单节点执行，broadcast 所有节点都执行
+	Timeout   int    `json:"timeout"`             // 执行超时时间，秒，0表示不限制
+
+	ExpectSuccessWithin int64 `json:"expectSuccessWithin"` // 期望在多少秒内至少成功一次，超过则告警，0表示不检查
+
+	Retention *LogRetention `json:"retention,omitempty"` // 日志保留策略，为空则使用master的全局配置
+	Notify    *JobNotify    `json:"notify,omitempty"`    // 通知规则，为空则不通知
+
+	Description string   `json:"description,omitempty"` // 任务说明
+	Owner       string   `json:"owner,omitempty"`       // 负责人
+	Team        string   `json:"team,omitempty"`        // 所属团队
+	Tags        []string `json:"tags,omitempty"`        // 自定义标签
+
+	CreateTime int64 `json:"createTime,omitempty"` // 创建时间，毫秒，由master保存时填写
+	UpdateTime int64 `json:"updateTime,omitempty"` // 最近修改时间，毫秒，由master保存时填写
+
+	Params   []*JobParam `json:"params,omitempty"`   // 任务参数，在命令中通过 {{.Params.参数名}} 引用
+	Template bool        `json:"template,omitempty"` // 没有参数时也按模板渲染命令，用于引用 {{.PlanTime}} 等变量
+}
+
+// 任务通知规则
+type JobNotify struct {
+	OnFailure           bool     `json:"onFailure"`           // 每次失败都通知
+	OnTimeout           bool     `json:"onTimeout"`           // 执行超时通知
+	ConsecutiveFailures int      `json:"consecutiveFailures"` // 连续失败达到N次时通知一次，0表示不通知
+	OnRecovery          bool     `json:"onRecovery"`          // 失败后第一次成功时通知
+	Targets             []string `json:"targets"`             // 通知渠道名称，对应master.json中的notifyTargets，为空则发往所有渠道
+}
+
+// 任务成功心跳的检查状态
+type JobWatchdogState struct {
+	ExpectSuccessWithin int64 `json:"expectSuccessWithin"` // 秒
+	LastSuccessTime     int64 `json:"lastSuccessTime"`     // 最近一次成功的结束时间，毫秒，0表示还没有成功过
+	Overdue             bool  `json:"overdue"`             // 是否已经超过期望时间没有成功
+	OverdueSince        int64 `json:"overdueSince"`        // 从什么时候开始算超期，毫秒
+	CheckTime           int64 `json:"checkTime"`           // 最近一次检查时间，毫秒
+}
+
+// 任务列表中的一项：任务本身加上运行状态
+type JobListItem struct {
+	*Job
+	Watchdog *JobWatchdogState `json:"watchdog,omitempty"` // 未配置expectSuccessWithin时为空
+}
+
+// 一页任务
+type JobListPage struct {
+	Total int            `json:"total"` // 符合条件的总数
+	Jobs  []*JobListItem `json:"jobs"`
+}
+
+// 一条通知
+type Notification struct {
+	Event               string  `json:"event"` // failure / timeout / consecutive_failures / recovery / overdue / overdue_recovery
+	JobName             string  `json:"jobName"`
+	Message             string  `json:"message"`             // 可读的通知内容
+	ConsecutiveFailures int     `json:"consecutiveFailures"` // 当前连续失败次数，恢复时为恢复前的次数
+	Log                 *JobLog `json:"log"`                 // 触发通知的执行日志，输出只保留末尾一部分
+	Time                int64   `json:"time"`                // 通知产生时间，毫秒
+}
+
+// 日志保留策略，任务上0表示使用全局配置，-1表示不限制；全局配置和生效的策略中0表示不限制
+type LogRetention struct {
+	MaxAge   int64 `json:"maxAge"`   // 最长保留时间，秒
+	MaxCount int64 `json:"maxCount"` // 最多保留条数
+}
+
+// 校验任务上的保留策略
+func (retention *LogRetention) Validate() error {
+	if retention.MaxAge < LOG_RETENTION_UNLIMITED || retention.MaxCount < LOG_RETENTION_UNLIMITED {
+		return ERR_INVALID_LOG_RETENTION
+	}
+	return nil
+}
+
+// 单个任务的日志量
+type JobLogStats struct {
+	JobName    string        `json:"jobName"`
+	Count      int64         `json:"count"`      // 日志条数
+	OldestTime int64         `json:"oldestTime"` // 最早一条日志的开始时间，毫秒
+	NewestTime int64         `json:"newestTime"` // 最新一条日志的开始时间，毫秒
+	Retention  *LogRetention `json:"retention"`  // 生效的保留策略
 }
 
 // 任务调度计划
 type JobSchedulePlan struct {
-	Job      *Job                 // 要调度的任务
-	Expr     *cronexpr.Expression // 解析好的cronexpr表达式
-	NextTime time.Time            // 下次调度时间
+	Job      *Job                   // 要调度的任务
+	Expr     *cronexpr.Expression   // 解析好的cronexpr表达式
+	NextTime time.Time              // 下次调度时间
+	Params   map[string]interface{} // 手动执行时覆盖的参数
 }
 
 // 任务执行状态
 type JobExecuteInfo struct {
-	Job        *Job               // 任务信息
-	PlanTime   time.Time          // 理论上的调度时间
-	RealTime   time.Time          // 实际的调度时间
-	CancelCtx  context.Context    // 用于取消任务command的context
-	CancelFunc context.CancelFunc // 用于取消任务command的方法
+	Job        *Job                   // 任务信息
+	ExecId     string                 // 本次执行的唯一ID
+	PlanTime   time.Time              // 理论上的调度时间
+	RealTime   time.Time              // 实际的调度时间
+	Params     map[string]interface{} // 手动执行时覆盖的参数，为空使用默认值
+	CancelCtx  context.Context        // 用于取消任务command的context
+	CancelFunc context.CancelFunc     // 用于取消任务command的方法
 }
 
 // HTTP接口应答
@@ -38,15 +125,54 @@ type Response struct {
 	Data  interface{} `json:"data"`
 }
 
+// REST接口(/api/v1)的错误应答 {"error": {"code": "JOB_NOT_FOUND", "message": "任务不存在"}}
+type ApiErrorResponse struct {
+	Error *ApiError `json:"error"`
+}
+
+type ApiError struct {
+	Code    string `json:"code"`    // 错误码，见 API_ERR_*
+	Message string `json:"message"` // 可读的错误信息
+}
+
 // 任务变化事件
 type JobEvent struct {
 	EventType int // save / delete
 	Job       *Job
+	Params    map[string]interface{} // 手动执行时覆盖的参数
+}
+
+// 手动执行通知，写入 /cron/run/任务全名
+type JobRunRequest struct {
+	Params map[string]interface{} `json:"params,omitempty"` // 覆盖的参数，为空使用默认值
+}
+
+// 正在执行的任务，worker写入 /cron/running/任务名/execId，时间都是毫秒
+type JobRunState struct {
+	JobName      string `json:"jobName"`
+	ExecId       string `json:"execId"`
+	Worker       string `json:"worker"`
+	PlanTime     int64  `json:"planTime"`     // 计划开始时间
+	ScheduleTime int64  `json:"scheduleTime"` // 实际调度时间
+	StartTime    int64  `json:"startTime"`    // 开始执行时间
+}
+
+// 实时事件，master监听etcd得到，通过 /events 推送
+type Event struct {
+	Revision int64        `json:"revision"`           // 事件对应的etcd revision，断线后从下一个revision继续
+	Type     string       `json:"type"`               // 见 EVENT_*
+	JobName  string       `json:"jobName,omitempty"`  // 任务和执行事件
+	WorkerId string       `json:"workerId,omitempty"` // worker和执行事件
+	Job      *Job         `json:"job,omitempty"`      // job.saved
+	Run      *JobRunState `json:"run,omitempty"`      // run.started
+	Log      *JobLog      `json:"log,omitempty"`      // run.finished，输出只保留末尾一部分
+	Worker   *WorkerInfo  `json:"worker,omitempty"`   // worker.joined
 }
 
 // 任务执行结果
 type JobExecuteResult struct {
 	ExecuteInfo *JobExecuteInfo // 执行状态
+	Command     string          // 实际执行的命令，模板渲染之后
 	Output      []byte          // 脚本输出
 	Err         error           // 脚本错误原因
 	StartTime   time.Time       // 启动时间
@@ -57,27 +183,130 @@ type JobExecuteResult struct {
 type JobLog struct {
 	JobName      string `bson:"jobName" json:"jobName"`
 	Command      string `bson:"command" json:"command"`
+	ExecId       string `bson:"execId" json:"execId"` // 执行ID，与worker日志中的execId对应
+	Worker       string `bson:"worker" json:"worker"` // 执行该任务的worker节点
 	Err          string `bson:"err" json:"err"`
 	Output       string `bson:"output" json:"output"`
+	TimedOut     bool   `bson:"timedOut" json:"timedOut"`         // 是否因超时被终止
 	PlanTime     int64  `bson:"planTime" json:"planTime"`         // 计划开始时间
 	ScheduleTime int64  `bson:"scheduleTime" json:"scheduleTime"` // 实际调度时间
 	StartTime    int64  `bson:"startTime" json:"startTime"`       // 任务执行开始时间
 	EndTime      int64  `bson:"endTime" json:"endTime"`           // 任务执行结束时间
 }
 
+// 广播任务单个节点的执行情况
+type BroadcastWorkerResult struct {
+	Worker    string `json:"worker"`
+	Err       string `json:"err"`
+	StartTime int64  `json:"startTime"`
+	EndTime   int64  `json:"endTime"`
+}
+
+// 广播任务某一次调度（同一个planTime）在所有节点上的汇总
+type BroadcastTick struct {
+	PlanTime     int64                    `json:"planTime"`
+	Workers      []*BroadcastWorkerResult `json:"workers"`
+	SuccessCount int                      `json:"successCount"`
+	FailCount    int                      `json:"failCount"`
+}
+
 // 日志批次
 type LogBatch struct {
-	Logs []interface{} // 多条日志
+	Logs []*JobLog // 多条日志
 }
 
-// 任务日志过滤条件
+// 任务日志过滤条件，零值字段不参与过滤
 type JobLogFilter struct {
-	JobName string `bson:"jobName"`
+	JobName   string // 任务全名 命名空间/任务名称，为空表示所有任务
+	Namespace string // 命名空间，为空表示所有命名空间，JobName不为空时忽略
+	StartFrom int64  // 任务开始时间 >= StartFrom，毫秒
+	StartTo   int64  // 任务开始时间 < StartTo，毫秒
+	EndFrom   int64  // 任务结束时间 >= EndFrom，毫秒
+	Status    string // success 成功 / failed 失败
+	Worker    string // 执行节点
+	Keyword   string // 在脚本输出和错误原因中搜索子串，必须同时指定JobName或StartFrom
 }
 
-// 任务日志排序规则
-type SortLogByStartTime struct {
-	SortOrder int `bson:"startTime"` //按照startTime排序，-1
+// 检查过滤条件，关键字搜索在存储端无法使用索引，只允许在一个任务或一段时间的日志中搜索
+func (filter *JobLogFilter) Validate() error {
+	if filter.Keyword != "" && filter.JobName == "" && filter.StartFrom <= 0 {
+		return ERR_LOG_KEYWORD_UNSCOPED
+	}
+	return nil
+}
+
+// 任务执行统计，时间单位都是毫秒
+type JobStats struct {
+	JobName        string  `json:"jobName"`
+	Total          int64   `json:"total"`          // 执行次数
+	SuccessCount   int64   `json:"successCount"`   // 成功次数
+	FailCount      int64   `json:"failCount"`      // 失败次数
+	SuccessRate    float64 `json:"successRate"`    // 成功率 0~1，没有执行记录时为1
+	DurationP50    int64   `json:"durationP50"`    // 执行耗时中位数
+	DurationP95    int64   `json:"durationP95"`    // 执行耗时95分位
+	DurationMax    int64   `json:"durationMax"`    // 最长执行耗时
+	ScheduleLagAvg int64   `json:"scheduleLagAvg"` // 平均调度延迟 scheduleTime - planTime
+	ScheduleLagMax int64   `json:"scheduleLagMax"` // 最大调度延迟
+}
+
+// 分页的任务日志
+type JobLogPage struct {
+	Total int64     `json:"total"` // 符合条件的总条数
+	Logs  []*JobLog `json:"logs"`
+}
+
+// 任务日志查询条件，由各个日志存储实现翻译成自己的查询
+type JobLogQuery struct {
+	Filter    JobLogFilter // 过滤条件
+	SortField string       // 排序字段 startTime / endTime / planTime，默认startTime
+	SortOrder int          // 1 正序，-1 倒序
+	Skip      int64        // 从第几条开始
+	Limit     int64        // 返回多少条，0表示不限制
+}
+
+// 解析日志排序参数 startTime / endTime / planTime，前面加-表示倒序，为空按开始时间倒序
+func ParseLogSort(sort string) (field string, order int, err error) {
+	if sort == "" {
+		return LOG_SORT_START_TIME, -1, nil
+	}
+	order = 1
+	if sort[0] == '-' {
+		sort = sort[1:]
+		order = -1
+	}
+	switch sort {
+	case LOG_SORT_START_TIME, LOG_SORT_END_TIME, LOG_SORT_PLAN_TIME:
+		return sort, order, nil
+	}
+	return "", 0, ERR_INVALID_LOG_SORT
+}
+
+// worker节点注册信息，保存在 /cron/workers/workerID 的value中
+type WorkerInfo struct {
+	ID             string            `json:"id"`             // 节点ID，同一台主机可以运行多个worker
+	IP             string            `json:"ip"`             // 节点对外地址
+	Hostname       string            `json:"hostname"`       // 主机名
+	Pid            int               `json:"pid"`            // 进程号
+	Version        string            `json:"version"`        // worker版本
+	StartTime      int64             `json:"startTime"`      // 进程启动时间，毫秒
+	Labels         map[string]string `json:"labels"`         // 节点标签
+	CpuNum         int               `json:"cpuNum"`         // cpu核数
+	MemTotal       uint64            `json:"memTotal"`       // 内存总量，字节
+	MaxConcurrency int               `json:"maxConcurrency"` // 最大并发任务数（执行槽位），0表示不限制
+	RunningJobs    int               `json:"runningJobs"`    // 当前正在执行的任务数（已占用槽位）
+	FreeSlots      int               `json:"freeSlots"`      // 空闲槽位，不限制时为-1
+	State          string            `json:"state"`          // 调度状态 active/cordoned/draining/drained
+	LogSink        *LogSinkStats     `json:"logSink"`        // 日志模块状态
+	UpdateTime     int64             `json:"updateTime"`     // 最近一次心跳上报时间，毫秒
+}
+
+// worker日志模块的运行状态
+type LogSinkStats struct {
+	QueueLen      int   `json:"queueLen"`      // 内存队列中等待的日志条数
+	SpoolSegments int   `json:"spoolSegments"` // 预写文件中待补发的批次数
+	SpoolLogs     int   `json:"spoolLogs"`     // 预写文件中待补发的日志条数
+	DroppedLogs   int64 `json:"droppedLogs"`   // 丢弃的日志条数
+	FlushErrors   int64 `json:"flushErrors"`   // 写mongodb失败的次数
 }
 
 // 应答方法
@@ -106,16 +335,26 @@ func UnpackJob(value []byte) (ret *Job, err error) {
 	return job, nil
 }
 
-// 从etcd的key中提取任务名称  /cron/jobs/job10 -> job10
+// 从etcd的key中提取任务全名  /cron/jobs/default/job10 -> default/job10
 func ExtractJobName(jobKey string) string {
 	return strings.TrimPrefix(jobKey, JOB_SAVE_DIR)
 }
 
-// 从etcd的key中提取任务名称  /cron/killer/job10 -> job10
+// 从etcd的key中提取任务全名  /cron/killer/default/job10 -> default/job10
 func ExtractKillerName(killerKey string) string {
 	return strings.TrimPrefix(killerKey, JOB_KILLER_DIR)
 }
 
+// 从etcd的key中提取任务全名  /cron/run/default/job10 -> default/job10
+func ExtractRunName(runKey string) string {
+	return strings.TrimPrefix(runKey, JOB_RUN_DIR)
+}
+
+// 从etcd的key中提取任务全名  /cron/result/default/job10 -> default/job10
+func ExtractResultName(resultKey string) string {
+	return strings.TrimPrefix(resultKey, JOB_RESULT_DIR)
+}
+
 // 任务变化事件有两种，1 更新任务 2 删除任务
 func BuildJobEvent(eventType int, job *Job) (jobEvent *JobEvent) {
 	return &JobEvent{
@@ -145,14 +384,56 @@ func BuildJobSchedulerPlan(job *Job) (jobSchedulePlan *JobSchedulePlan, err erro
 func BuildJobExecuteInfo(jobSchedulePlan *JobSchedulePlan) (jobExecuteInfo *JobExecuteInfo) {
 	jobExecuteInfo = &JobExecuteInfo{
 		Job:      jobSchedulePlan.Job,
+		ExecId:   genExecId(),
 		PlanTime: jobSchedulePlan.NextTime, // 计划调度时间
 		RealTime: time.Now(),               // 真实调度时间
+		Params:   jobSchedulePlan.Params,
 	}
 	jobExecuteInfo.CancelCtx, jobExecuteInfo.CancelFunc = context.WithCancel(context.TODO())
 	return
 }
 
-// 提取worker的ip
-func ExtractWorkerIP(WorkerKey string) string {
-	return strings.TrimPrefix(WorkerKey, JOB_WORKER_DIR)
+// 生成执行ID：16位随机十六进制
+func genExecId() string {
+	var (
+		buf []byte
+	)
+	buf = make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// 是否为广播任务
+func (job *Job) IsBroadcast() bool {
+	return job.Mode == JOB_MODE_BROADCAST
+}
+
+// 反序列化worker注册信息
+func UnpackWorkerInfo(value []byte) (ret *WorkerInfo, err error) {
+	var (
+		workerInfo *WorkerInfo
+	)
+	workerInfo = &WorkerInfo{}
+	if err = json.Unmarshal(value, workerInfo); err != nil {
+		return
+	}
+	return workerInfo, nil
+}
+
+// 提取worker的ID  /cron/workers/host1-3f2a9c -> host1-3f2a9c
+func ExtractWorkerID(workerKey string) string {
+	return strings.TrimPrefix(workerKey, JOB_WORKER_DIR)
+}
+
+// 从 /cron/running/default/job10/execId 中提取任务全名 default/job10
+func ExtractRunningName(runningKey string) string {
+	var (
+		name  string
+		index int
+	)
+	name = strings.TrimPrefix(runningKey, JOB_RUNNING_DIR)
+	if index = strings.LastIndex(name, "/"); index >= 0 {
+		name = name[:index]
+	}
+	return name
 }
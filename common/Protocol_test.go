@@ -0,0 +1,79 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestParseLogSort(t *testing.T) {
+	var (
+		cases = []struct {
+			sort  string
+			field string
+			order int
+			err   error
+		}{
+			{"", LOG_SORT_START_TIME, -1, nil},
+			{"startTime", LOG_SORT_START_TIME, 1, nil},
+			{"-endTime", LOG_SORT_END_TIME, -1, nil},
+			{"endTime", LOG_SORT_END_TIME, 1, nil},
+			{"-planTime", LOG_SORT_PLAN_TIME, -1, nil},
+			{"output", "", 0, ERR_INVALID_LOG_SORT},
+			{"-", "", 0, ERR_INVALID_LOG_SORT},
+		}
+		field string
+		order int
+		err   error
+		i     int
+	)
+	for i = range cases {
+		field, order, err = ParseLogSort(cases[i].sort)
+		if field != cases[i].field || order != cases[i].order || err != cases[i].err {
+			t.Errorf("%q: 得到 %q %d %v，期望 %q %d %v", cases[i].sort, field, order, err, cases[i].field, cases[i].order, cases[i].err)
+		}
+	}
+}
+
+func TestJobLogFilterValidate(t *testing.T) {
+	var (
+		cases = []struct {
+			filter JobLogFilter
+			err    error
+		}{
+			{JobLogFilter{}, nil},
+			{JobLogFilter{Namespace: "a", Status: LOG_STATUS_FAILED}, nil},
+			{JobLogFilter{Keyword: "timeout"}, ERR_LOG_KEYWORD_UNSCOPED},
+			{JobLogFilter{Keyword: "timeout", Namespace: "a", StartTo: 100}, ERR_LOG_KEYWORD_UNSCOPED},
+			{JobLogFilter{Keyword: "timeout", JobName: "a/job1"}, nil},
+			{JobLogFilter{Keyword: "timeout", StartFrom: 100}, nil},
+		}
+		err error
+		i   int
+	)
+	for i = range cases {
+		if err = cases[i].filter.Validate(); err != cases[i].err {
+			t.Errorf("%+v: 得到 %v，期望 %v", cases[i].filter, err, cases[i].err)
+		}
+	}
+}
+
+func TestLogRetentionValidate(t *testing.T) {
+	var (
+		cases = []struct {
+			retention LogRetention
+			err       error
+		}{
+			{LogRetention{}, nil},
+			{LogRetention{MaxAge: 3600, MaxCount: 100}, nil},
+			{LogRetention{MaxAge: LOG_RETENTION_UNLIMITED, MaxCount: LOG_RETENTION_UNLIMITED}, nil},
+			{LogRetention{MaxAge: -2}, ERR_INVALID_LOG_RETENTION},
+			{LogRetention{MaxCount: -100}, ERR_INVALID_LOG_RETENTION},
+		}
+		err error
+		i   int
+	)
+	for i = range cases {
+		if err = cases[i].retention.Validate(); err != cases[i].err {
+			t.Errorf("%+v: 得到 %v，期望 %v", cases[i].retention, err, cases[i].err)
+		}
+	}
+}
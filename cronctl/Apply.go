@@ -0,0 +1,231 @@
+package cronctl
+
+import (
+	"../common"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 可以重复指定的参数，例如 -f a.yaml -f b.yaml
+type stringsFlag []string
+
+func (values *stringsFlag) String() string {
+	return strings.Join(*values, ",")
+}
+
+func (values *stringsFlag) Set(value string) error {
+	*values = append(*values, value)
+	return nil
+}
+
+// 展开清单路径，目录取其中的 .yaml / .yml / .json 文件（不递归）
+func manifestFiles(paths []string) (files []string, err error) {
+	var (
+		path     string
+		info     os.FileInfo
+		matches  []string
+		dirFiles []string
+		pattern  string
+	)
+	files = make([]string, 0)
+	for _, path = range paths {
+		if path == "-" {
+			files = append(files, path)
+			continue
+		}
+		if info, err = os.Stat(path); err != nil {
+			return
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		dirFiles = make([]string, 0)
+		for _, pattern = range []string{"*.yaml", "*.yml", "*.json"} {
+			if matches, err = filepath.Glob(filepath.Join(path, pattern)); err != nil {
+				return
+			}
+			dirFiles = append(dirFiles, matches...)
+		}
+		sort.Strings(dirFiles)
+		files = append(files, dirFiles...)
+	}
+	return
+}
+
+// 读取并合并多个清单文件，在本地先做一次解析，错误信息可以带上文件名
+// 每个文件的命名空间写到各自的任务上，文件中也没有命名空间的任务使用namespace
+func loadManifest(paths []string, namespace string) (manifest *common.JobManifest, err error) {
+	var (
+		files    []string
+		file     string
+		content  []byte
+		part     *common.JobManifest
+		job      *common.Job
+		fileOf   map[string]string // 任务全名 -> 所在文件
+		lastFile string
+		ok       bool
+	)
+	if files, err = manifestFiles(paths); err != nil {
+		return
+	}
+	if len(files) == 0 {
+		err = newUsageError("没有找到清单文件")
+		return
+	}
+	manifest = &common.JobManifest{Version: common.JOB_MANIFEST_VERSION, Namespace: namespace, Jobs: make([]*common.Job, 0)}
+	fileOf = make(map[string]string)
+	for _, file = range files {
+		if file == "-" {
+			content, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			content, err = ioutil.ReadFile(file)
+		}
+		if err != nil {
+			return
+		}
+		if part, err = common.ParseManifest(content); err != nil {
+			err = fmt.Errorf("%s: %w", file, err)
+			return
+		}
+		for _, job = range part.Jobs {
+			if job.Namespace == "" {
+				job.Namespace = part.Namespace
+			}
+			if job.Namespace == "" {
+				job.Namespace = namespace
+			}
+			if lastFile, ok = fileOf[job.FullName()]; ok {
+				err = fmt.Errorf("%w: %s（%s 和 %s）", common.ERR_DUPLICATE_JOB_NAME, job.FullName(), lastFile, file)
+				return
+			}
+			fileOf[job.FullName()] = file
+			manifest.Jobs = append(manifest.Jobs, job)
+		}
+	}
+	return
+}
+
+// 打印变更，类似 diff 的 +/~/- 格式
+func printChanges(ctx *Context, result *common.ApplyResult, prune bool) {
+	var (
+		change *common.JobChange
+		field  *common.JobFieldChange
+		counts map[string]int
+	)
+	counts = make(map[string]int)
+	for _, change = range result.Changes {
+		counts[change.Action]++
+		switch change.Action {
+		case common.JOB_CHANGE_CREATE:
+			fmt.Fprintf(ctx.stdout, "+ %s\n", change.Name)
+		case common.JOB_CHANGE_UPDATE:
+			fmt.Fprintf(ctx.stdout, "~ %s\n", change.Name)
+			for _, field = range change.Fields {
+				fmt.Fprintf(ctx.stdout, "    %s: %s -> %s\n", field.Field, orDash(field.Old), orDash(field.New))
+			}
+		case common.JOB_CHANGE_DELETE:
+			fmt.Fprintf(ctx.stdout, "- %s\n", change.Name)
+		}
+	}
+	fmt.Fprintf(ctx.stdout, "create: %d, update: %d, delete: %d, unchanged: %d\n",
+		counts[common.JOB_CHANGE_CREATE], counts[common.JOB_CHANGE_UPDATE], counts[common.JOB_CHANGE_DELETE], result.Unchanged)
+	if !prune && len(result.NotInManifest) > 0 {
+		fmt.Fprintf(ctx.stdout, "%d 个任务不在清单中，使用 -prune 删除: %s\n", len(result.NotInManifest), strings.Join(result.NotInManifest, ", "))
+	}
+}
+
+// apply和diff共用：读取清单，提交给master
+func submitManifest(ctx *Context, cmd *command, args []string, dryRun bool) (result *common.ApplyResult, prune bool, err error) {
+	var (
+		fs         *flag.FlagSet
+		files      stringsFlag
+		positional []string
+		manifest   *common.JobManifest
+		content    []byte
+		client     *Client
+	)
+	fs = ctx.flagSet(cmd)
+	fs.Var(&files, "f", "清单文件或目录，YAML或JSON格式，可以指定多次，- 表示标准输入")
+	fs.BoolVar(&prune, "prune", false, "删除清单中没有的任务，任务日志一起删除")
+	if !dryRun {
+		fs.BoolVar(&dryRun, "dry-run", false, "只显示变更，不写入")
+	}
+	if positional, err = ctx.parse(fs, args); err != nil {
+		return
+	}
+	if len(positional) != 0 {
+		err = newUsageError("多余的参数: %s", strings.Join(positional, " "))
+		return
+	}
+	if len(files) == 0 {
+		err = newUsageError("需要用 -f 指定清单文件")
+		return
+	}
+	if manifest, err = loadManifest(files, ctx.options.namespace); err != nil {
+		return
+	}
+	if content, err = json.Marshal(manifest); err != nil {
+		return
+	}
+	if client, err = ctx.Client(); err != nil {
+		return
+	}
+	err = client.Post("/job/apply", url.Values{
+		"manifest": {string(content)},
+		"prune":    {strconv.FormatBool(prune)},
+		"dryRun":   {strconv.FormatBool(dryRun)},
+	}, &result)
+	return
+}
+
+// cronctl apply -f FILE [-prune] [-dry-run]
+// 所有变更在master上通过一个etcd事务提交，要么全部成功要么全部失败
+func runApply(ctx *Context, cmd *command, args []string) (err error) {
+	var (
+		result *common.ApplyResult
+		prune  bool
+	)
+	if result, prune, err = submitManifest(ctx, cmd, args, false); err != nil {
+		return
+	}
+	if ctx.JSON() {
+		return printJSON(ctx.stdout, result)
+	}
+	printChanges(ctx, result, prune)
+	if result.Applied && len(result.Changes) > 0 {
+		fmt.Fprintf(ctx.stdout, "applied at revision %d\n", result.Revision)
+	} else if !result.Applied {
+		fmt.Fprintln(ctx.stdout, "dry run, nothing applied")
+	}
+	return
+}
+
+// cronctl diff -f FILE [-prune]
+// 有变更时退出码为 EXIT_CHANGED，方便在CI中检查清单和线上是否一致
+func runDiff(ctx *Context, cmd *command, args []string) (err error) {
+	var (
+		result *common.ApplyResult
+		prune  bool
+	)
+	if result, prune, err = submitManifest(ctx, cmd, args, true); err != nil {
+		return
+	}
+	if ctx.JSON() {
+		err = printJSON(ctx.stdout, result)
+	} else {
+		printChanges(ctx, result, prune)
+	}
+	if err == nil && len(result.Changes) > 0 {
+		err = &exitError{code: EXIT_CHANGED}
+	}
+	return
+}
@@ -0,0 +1,106 @@
+package cronctl
+
+import (
+	"../common"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// cronctl export [-f FILE]
+// 备份所有任务，不指定文件时输出到标准输出
+func runExport(ctx *Context, cmd *command, args []string) (err error) {
+	var (
+		fs         *flag.FlagSet
+		file       string
+		positional []string
+		client     *Client
+		content    []byte
+		archive    *common.JobArchive
+	)
+	fs = ctx.flagSet(cmd)
+	fs.StringVar(&file, "f", "", "备份文件，不指定则输出到标准输出")
+	if positional, err = ctx.parse(fs, args); err != nil {
+		return
+	}
+	if len(positional) != 0 {
+		return newUsageError("多余的参数: %s", strings.Join(positional, " "))
+	}
+	if client, err = ctx.Client(); err != nil {
+		return
+	}
+	if content, err = client.Download("/job/export?" + url.Values{"namespace": {ctx.options.namespace}}.Encode()); err != nil {
+		return
+	}
+	// 确认是完整的备份再写文件
+	if archive, err = common.UnpackJobArchive(content); err != nil {
+		return
+	}
+	if file == "" {
+		_, err = ctx.stdout.Write(content)
+		return
+	}
+	if err = ioutil.WriteFile(file, content, 0600); err != nil {
+		return
+	}
+	fmt.Fprintf(ctx.stderr, "已导出 %d 个任务到 %s（revision %d）\n", len(archive.Jobs), file, archive.Revision)
+	return
+}
+
+// cronctl import -f FILE [-mode skip|overwrite|fail]
+func runImport(ctx *Context, cmd *command, args []string) (err error) {
+	var (
+		fs         *flag.FlagSet
+		file       string
+		mode       string
+		positional []string
+		content    []byte
+		client     *Client
+		result     *common.JobImportResult
+		name       string
+	)
+	fs = ctx.flagSet(cmd)
+	fs.StringVar(&file, "f", "", "备份文件，- 表示标准输入")
+	fs.StringVar(&mode, "mode", common.JOB_IMPORT_MODE_FAIL, "任务已存在时的处理方式 skip|overwrite|fail")
+	if positional, err = ctx.parse(fs, args); err != nil {
+		return
+	}
+	if len(positional) != 0 {
+		return newUsageError("多余的参数: %s", strings.Join(positional, " "))
+	}
+	if file == "" {
+		return newUsageError("需要用 -f 指定备份文件")
+	}
+	if file == "-" {
+		content, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		content, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return
+	}
+	if client, err = ctx.Client(); err != nil {
+		return
+	}
+	if err = client.Post("/job/import", url.Values{"archive": {string(content)}, "mode": {mode}}, &result); err != nil {
+		return
+	}
+	if ctx.JSON() {
+		return printJSON(ctx.stdout, result)
+	}
+	for _, name = range result.Created {
+		fmt.Fprintf(ctx.stdout, "job/%s created\n", name)
+	}
+	for _, name = range result.Overwritten {
+		fmt.Fprintf(ctx.stdout, "job/%s overwritten\n", name)
+	}
+	for _, name = range result.Skipped {
+		fmt.Fprintf(ctx.stdout, "job/%s skipped (already exists)\n", name)
+	}
+	fmt.Fprintf(ctx.stdout, "created: %d, overwritten: %d, skipped: %d, unchanged: %d\n",
+		len(result.Created), len(result.Overwritten), len(result.Skipped), result.Unchanged)
+	return
+}
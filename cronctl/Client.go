@@ -0,0 +1,136 @@
+package cronctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// master接口客户端
+type Client struct {
+	server     string
+	token      string
+	httpClient *http.Client
+}
+
+// 接口应答，data留给调用方按接口解析
+type apiResponse struct {
+	Errno int             `json:"errno"`
+	Msg   string          `json:"msg"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// 接口返回的业务错误
+type ApiError struct {
+	StatusCode int    // http状态码
+	Msg        string // 应答中的msg
+}
+
+func (apiErr *ApiError) Error() string {
+	return apiErr.Msg
+}
+
+// 连不上master
+type ConnectError struct {
+	Err error
+}
+
+func (connErr *ConnectError) Error() string {
+	return "连接master失败: " + connErr.Err.Error()
+}
+
+func NewClient(config *Config) *Client {
+	return &Client{
+		server:     strings.TrimRight(config.Server, "/"),
+		token:      config.Token,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// 发送请求并把data解析到result中，result为nil时忽略data
+func (client *Client) do(method string, path string, params url.Values, result interface{}) (err error) {
+	var (
+		req     *http.Request
+		resp    *http.Response
+		body    []byte
+		apiResp apiResponse
+	)
+	if method == "GET" {
+		if len(params) > 0 {
+			path += "?" + params.Encode()
+		}
+		if req, err = http.NewRequest("GET", client.server+path, nil); err != nil {
+			return
+		}
+	} else {
+		if req, err = http.NewRequest("POST", client.server+path, strings.NewReader(params.Encode())); err != nil {
+			return
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if client.token != "" {
+		req.Header.Set("Authorization", "Bearer "+client.token)
+	}
+
+	if resp, err = client.httpClient.Do(req); err != nil {
+		return &ConnectError{Err: err}
+	}
+	defer resp.Body.Close()
+	if body, err = ioutil.ReadAll(resp.Body); err != nil {
+		return &ConnectError{Err: err}
+	}
+	if err = json.Unmarshal(body, &apiResp); err != nil {
+		return &ApiError{StatusCode: resp.StatusCode, Msg: fmt.Sprintf("无法解析的应答，状态码：%d", resp.StatusCode)}
+	}
+	if apiResp.Errno != 0 {
+		return &ApiError{StatusCode: resp.StatusCode, Msg: apiResp.Msg}
+	}
+	if result != nil && len(apiResp.Data) > 0 {
+		err = json.Unmarshal(apiResp.Data, result)
+	}
+	return
+}
+
+// 下载接口直接返回的内容（例如备份文件），出错时接口仍然返回普通的错误应答
+func (client *Client) Download(path string) (body []byte, err error) {
+	var (
+		req     *http.Request
+		resp    *http.Response
+		apiResp apiResponse
+	)
+	if req, err = http.NewRequest("GET", client.server+path, nil); err != nil {
+		return
+	}
+	if client.token != "" {
+		req.Header.Set("Authorization", "Bearer "+client.token)
+	}
+	if resp, err = client.httpClient.Do(req); err != nil {
+		err = &ConnectError{Err: err}
+		return
+	}
+	defer resp.Body.Close()
+	if body, err = ioutil.ReadAll(resp.Body); err != nil {
+		err = &ConnectError{Err: err}
+		return
+	}
+	if json.Unmarshal(body, &apiResp) == nil && apiResp.Errno != 0 {
+		err = &ApiError{StatusCode: resp.StatusCode, Msg: apiResp.Msg}
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = &ApiError{StatusCode: resp.StatusCode, Msg: fmt.Sprintf("下载失败，状态码：%d", resp.StatusCode)}
+	}
+	return
+}
+
+func (client *Client) Get(path string, params url.Values, result interface{}) error {
+	return client.do("GET", path, params, result)
+}
+
+func (client *Client) Post(path string, params url.Values, result interface{}) error {
+	return client.do("POST", path, params, result)
+}
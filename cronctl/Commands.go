@@ -0,0 +1,718 @@
+package cronctl
+
+import (
+	"../common"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/gorhill/cronexpr"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 按名称操作的命令都只接受一个任务名称
+func requireName(positional []string) (name string, err error) {
+	if len(positional) != 1 {
+		err = newUsageError("需要指定一个任务名称")
+		return
+	}
+	return positional[0], nil
+}
+
+// 调度模式，空表示single
+func jobMode(job *common.Job) string {
+	if job.Mode == "" {
+		return common.JOB_MODE_SINGLE
+	}
+	return job.Mode
+}
+
+// 成功心跳状态
+func watchdogStatus(state *common.JobWatchdogState) string {
+	if state == nil {
+		return "-"
+	}
+	if state.Overdue {
+		return "overdue"
+	}
+	return "ok"
+}
+
+// 执行结果
+func logResult(jobLog *common.JobLog) string {
+	if jobLog.TimedOut {
+		return "timeout"
+	}
+	if jobLog.Err != "" {
+		return "failed"
+	}
+	return "success"
+}
+
+// cronctl list
+func runList(ctx *Context, cmd *command, args []string) (err error) {
+	var (
+		fs      *flag.FlagSet
+		tags    string
+		owner   string
+		team    string
+		keyword string
+		sortBy  string
+		skip    int
+		limit   int
+		client  *Client
+		params  url.Values
+		page    *common.JobListPage
+		item    *common.JobListItem
+		t       *table
+	)
+	fs = ctx.flagSet(cmd)
+	fs.StringVar(&tags, "tag", "", "只看包含这些标签的任务，多个用逗号分隔")
+	fs.StringVar(&owner, "owner", "", "只看某个负责人的任务")
+	fs.StringVar(&team, "team", "", "只看某个团队的任务")
+	fs.StringVar(&keyword, "keyword", "", "在名称、说明、命令、负责人、团队和标签中搜索")
+	fs.StringVar(&sortBy, "sort", "", "排序字段 name|createTime|updateTime|owner，前面加-表示倒序")
+	fs.IntVar(&skip, "skip", 0, "跳过前多少个")
+	fs.IntVar(&limit, "limit", 0, "最多显示多少个，0表示不限制")
+	if _, err = ctx.parse(fs, args); err != nil {
+		return
+	}
+	if client, err = ctx.Client(); err != nil {
+		return
+	}
+	params = url.Values{
+		"namespace": {ctx.options.namespace},
+		"tag":       {tags},
+		"owner":     {owner},
+		"team":      {team},
+		"keyword":   {keyword},
+		"sort":      {sortBy},
+		"skip":      {strconv.Itoa(skip)},
+		"limit":     {strconv.Itoa(limit)},
+	}
+	if err = client.Get("/job/list", params, &page); err != nil {
+		return
+	}
+	if ctx.JSON() {
+		return printJSON(ctx.stdout, page)
+	}
+	t = newTable(ctx.stdout, "NAMESPACE", "NAME", "MODE", "CRON", "OWNER", "TAGS", "WATCHDOG", "COMMAND")
+	for _, item = range page.Jobs {
+		t.row(common.NormalizeNamespace(item.Namespace), item.Name, jobMode(item.Job), item.CronExpr, orDash(item.Owner), orDash(strings.Join(item.Tags, ",")), watchdogStatus(item.Watchdog), truncate(item.Command, 60))
+	}
+	if err = t.flush(); err != nil {
+		return
+	}
+	if len(page.Jobs) < page.Total {
+		fmt.Fprintf(ctx.stdout, "显示 %d / 共 %d 个任务\n", len(page.Jobs), page.Total)
+	}
+	return
+}
+
+// cronctl get NAME
+func runGet(ctx *Context, cmd *command, args []string) (err error) {
+	var (
+		fs           *flag.FlagSet
+		positional   []string
+		name         string
+		client       *Client
+		item         *common.JobListItem
+		param        *common.JobParam
+		paramType    string
+		paramDefault string
+		t            *table
+	)
+	fs = ctx.flagSet(cmd)
+	if positional, err = ctx.parse(fs, args); err != nil {
+		return
+	}
+	if name, err = requireName(positional); err != nil {
+		return
+	}
+	if client, err = ctx.Client(); err != nil {
+		return
+	}
+	if err = client.Get("/job/get", ctx.jobParams(name), &item); err != nil {
+		return
+	}
+	if ctx.JSON() {
+		return printJSON(ctx.stdout, item)
+	}
+	t = newTable(ctx.stdout)
+	t.row("Namespace:", common.NormalizeNamespace(item.Namespace))
+	t.row("Name:", item.Name)
+	t.row("Command:", item.Command)
+	t.row("Cron:", item.CronExpr)
+	t.row("Description:", orDash(item.Description))
+	t.row("Owner:", orDash(item.Owner))
+	t.row("Team:", orDash(item.Team))
+	t.row("Tags:", orDash(strings.Join(item.Tags, ",")))
+	t.row("Mode:", jobMode(item.Job))
+	for _, param = range item.Params {
+		if paramType = param.Type; paramType == "" {
+			paramType = common.JOB_PARAM_STRING
+		}
+		if paramDefault = "-"; param.Default != nil {
+			paramDefault = fmt.Sprint(param.Default)
+		}
+		t.row("Param:", fmt.Sprintf("%s %s default=%s %s", param.Name, paramType, paramDefault, param.Description))
+	}
+	t.row("Timeout:", formatSeconds(int64(item.Timeout)))
+	t.row("ExpectSuccessWithin:", formatSeconds(item.ExpectSuccessWithin))
+	if item.Retention != nil {
+		t.row("Retention:", fmt.Sprintf("maxAge=%s maxCount=%d", formatSeconds(item.Retention.MaxAge), item.Retention.MaxCount))
+	}
+	if item.Notify != nil {
+		t.row("Notify:", fmt.Sprintf("onFailure=%t onTimeout=%t consecutiveFailures=%d onRecovery=%t targets=%s",
+			item.Notify.OnFailure, item.Notify.OnTimeout, item.Notify.ConsecutiveFailures, item.Notify.OnRecovery, orDash(strings.Join(item.Notify.Targets, ","))))
+	}
+	if item.Watchdog != nil {
+		t.row("Watchdog:", fmt.Sprintf("%s (lastSuccess=%s)", watchdogStatus(item.Watchdog), formatMillis(item.Watchdog.LastSuccessTime)))
+	}
+	t.row("Created:", formatMillis(item.CreateTime))
+	t.row("Updated:", formatMillis(item.UpdateTime))
+	return t.flush()
+}
+
+// 从文件读取任务，文件内容可以是单个任务或者任务数组，"-"表示标准输入
+func readJobFile(filename string) (jobs []*common.Job, err error) {
+	var (
+		content []byte
+		job     *common.Job
+	)
+	if filename == "-" {
+		content, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		content, err = ioutil.ReadFile(filename)
+	}
+	if err != nil {
+		return
+	}
+	content = []byte(strings.TrimSpace(string(content)))
+	if len(content) > 0 && content[0] == '[' {
+		err = json.Unmarshal(content, &jobs)
+		return
+	}
+	job = &common.Job{}
+	if err = json.Unmarshal(content, job); err != nil {
+		return
+	}
+	jobs = []*common.Job{job}
+	return
+}
+
+// cronctl save -f FILE
+// cronctl save -name NAME [-command CMD] [-cron EXPR] [-mode MODE] [-timeout N] [-expect-success-within N]
+// 按参数保存时，已有任务只修改指定的字段
+func runSave(ctx *Context, cmd *command, args []string) (err error) {
+	var (
+		fs                  *flag.FlagSet
+		file                string
+		name                string
+		command             string
+		cronExpr            string
+		mode                string
+		timeout             int
+		expectSuccessWithin int64
+		positional          []string
+		setFlags            map[string]bool
+		client              *Client
+		jobs                []*common.Job
+		job                 *common.Job
+		item                *common.JobListItem
+		apiErr              *ApiError
+		content             []byte
+		oldJob              *common.Job
+		action              string
+	)
+	fs = ctx.flagSet(cmd)
+	fs.StringVar(&file, "f", "", "从json文件读取任务，可以是单个任务或任务数组，- 表示标准输入")
+	fs.StringVar(&name, "name", "", "任务名称")
+	fs.StringVar(&command, "command", "", "shell命令")
+	fs.StringVar(&cronExpr, "cron", "", "cron表达式")
+	fs.StringVar(&mode, "mode", "", "调度模式 single|broadcast")
+	fs.IntVar(&timeout, "timeout", 0, "执行超时时间，秒，0表示不限制")
+	fs.Int64Var(&expectSuccessWithin, "expect-success-within", 0, "期望在多少秒内至少成功一次，0表示不检查")
+	if positional, err = ctx.parse(fs, args); err != nil {
+		return
+	}
+	if len(positional) != 0 {
+		return newUsageError("多余的参数: %s", strings.Join(positional, " "))
+	}
+	setFlags = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	if client, err = ctx.Client(); err != nil {
+		return
+	}
+
+	if file != "" {
+		if name != "" || command != "" || cronExpr != "" || setFlags["mode"] || setFlags["timeout"] || setFlags["expect-success-within"] {
+			return newUsageError("-f 不能和任务字段参数一起使用")
+		}
+		if jobs, err = readJobFile(file); err != nil {
+			return
+		}
+	} else {
+		if name == "" {
+			return newUsageError("需要指定 -f 或者 -name")
+		}
+		// 已有任务在原来的基础上修改
+		if err = client.Get("/job/get", ctx.jobParams(name), &item); err == nil {
+			job = item.Job
+		} else if apiErr, _ = err.(*ApiError); apiErr != nil && apiErr.Msg == common.ERR_JOB_NOT_FOUND.Error() {
+			if command == "" || cronExpr == "" {
+				return newUsageError("新建任务需要指定 -command 和 -cron")
+			}
+			job = &common.Job{Namespace: ctx.options.namespace, Name: name}
+		} else {
+			return
+		}
+		if setFlags["command"] {
+			job.Command = command
+		}
+		if setFlags["cron"] {
+			job.CronExpr = cronExpr
+		}
+		if setFlags["mode"] {
+			job.Mode = mode
+		}
+		if setFlags["timeout"] {
+			job.Timeout = timeout
+		}
+		if setFlags["expect-success-within"] {
+			job.ExpectSuccessWithin = expectSuccessWithin
+		}
+		jobs = []*common.Job{job}
+	}
+
+	for _, job = range jobs {
+		if content, err = json.Marshal(job); err != nil {
+			return
+		}
+		oldJob = nil
+		// 文件中没有命名空间的任务使用 -namespace
+		if err = client.Post("/job/save", url.Values{"job": {string(content)}, "namespace": {ctx.options.namespace}}, &oldJob); err != nil {
+			return fmt.Errorf("保存任务 %s 失败: %w", job.Name, err)
+		}
+		if ctx.JSON() {
+			continue
+		}
+		action = "updated"
+		if oldJob == nil {
+			action = "created"
+		}
+		if job.Namespace == "" {
+			job.Namespace = ctx.options.namespace
+		}
+		fmt.Fprintf(ctx.stdout, "job/%s %s\n", job.FullName(), action)
+	}
+	if ctx.JSON() {
+		return printJSON(ctx.stdout, jobs)
+	}
+	return
+}
+
+// cronctl delete NAME [-keep-logs]
+func runDelete(ctx *Context, cmd *command, args []string) (err error) {
+	var (
+		fs         *flag.FlagSet
+		keepLogs   bool
+		positional []string
+		name       string
+		client     *Client
+		params     url.Values
+		oldJob     *common.Job
+	)
+	fs = ctx.flagSet(cmd)
+	fs.BoolVar(&keepLogs, "keep-logs", false, "保留任务的执行日志，默认同时删除")
+	if positional, err = ctx.parse(fs, args); err != nil {
+		return
+	}
+	if name, err = requireName(positional); err != nil {
+		return
+	}
+	if client, err = ctx.Client(); err != nil {
+		return
+	}
+	params = ctx.jobParams(name)
+	params.Set("keepLogs", strconv.FormatBool(keepLogs))
+	if err = client.Post("/job/delete", params, &oldJob); err != nil {
+		return
+	}
+	// 删除不存在的任务接口不报错，返回的旧任务为空
+	if oldJob == nil {
+		return &ApiError{Msg: common.ERR_JOB_NOT_FOUND.Error()}
+	}
+	if ctx.JSON() {
+		return printJSON(ctx.stdout, oldJob)
+	}
+	fmt.Fprintf(ctx.stdout, "job/%s deleted\n", common.JobFullName(ctx.options.namespace, name))
+	return
+}
+
+// 只需要任务名称的POST操作：kill / run
+func postByName(ctx *Context, cmd *command, path string, action string, args []string) (err error) {
+	var (
+		fs         *flag.FlagSet
+		positional []string
+		name       string
+		client     *Client
+	)
+	fs = ctx.flagSet(cmd)
+	if positional, err = ctx.parse(fs, args); err != nil {
+		return
+	}
+	if name, err = requireName(positional); err != nil {
+		return
+	}
+	if client, err = ctx.Client(); err != nil {
+		return
+	}
+	if err = client.Post(path, ctx.jobParams(name), nil); err != nil {
+		return
+	}
+	return printJobAction(ctx, name, action)
+}
+
+// 输出对任务的操作结果
+func printJobAction(ctx *Context, name string, action string) (err error) {
+	if ctx.JSON() {
+		return printJSON(ctx.stdout, map[string]string{"namespace": common.NormalizeNamespace(ctx.options.namespace), "name": name, "action": action})
+	}
+	fmt.Fprintf(ctx.stdout, "job/%s %s\n", common.JobFullName(ctx.options.namespace, name), action)
+	return
+}
+
+// cronctl kill NAME
+func runKill(ctx *Context, cmd *command, args []string) error {
+	return postByName(ctx, cmd, "/job/kill", "killed", args)
+}
+
+// cronctl run NAME [-p KEY=VALUE]...
+// -p 覆盖任务参数的默认值，值由master按参数类型解析
+func runRun(ctx *Context, cmd *command, args []string) (err error) {
+	var (
+		fs         *flag.FlagSet
+		paramFlags stringsFlag
+		positional []string
+		name       string
+		runParams  map[string]string
+		param      string
+		index      int
+		content    []byte
+		params     url.Values
+		client     *Client
+	)
+	fs = ctx.flagSet(cmd)
+	fs.Var(&paramFlags, "p", "覆盖任务参数 KEY=VALUE，可以指定多次")
+	if positional, err = ctx.parse(fs, args); err != nil {
+		return
+	}
+	if name, err = requireName(positional); err != nil {
+		return
+	}
+	runParams = make(map[string]string)
+	for _, param = range paramFlags {
+		if index = strings.Index(param, "="); index <= 0 {
+			return newUsageError("参数格式应为 KEY=VALUE: %s", param)
+		}
+		runParams[param[:index]] = param[index+1:]
+	}
+	if client, err = ctx.Client(); err != nil {
+		return
+	}
+	params = ctx.jobParams(name)
+	if len(runParams) != 0 {
+		if content, err = json.Marshal(runParams); err != nil {
+			return
+		}
+		params.Set("params", string(content))
+	}
+	if err = client.Post("/job/run", params, nil); err != nil {
+		return
+	}
+	return printJobAction(ctx, name, "triggered")
+}
+
+// 打印一批日志，logArr按时间正序
+func printLogs(ctx *Context, logArr []*common.JobLog, showJob bool, showOutput bool, header bool) (err error) {
+	var (
+		jobLog  *common.JobLog
+		t       *table
+		content []byte
+	)
+	// json：每行一条，-f时可以直接交给其他程序逐行处理
+	if ctx.JSON() {
+		for _, jobLog = range logArr {
+			if content, err = json.Marshal(jobLog); err != nil {
+				return
+			}
+			fmt.Fprintln(ctx.stdout, string(content))
+		}
+		return
+	}
+	// 带输出时不适合表格，逐条打印
+	if showOutput {
+		for _, jobLog = range logArr {
+			fmt.Fprintf(ctx.stdout, "==> %s %s %s %s %s\n", formatMillis(jobLog.StartTime), jobLog.JobName, orDash(jobLog.Worker), orDash(jobLog.ExecId), logResult(jobLog))
+			if jobLog.Err != "" {
+				fmt.Fprintln(ctx.stdout, "error:", jobLog.Err)
+			}
+			fmt.Fprintln(ctx.stdout, strings.TrimRight(jobLog.Output, "\n"))
+		}
+		return
+	}
+	if header {
+		if showJob {
+			t = newTable(ctx.stdout, "START", "JOB", "DURATION", "WORKER", "EXEC_ID", "RESULT", "ERROR")
+		} else {
+			t = newTable(ctx.stdout, "START", "DURATION", "WORKER", "EXEC_ID", "RESULT", "ERROR")
+		}
+	} else {
+		t = newTable(ctx.stdout)
+	}
+	for _, jobLog = range logArr {
+		if showJob {
+			t.row(formatMillis(jobLog.StartTime), jobLog.JobName, formatDuration(jobLog.StartTime, jobLog.EndTime), orDash(jobLog.Worker), orDash(jobLog.ExecId), logResult(jobLog), truncate(orDash(jobLog.Err), 60))
+		} else {
+			t.row(formatMillis(jobLog.StartTime), formatDuration(jobLog.StartTime, jobLog.EndTime), orDash(jobLog.Worker), orDash(jobLog.ExecId), logResult(jobLog), truncate(orDash(jobLog.Err), 60))
+		}
+	}
+	return t.flush()
+}
+
+// 日志去重用的key，老版本worker写的日志没有execId
+func logKey(jobLog *common.JobLog) string {
+	return jobLog.JobName + "/" + jobLog.Worker + "/" + jobLog.ExecId + "/" + strconv.FormatInt(jobLog.StartTime, 10)
+}
+
+// -f时每次查询的条数，新日志超过一页时翻页
+const FOLLOW_PAGE_SIZE = 100
+
+// cronctl logs [NAME] [-n 20] [-status success|failed] [-worker ID] [-keyword KEYWORD] [-since 24h] [-output] [-f]
+func runLogs(ctx *Context, cmd *command, args []string) (err error) {
+	var (
+		fs         *flag.FlagSet
+		limit      int
+		status     string
+		worker     string
+		keyword    string
+		since      time.Duration
+		showOutput bool
+		follow     bool
+		interval   time.Duration
+		lag        time.Duration
+		positional []string
+		name       string
+		client     *Client
+		params     url.Values
+		logPage    *common.JobLogPage
+		logArr     []*common.JobLog
+		jobLog     *common.JobLog
+		lastEnd    int64
+		endFrom    int64
+		seen       map[string]int64 // 已经输出的日志 -> 结束时间
+		key        string
+		endTime    int64
+		skip       int
+		ok         bool
+		i          int
+	)
+	fs = ctx.flagSet(cmd)
+	fs.IntVar(&limit, "n", 20, "显示最近多少条")
+	fs.StringVar(&status, "status", "", "只看成功(success)或失败(failed)的日志")
+	fs.StringVar(&worker, "worker", "", "只看某个worker的日志")
+	fs.StringVar(&keyword, "keyword", "", "在输出和错误中搜索，不指定任务名称时必须同时指定-since")
+	fs.DurationVar(&since, "since", 0, "只看最近这段时间开始的日志，例如24h")
+	fs.BoolVar(&showOutput, "output", false, "显示任务输出")
+	fs.BoolVar(&follow, "f", false, "持续输出新的日志")
+	fs.DurationVar(&interval, "interval", 2*time.Second, "-f时的轮询间隔")
+	fs.DurationVar(&lag, "lag", 30*time.Second, "-f时回看的时间，补上晚写入的日志（worker补发、节点时钟偏差）")
+	if positional, err = ctx.parse(fs, args); err != nil {
+		return
+	}
+	if len(positional) > 1 {
+		return newUsageError("最多指定一个任务名称")
+	}
+	if len(positional) == 1 {
+		name = positional[0]
+	}
+	if client, err = ctx.Client(); err != nil {
+		return
+	}
+
+	params = url.Values{
+		"name":      {name},
+		"namespace": {ctx.options.namespace},
+		"status":    {status},
+		"worker":    {worker},
+		"keyword":   {keyword},
+		"limit":     {strconv.Itoa(limit)},
+	}
+	if since > 0 {
+		params.Set("startTime", strconv.FormatInt((time.Now().UnixNano()-int64(since))/1000/1000, 10))
+	}
+	// -f时先显示最近结束的日志，之后按结束时间跟踪
+	if follow {
+		params.Set("sort", "-"+common.LOG_SORT_END_TIME)
+	}
+	if err = client.Get("/job/log", params, &logPage); err != nil {
+		return
+	}
+	if ctx.JSON() && !follow {
+		return printJSON(ctx.stdout, logPage)
+	}
+
+	// 接口按时间倒序返回，按时间正序显示
+	logArr = make([]*common.JobLog, 0, len(logPage.Logs))
+	for i = len(logPage.Logs) - 1; i >= 0; i-- {
+		logArr = append(logArr, logPage.Logs[i])
+	}
+	if err = printLogs(ctx, logArr, name == "", showOutput, true); err != nil {
+		return
+	}
+	if !follow {
+		return
+	}
+
+	// 日志在任务结束后才写入，按结束时间跟踪：开始得早、结束得晚的长任务也不会漏掉
+	// 每次查询结束时间不早于 最新结束时间-lag 的日志，重叠部分按key去重
+	seen = make(map[string]int64)
+	lastEnd = time.Now().UnixNano() / 1000 / 1000
+	if len(logArr) != 0 {
+		lastEnd = 0
+	}
+	for _, jobLog = range logArr {
+		seen[logKey(jobLog)] = jobLog.EndTime
+		if jobLog.EndTime > lastEnd {
+			lastEnd = jobLog.EndTime
+		}
+	}
+	params.Set("sort", common.LOG_SORT_END_TIME)
+	params.Set("limit", strconv.Itoa(FOLLOW_PAGE_SIZE))
+	for {
+		time.Sleep(interval)
+		endFrom = lastEnd - int64(lag/time.Millisecond)
+		params.Set("endFrom", strconv.FormatInt(endFrom, 10))
+		logArr = make([]*common.JobLog, 0)
+		// 一次轮询期间的新日志可能超过一页，翻页直到取完
+		for skip = 0; ; skip += FOLLOW_PAGE_SIZE {
+			params.Set("skip", strconv.Itoa(skip))
+			logPage = nil
+			if err = client.Get("/job/log", params, &logPage); err != nil {
+				fmt.Fprintln(ctx.stderr, "查询日志失败:", err)
+				break
+			}
+			for _, jobLog = range logPage.Logs {
+				key = logKey(jobLog)
+				if _, ok = seen[key]; ok {
+					continue
+				}
+				seen[key] = jobLog.EndTime
+				logArr = append(logArr, jobLog)
+				if jobLog.EndTime > lastEnd {
+					lastEnd = jobLog.EndTime
+				}
+			}
+			if len(logPage.Logs) < FOLLOW_PAGE_SIZE {
+				break
+			}
+		}
+		// 去重表只保留下次还会查到的日志
+		for key, endTime = range seen {
+			if endTime < lastEnd-int64(lag/time.Millisecond) {
+				delete(seen, key)
+			}
+		}
+		if len(logArr) == 0 {
+			continue
+		}
+		if err = printLogs(ctx, logArr, name == "", showOutput, false); err != nil {
+			return
+		}
+	}
+}
+
+// cronctl workers
+func runWorkers(ctx *Context, cmd *command, args []string) (err error) {
+	var (
+		fs        *flag.FlagSet
+		client    *Client
+		workerArr []*common.WorkerInfo
+		worker    *common.WorkerInfo
+		freeSlots string
+		t         *table
+	)
+	fs = ctx.flagSet(cmd)
+	if _, err = ctx.parse(fs, args); err != nil {
+		return
+	}
+	if client, err = ctx.Client(); err != nil {
+		return
+	}
+	if err = client.Get("/worker/list", nil, &workerArr); err != nil {
+		return
+	}
+	if ctx.JSON() {
+		return printJSON(ctx.stdout, workerArr)
+	}
+	t = newTable(ctx.stdout, "ID", "IP", "STATE", "RUNNING", "FREE", "VERSION", "LAST_SEEN")
+	for _, worker = range workerArr {
+		freeSlots = strconv.Itoa(worker.FreeSlots)
+		if worker.FreeSlots < 0 {
+			freeSlots = "unlimited"
+		}
+		t.row(worker.ID, worker.IP, orDash(worker.State), strconv.Itoa(worker.RunningJobs), freeSlots, orDash(worker.Version), formatMillis(worker.UpdateTime))
+	}
+	return t.flush()
+}
+
+// cronctl preview NAME | preview -expr EXPR [-n 5]
+// 在本地按cron表达式计算，和worker使用同一个解析库
+func runPreview(ctx *Context, cmd *command, args []string) (err error) {
+	var (
+		fs         *flag.FlagSet
+		exprStr    string
+		count      uint
+		positional []string
+		client     *Client
+		item       *common.JobListItem
+		expr       *cronexpr.Expression
+		nextTimes  []time.Time
+		nextTime   time.Time
+	)
+	fs = ctx.flagSet(cmd)
+	fs.StringVar(&exprStr, "expr", "", "cron表达式，不指定则使用任务的表达式")
+	fs.UintVar(&count, "n", 5, "显示接下来多少次")
+	if positional, err = ctx.parse(fs, args); err != nil {
+		return
+	}
+	if (exprStr == "") == (len(positional) == 0) || len(positional) > 1 {
+		return newUsageError("需要指定一个任务名称或者 -expr")
+	}
+	if exprStr == "" {
+		if client, err = ctx.Client(); err != nil {
+			return
+		}
+		if err = client.Get("/job/get", ctx.jobParams(positional[0]), &item); err != nil {
+			return
+		}
+		exprStr = item.CronExpr
+	}
+	if expr, err = cronexpr.Parse(exprStr); err != nil {
+		return newUsageError("cron表达式错误: %s", err)
+	}
+	nextTimes = expr.NextN(time.Now(), count)
+	if ctx.JSON() {
+		return printJSON(ctx.stdout, map[string]interface{}{"cronExpr": exprStr, "next": nextTimes})
+	}
+	for _, nextTime = range nextTimes {
+		fmt.Fprintln(ctx.stdout, nextTime.Format("2006-01-02 15:04:05 Mon"))
+	}
+	return
+}
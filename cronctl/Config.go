@@ -0,0 +1,72 @@
+package cronctl
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// 命令行客户端配置
+// 优先级：命令行参数 > 环境变量 > 配置文件 > 默认值
+type Config struct {
+	Server string `json:"server"` // master地址，例如 http://127.0.0.1:8070
+	Token  string `json:"token"`  // 接口token，对应master.json中的apiToken
+}
+
+const (
+	DEFAULT_SERVER = "http://127.0.0.1:8070"
+
+	ENV_SERVER = "CRONCTL_SERVER"
+	ENV_TOKEN  = "CRONCTL_TOKEN"
+	ENV_CONFIG = "CRONCTL_CONFIG"
+)
+
+// 默认配置文件 ~/.cronctl.json
+func defaultConfigFile() string {
+	var (
+		home string
+		err  error
+	)
+	if home, err = os.UserHomeDir(); err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".cronctl.json")
+}
+
+// 加载配置，filename为空时使用环境变量CRONCTL_CONFIG或者默认配置文件，默认配置文件不存在不算错误
+func LoadConfig(filename string) (config *Config, err error) {
+	var (
+		content  []byte
+		explicit bool
+		value    string
+	)
+	config = &Config{Server: DEFAULT_SERVER}
+
+	if filename == "" {
+		filename = os.Getenv(ENV_CONFIG)
+	}
+	explicit = filename != ""
+	if !explicit {
+		filename = defaultConfigFile()
+	}
+	if filename != "" {
+		if content, err = ioutil.ReadFile(filename); err != nil {
+			if explicit || !os.IsNotExist(err) {
+				return
+			}
+			err = nil
+		} else if err = json.Unmarshal(content, config); err != nil {
+			return
+		}
+	}
+
+	// 环境变量覆盖配置文件
+	if value = os.Getenv(ENV_SERVER); value != "" {
+		config.Server = value
+	}
+	if value = os.Getenv(ENV_TOKEN); value != "" {
+		config.Token = value
+	}
+	return
+}
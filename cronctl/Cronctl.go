@@ -0,0 +1,268 @@
+package cronctl
+
+import (
+	"../common"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// 退出码
+const (
+	EXIT_OK           = 0 // 成功
+	EXIT_FAILED       = 1 // 接口返回错误
+	EXIT_USAGE        = 2 // 命令或参数错误
+	EXIT_CONNECT      = 3 // 连不上master
+	EXIT_NOT_FOUND    = 4 // 任务或节点不存在
+	EXIT_UNAUTHORIZED = 5 // token错误
+	EXIT_CHANGED      = 6 // diff发现清单和线上不一致
+)
+
+// 子命令
+type command struct {
+	name    string
+	usage   string // 参数说明
+	summary string // 一句话说明
+	run     func(ctx *Context, cmd *command, args []string) error
+}
+
+// 所有子命令，按帮助中的顺序排列
+var commands = []*command{
+	{"list", "list [-tag TAGS] [-owner OWNER] [-team TEAM] [-keyword TEXT] [-sort FIELD] [-skip N] [-limit N]", "列出任务", runList},
+	{"get", "get NAME", "查看任务详情", runGet},
+	{"save", "save -f FILE | save -name NAME [-command CMD] [-cron EXPR] ...", "创建或修改任务", runSave},
+	{"delete", "delete NAME [-keep-logs]", "删除任务", runDelete},
+	{"kill", "kill NAME", "强杀正在执行的任务", runKill},
+	{"run", "run NAME [-p KEY=VALUE]...", "立即执行一次任务，可以覆盖任务参数", runRun},
+	{"logs", "logs [NAME] [-n 20] [-status success|failed] [-worker ID] [-f]", "查看执行日志", runLogs},
+	{"workers", "workers", "列出worker节点", runWorkers},
+	{"preview", "preview NAME | preview -expr EXPR [-n 5]", "预览接下来的调度时间", runPreview},
+	{"apply", "apply -f FILE|DIR [-f ...] [-prune] [-dry-run]", "按任务清单创建、修改、删除任务", runApply},
+	{"diff", "diff -f FILE|DIR [-f ...] [-prune]", "比较任务清单和线上任务", runDiff},
+	{"export", "export [-f FILE]", "备份任务", runExport},
+	{"import", "import -f FILE [-mode skip|overwrite|fail]", "从备份恢复任务", runImport},
+	{"import-crontab", "import-crontab -f FILE [-system] [-prefix PREFIX] [-apply]", "把crontab文件转换成任务", runImportCrontab},
+}
+
+// 每个命令都可以使用的全局参数
+type globalOptions struct {
+	configFile string
+	server     string
+	token      string
+	output     string
+	namespace  string
+}
+
+// 命令执行上下文
+type Context struct {
+	options globalOptions
+	stdout  io.Writer
+	stderr  io.Writer
+	client  *Client
+}
+
+// 参数错误
+type usageError struct {
+	msg    string
+	silent bool // flag包已经打印过错误和用法
+}
+
+func (usageErr *usageError) Error() string {
+	return usageErr.msg
+}
+
+func newUsageError(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// 只需要设置退出码、不需要打印的错误
+type exitError struct {
+	code int
+}
+
+func (exitErr *exitError) Error() string {
+	return "exit " + strconv.Itoa(exitErr.code)
+}
+
+// 按需创建客户端，preview -expr这类不需要访问master的命令不会读取配置
+func (ctx *Context) Client() (client *Client, err error) {
+	var (
+		config *Config
+	)
+	if ctx.client != nil {
+		return ctx.client, nil
+	}
+	if config, err = LoadConfig(ctx.options.configFile); err != nil {
+		return
+	}
+	// 命令行参数覆盖环境变量和配置文件
+	if ctx.options.server != "" {
+		config.Server = ctx.options.server
+	}
+	if ctx.options.token != "" {
+		config.Token = ctx.options.token
+	}
+	ctx.client = NewClient(config)
+	return ctx.client, nil
+}
+
+// 按名称操作任务的参数，没有指定命名空间时master使用default
+func (ctx *Context) jobParams(name string) (params url.Values) {
+	params = url.Values{"name": {name}}
+	if ctx.options.namespace != "" {
+		params.Set("namespace", ctx.options.namespace)
+	}
+	return
+}
+
+// 是否输出json
+func (ctx *Context) JSON() bool {
+	return ctx.options.output == OUTPUT_JSON
+}
+
+// 创建子命令的参数解析器，附带全局参数
+func (ctx *Context) flagSet(cmd *command) (fs *flag.FlagSet) {
+	fs = flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(ctx.stderr)
+	fs.StringVar(&ctx.options.configFile, "config", ctx.options.configFile, "配置文件，默认 ~/.cronctl.json")
+	fs.StringVar(&ctx.options.server, "server", ctx.options.server, "master地址，默认 "+DEFAULT_SERVER)
+	fs.StringVar(&ctx.options.token, "token", ctx.options.token, "接口token")
+	fs.StringVar(&ctx.options.output, "o", ctx.options.output, "输出格式 table|json")
+	fs.StringVar(&ctx.options.namespace, "namespace", ctx.options.namespace, "命名空间，默认default；list、logs、export不指定时为所有有权限的命名空间")
+	fs.Usage = func() {
+		fmt.Fprintf(ctx.stderr, "用法: cronctl %s\n\n%s\n\n参数:\n", cmd.usage, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return
+}
+
+// 解析参数，允许参数和位置参数交替出现，例如 logs job1 -f
+func (ctx *Context) parse(fs *flag.FlagSet, args []string) (positional []string, err error) {
+	positional = make([]string, 0)
+	for {
+		if err = fs.Parse(args); err != nil {
+			if err != flag.ErrHelp {
+				err = &usageError{msg: err.Error(), silent: true}
+			}
+			return
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if ctx.options.output != OUTPUT_TABLE && ctx.options.output != OUTPUT_JSON {
+		err = newUsageError("不支持的输出格式: %s", ctx.options.output)
+	}
+	return
+}
+
+// 根据错误类型得到退出码
+func exitCode(err error) int {
+	var (
+		usageErr   *usageError
+		exitErr    *exitError
+		connectErr *ConnectError
+		apiErr     *ApiError
+	)
+	switch {
+	case err == nil, err == flag.ErrHelp:
+		return EXIT_OK
+	case errors.As(err, &usageErr):
+		return EXIT_USAGE
+	case errors.As(err, &exitErr):
+		return exitErr.code
+	case errors.As(err, &connectErr):
+		return EXIT_CONNECT
+	case errors.As(err, &apiErr):
+		if apiErr.StatusCode == 401 {
+			return EXIT_UNAUTHORIZED
+		}
+		if apiErr.Msg == common.ERR_JOB_NOT_FOUND.Error() || apiErr.Msg == common.ERR_WORKER_NOT_FOUND.Error() {
+			return EXIT_NOT_FOUND
+		}
+	}
+	return EXIT_FAILED
+}
+
+// 已经打印过或者不需要打印的错误
+func silentError(err error) bool {
+	switch typedErr := err.(type) {
+	case *usageError:
+		return typedErr.silent
+	case *exitError:
+		return true
+	}
+	return false
+}
+
+// 打印所有命令
+func printUsage(out io.Writer) {
+	var (
+		cmd *command
+	)
+	fmt.Fprintln(out, "用法: cronctl [-server ADDR] [-token TOKEN] [-config FILE] [-namespace NS] [-o table|json] <命令> [参数]")
+	fmt.Fprintln(out, "\n命令:")
+	for _, cmd = range commands {
+		fmt.Fprintf(out, "  %-16s%s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out, "\n使用 cronctl <命令> -h 查看命令参数")
+	fmt.Fprintf(out, "环境变量 %s / %s / %s 分别对应 -server / -token / -config\n", ENV_SERVER, ENV_TOKEN, ENV_CONFIG)
+}
+
+// 命令行入口，返回退出码
+func Run(args []string) int {
+	var (
+		ctx  *Context
+		fs   *flag.FlagSet
+		cmd  *command
+		name string
+		err  error
+	)
+	ctx = &Context{
+		options: globalOptions{output: OUTPUT_TABLE},
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+	}
+
+	// 命令之前的全局参数
+	fs = ctx.flagSet(&command{name: "cronctl"})
+	fs.Usage = func() { printUsage(ctx.stderr) }
+	if err = fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return EXIT_OK
+		}
+		return EXIT_USAGE
+	}
+	if fs.NArg() == 0 {
+		printUsage(ctx.stderr)
+		return EXIT_USAGE
+	}
+	name = fs.Arg(0)
+	if name == "help" {
+		printUsage(ctx.stdout)
+		return EXIT_OK
+	}
+	for _, cmd = range commands {
+		if cmd.name == name {
+			break
+		}
+		cmd = nil
+	}
+	if cmd == nil {
+		fmt.Fprintf(ctx.stderr, "未知命令: %s\n\n", name)
+		printUsage(ctx.stderr)
+		return EXIT_USAGE
+	}
+
+	if err = cmd.run(ctx, cmd, fs.Args()[1:]); err != nil && !silentError(err) {
+		fmt.Fprintln(ctx.stderr, "错误:", err)
+	}
+	return exitCode(err)
+}
@@ -0,0 +1,102 @@
+package cronctl
+
+import (
+	"../common"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sigs.k8s.io/yaml"
+	"strconv"
+	"strings"
+)
+
+// 系统crontab的路径，带用户字段
+func isSystemCrontab(file string) bool {
+	file = filepath.Clean(file)
+	return file == "/etc/crontab" || filepath.Dir(file) == "/etc/cron.d"
+}
+
+// 打印无法转换的行和需要注意的行
+func printCrontabIssues(ctx *Context, result *common.CrontabImportResult) {
+	var (
+		issue *common.CrontabIssue
+	)
+	for _, issue = range result.Skipped {
+		fmt.Fprintf(ctx.stderr, "跳过 第%d行: %s\n    %s\n", issue.Line, issue.Reason, strings.TrimSpace(issue.Text))
+	}
+	for _, issue = range result.Warnings {
+		fmt.Fprintf(ctx.stderr, "注意 第%d行: %s\n    %s\n", issue.Line, issue.Reason, strings.TrimSpace(issue.Text))
+	}
+	fmt.Fprintf(ctx.stderr, "转换 %d 个任务，跳过 %d 行，%d 处需要注意\n", len(result.Jobs), len(result.Skipped), len(result.Warnings))
+}
+
+// cronctl import-crontab -f FILE [-system] [-prefix PREFIX] [-apply]
+// 默认输出可以直接用于apply的YAML清单，-apply时直接保存
+func runImportCrontab(ctx *Context, cmd *command, args []string) (err error) {
+	var (
+		fs         *flag.FlagSet
+		file       string
+		system     bool
+		prefix     string
+		apply      bool
+		positional []string
+		content    []byte
+		client     *Client
+		result     *common.CrontabImportResult
+		manifest   []byte
+	)
+	fs = ctx.flagSet(cmd)
+	fs.StringVar(&file, "f", "", "crontab文件，- 表示标准输入")
+	fs.BoolVar(&system, "system", false, "系统crontab格式（带用户字段），/etc/crontab 和 /etc/cron.d/* 自动识别")
+	fs.StringVar(&prefix, "prefix", "", "生成的任务名称前缀")
+	fs.BoolVar(&apply, "apply", false, "直接保存生成的任务，已存在的同名任务不修改")
+	if positional, err = ctx.parse(fs, args); err != nil {
+		return
+	}
+	if len(positional) != 0 {
+		return newUsageError("多余的参数: %s", strings.Join(positional, " "))
+	}
+	if file == "" {
+		return newUsageError("需要用 -f 指定crontab文件")
+	}
+	if file == "-" {
+		content, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		content, err = ioutil.ReadFile(file)
+		system = system || isSystemCrontab(file)
+	}
+	if err != nil {
+		return
+	}
+
+	if client, err = ctx.Client(); err != nil {
+		return
+	}
+	if err = client.Post("/job/import/crontab", url.Values{
+		"crontab":   {string(content)},
+		"system":    {strconv.FormatBool(system)},
+		"prefix":    {prefix},
+		"namespace": {ctx.options.namespace},
+		"apply":     {strconv.FormatBool(apply)},
+	}, &result); err != nil {
+		return
+	}
+	if ctx.JSON() {
+		return printJSON(ctx.stdout, result)
+	}
+
+	printCrontabIssues(ctx, result)
+	if apply {
+		// 导入不会删除其他任务，不提示prune
+		printChanges(ctx, result.Applied, true)
+		return
+	}
+	if manifest, err = yaml.Marshal(&common.JobManifest{Version: common.JOB_MANIFEST_VERSION, Jobs: result.Jobs}); err != nil {
+		return
+	}
+	_, err = ctx.stdout.Write(manifest)
+	return
+}
@@ -0,0 +1,94 @@
+package cronctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// 输出格式
+const (
+	OUTPUT_TABLE = "table"
+	OUTPUT_JSON  = "json"
+)
+
+// 缩进的json
+func printJSON(out io.Writer, value interface{}) (err error) {
+	var (
+		content []byte
+	)
+	if content, err = json.MarshalIndent(value, "", "  "); err != nil {
+		return
+	}
+	_, err = fmt.Fprintln(out, string(content))
+	return
+}
+
+// 对齐的表格，表头和每一行都用制表符分隔
+type table struct {
+	writer *tabwriter.Writer
+}
+
+func newTable(out io.Writer, headers ...string) (t *table) {
+	t = &table{writer: tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)}
+	if len(headers) > 0 {
+		t.row(headers...)
+	}
+	return
+}
+
+func (t *table) row(columns ...string) {
+	fmt.Fprintln(t.writer, strings.Join(columns, "\t"))
+}
+
+func (t *table) flush() error {
+	return t.writer.Flush()
+}
+
+// 毫秒时间戳格式化，0显示为 -
+func formatMillis(millis int64) string {
+	if millis <= 0 {
+		return "-"
+	}
+	return time.Unix(0, millis*int64(time.Millisecond)).Format("2006-01-02 15:04:05")
+}
+
+// 两个毫秒时间戳之间的耗时
+func formatDuration(startMillis int64, endMillis int64) string {
+	if startMillis <= 0 || endMillis < startMillis {
+		return "-"
+	}
+	return (time.Duration(endMillis-startMillis) * time.Millisecond).String()
+}
+
+// 秒数，0显示为 -
+func formatSeconds(seconds int64) string {
+	if seconds <= 0 {
+		return "-"
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// 空字符串显示为 -
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// 截断过长的列，避免表格被撑开
+func truncate(value string, max int) string {
+	var (
+		runes []rune
+	)
+	value = strings.Replace(value, "\n", " ", -1)
+	runes = []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+	return string(runes[:max-3]) + "..."
+}
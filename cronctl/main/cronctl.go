@@ -0,0 +1,10 @@
+package main
+
+import (
+	"../../cronctl"
+	"os"
+)
+
+func main() {
+	os.Exit(cronctl.Run(os.Args[1:]))
+}
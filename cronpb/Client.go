@@ -0,0 +1,37 @@
+package cronpb
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// 每次请求在元数据中携带 authorization: Bearer <token>
+type tokenCredentials struct {
+	token string
+}
+
+func (creds tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + creds.token}, nil
+}
+
+func (creds tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// 连接master的gRPC服务（master.json中的grpcPort），token为空则不携带
+// 默认使用明文连接，opts追加在默认选项之后，可以用来配置TLS等
+//
+//	conn, err := cronpb.Dial("127.0.0.1:8072", token)
+//	client := cronpb.NewCronServiceClient(conn)
+func Dial(addr string, token string, opts ...grpc.DialOption) (conn *grpc.ClientConn, err error) {
+	var (
+		dialOpts []grpc.DialOption
+	)
+	dialOpts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(tokenCredentials{token: token}))
+	}
+	dialOpts = append(dialOpts, opts...)
+	return grpc.NewClient(addr, dialOpts...)
+}
@@ -0,0 +1,2221 @@
+// crontab master的gRPC接口，和 /job/*、/api/v1/* 接口功能一致
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: cronpb/cron.proto
+
+package cronpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type JobEvent_Type int32
+
+const (
+	JobEvent_PUT    JobEvent_Type = 0
+	JobEvent_DELETE JobEvent_Type = 1
+)
+
+// Enum value maps for JobEvent_Type.
+var (
+	JobEvent_Type_name = map[int32]string{
+		0: "PUT",
+		1: "DELETE",
+	}
+	JobEvent_Type_value = map[string]int32{
+		"PUT":    0,
+		"DELETE": 1,
+	}
+)
+
+func (x JobEvent_Type) Enum() *JobEvent_Type {
+	p := new(JobEvent_Type)
+	*p = x
+	return p
+}
+
+func (x JobEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (JobEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_cronpb_cron_proto_enumTypes[0].Descriptor()
+}
+
+func (JobEvent_Type) Type() protoreflect.EnumType {
+	return &file_cronpb_cron_proto_enumTypes[0]
+}
+
+func (x JobEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use JobEvent_Type.Descriptor instead.
+func (JobEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{26, 0}
+}
+
+// 日志保留策略
+// 0表示使用全局配置，-1表示不限制
+type LogRetention struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MaxAge        int64                  `protobuf:"varint,1,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"`       // 最长保留时间，秒
+	MaxCount      int64                  `protobuf:"varint,2,opt,name=max_count,json=maxCount,proto3" json:"max_count,omitempty"` // 最多保留条数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogRetention) Reset() {
+	*x = LogRetention{}
+	mi := &file_cronpb_cron_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogRetention) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogRetention) ProtoMessage() {}
+
+func (x *LogRetention) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogRetention.ProtoReflect.Descriptor instead.
+func (*LogRetention) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LogRetention) GetMaxAge() int64 {
+	if x != nil {
+		return x.MaxAge
+	}
+	return 0
+}
+
+func (x *LogRetention) GetMaxCount() int64 {
+	if x != nil {
+		return x.MaxCount
+	}
+	return 0
+}
+
+// 通知规则
+type JobNotify struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	OnFailure           bool                   `protobuf:"varint,1,opt,name=on_failure,json=onFailure,proto3" json:"on_failure,omitempty"`
+	OnTimeout           bool                   `protobuf:"varint,2,opt,name=on_timeout,json=onTimeout,proto3" json:"on_timeout,omitempty"`
+	ConsecutiveFailures int32                  `protobuf:"varint,3,opt,name=consecutive_failures,json=consecutiveFailures,proto3" json:"consecutive_failures,omitempty"`
+	OnRecovery          bool                   `protobuf:"varint,4,opt,name=on_recovery,json=onRecovery,proto3" json:"on_recovery,omitempty"`
+	Targets             []string               `protobuf:"bytes,5,rep,name=targets,proto3" json:"targets,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *JobNotify) Reset() {
+	*x = JobNotify{}
+	mi := &file_cronpb_cron_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobNotify) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobNotify) ProtoMessage() {}
+
+func (x *JobNotify) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobNotify.ProtoReflect.Descriptor instead.
+func (*JobNotify) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *JobNotify) GetOnFailure() bool {
+	if x != nil {
+		return x.OnFailure
+	}
+	return false
+}
+
+func (x *JobNotify) GetOnTimeout() bool {
+	if x != nil {
+		return x.OnTimeout
+	}
+	return false
+}
+
+func (x *JobNotify) GetConsecutiveFailures() int32 {
+	if x != nil {
+		return x.ConsecutiveFailures
+	}
+	return 0
+}
+
+func (x *JobNotify) GetOnRecovery() bool {
+	if x != nil {
+		return x.OnRecovery
+	}
+	return false
+}
+
+func (x *JobNotify) GetTargets() []string {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
+// 任务
+type Job struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Name                string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Command             string                 `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+	CronExpr            string                 `protobuf:"bytes,3,opt,name=cron_expr,json=cronExpr,proto3" json:"cron_expr,omitempty"`
+	Mode                string                 `protobuf:"bytes,4,opt,name=mode,proto3" json:"mode,omitempty"`                                                             // 空/single 抢锁单节点执行，broadcast 所有节点都执行
+	Timeout             int32                  `protobuf:"varint,5,opt,name=timeout,proto3" json:"timeout,omitempty"`                                                      // 执行超时时间，秒，0表示不限制
+	ExpectSuccessWithin int64                  `protobuf:"varint,6,opt,name=expect_success_within,json=expectSuccessWithin,proto3" json:"expect_success_within,omitempty"` // 期望在多少秒内至少成功一次，0表示不检查
+	Retention           *LogRetention          `protobuf:"bytes,7,opt,name=retention,proto3" json:"retention,omitempty"`                                                   // 为空则使用master的全局配置
+	Notify              *JobNotify             `protobuf:"bytes,8,opt,name=notify,proto3" json:"notify,omitempty"`                                                         // 为空则不通知
+	Namespace           string                 `protobuf:"bytes,9,opt,name=namespace,proto3" json:"namespace,omitempty"`                                                   // 为空表示default
+	Description         string                 `protobuf:"bytes,10,opt,name=description,proto3" json:"description,omitempty"`
+	Owner               string                 `protobuf:"bytes,11,opt,name=owner,proto3" json:"owner,omitempty"` // 负责人
+	Team                string                 `protobuf:"bytes,12,opt,name=team,proto3" json:"team,omitempty"`   // 所属团队
+	Tags                []string               `protobuf:"bytes,13,rep,name=tags,proto3" json:"tags,omitempty"`
+	CreateTime          int64                  `protobuf:"varint,14,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"` // 毫秒，由master保存时填写
+	UpdateTime          int64                  `protobuf:"varint,15,opt,name=update_time,json=updateTime,proto3" json:"update_time,omitempty"` // 毫秒，由master保存时填写
+	Params              []*JobParam            `protobuf:"bytes,16,rep,name=params,proto3" json:"params,omitempty"`                            // 定义了参数时命令按text/template渲染，参数用 {{.Params.名称 | shellquote}} 转义
+	Template            bool                   `protobuf:"varint,17,opt,name=template,proto3" json:"template,omitempty"`                       // 没有参数时也按模板渲染命令
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_cronpb_cron_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Job) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Job) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *Job) GetCronExpr() string {
+	if x != nil {
+		return x.CronExpr
+	}
+	return ""
+}
+
+func (x *Job) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *Job) GetTimeout() int32 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+func (x *Job) GetExpectSuccessWithin() int64 {
+	if x != nil {
+		return x.ExpectSuccessWithin
+	}
+	return 0
+}
+
+func (x *Job) GetRetention() *LogRetention {
+	if x != nil {
+		return x.Retention
+	}
+	return nil
+}
+
+func (x *Job) GetNotify() *JobNotify {
+	if x != nil {
+		return x.Notify
+	}
+	return nil
+}
+
+func (x *Job) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Job) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Job) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Job) GetTeam() string {
+	if x != nil {
+		return x.Team
+	}
+	return ""
+}
+
+func (x *Job) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Job) GetCreateTime() int64 {
+	if x != nil {
+		return x.CreateTime
+	}
+	return 0
+}
+
+func (x *Job) GetUpdateTime() int64 {
+	if x != nil {
+		return x.UpdateTime
+	}
+	return 0
+}
+
+func (x *Job) GetParams() []*JobParam {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *Job) GetTemplate() bool {
+	if x != nil {
+		return x.Template
+	}
+	return false
+}
+
+// 任务参数
+type JobParam struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`       // string / int / float / bool，为空表示string
+	Default       string                 `protobuf:"bytes,3,opt,name=default,proto3" json:"default,omitempty"` // 默认值的字符串形式，按类型解析，为空表示类型的零值
+	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobParam) Reset() {
+	*x = JobParam{}
+	mi := &file_cronpb_cron_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobParam) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobParam) ProtoMessage() {}
+
+func (x *JobParam) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobParam.ProtoReflect.Descriptor instead.
+func (*JobParam) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *JobParam) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *JobParam) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *JobParam) GetDefault() string {
+	if x != nil {
+		return x.Default
+	}
+	return ""
+}
+
+func (x *JobParam) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+// 任务成功心跳状态
+type JobWatchdogState struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	ExpectSuccessWithin int64                  `protobuf:"varint,1,opt,name=expect_success_within,json=expectSuccessWithin,proto3" json:"expect_success_within,omitempty"`
+	LastSuccessTime     int64                  `protobuf:"varint,2,opt,name=last_success_time,json=lastSuccessTime,proto3" json:"last_success_time,omitempty"`
+	Overdue             bool                   `protobuf:"varint,3,opt,name=overdue,proto3" json:"overdue,omitempty"`
+	OverdueSince        int64                  `protobuf:"varint,4,opt,name=overdue_since,json=overdueSince,proto3" json:"overdue_since,omitempty"`
+	CheckTime           int64                  `protobuf:"varint,5,opt,name=check_time,json=checkTime,proto3" json:"check_time,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *JobWatchdogState) Reset() {
+	*x = JobWatchdogState{}
+	mi := &file_cronpb_cron_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobWatchdogState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobWatchdogState) ProtoMessage() {}
+
+func (x *JobWatchdogState) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobWatchdogState.ProtoReflect.Descriptor instead.
+func (*JobWatchdogState) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *JobWatchdogState) GetExpectSuccessWithin() int64 {
+	if x != nil {
+		return x.ExpectSuccessWithin
+	}
+	return 0
+}
+
+func (x *JobWatchdogState) GetLastSuccessTime() int64 {
+	if x != nil {
+		return x.LastSuccessTime
+	}
+	return 0
+}
+
+func (x *JobWatchdogState) GetOverdue() bool {
+	if x != nil {
+		return x.Overdue
+	}
+	return false
+}
+
+func (x *JobWatchdogState) GetOverdueSince() int64 {
+	if x != nil {
+		return x.OverdueSince
+	}
+	return 0
+}
+
+func (x *JobWatchdogState) GetCheckTime() int64 {
+	if x != nil {
+		return x.CheckTime
+	}
+	return 0
+}
+
+// 任务列表中的一项
+type JobListItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Job           *Job                   `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	Watchdog      *JobWatchdogState      `protobuf:"bytes,2,opt,name=watchdog,proto3" json:"watchdog,omitempty"` // 未配置expect_success_within时为空
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobListItem) Reset() {
+	*x = JobListItem{}
+	mi := &file_cronpb_cron_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobListItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobListItem) ProtoMessage() {}
+
+func (x *JobListItem) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobListItem.ProtoReflect.Descriptor instead.
+func (*JobListItem) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *JobListItem) GetJob() *Job {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+func (x *JobListItem) GetWatchdog() *JobWatchdogState {
+	if x != nil {
+		return x.Watchdog
+	}
+	return nil
+}
+
+type SaveJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Job           *Job                   `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SaveJobRequest) Reset() {
+	*x = SaveJobRequest{}
+	mi := &file_cronpb_cron_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SaveJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveJobRequest) ProtoMessage() {}
+
+func (x *SaveJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveJobRequest.ProtoReflect.Descriptor instead.
+func (*SaveJobRequest) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SaveJobRequest) GetJob() *Job {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+type SaveJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OldJob        *Job                   `protobuf:"bytes,1,opt,name=old_job,json=oldJob,proto3" json:"old_job,omitempty"` // 新建任务时为空
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SaveJobResponse) Reset() {
+	*x = SaveJobResponse{}
+	mi := &file_cronpb_cron_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SaveJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveJobResponse) ProtoMessage() {}
+
+func (x *SaveJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveJobResponse.ProtoReflect.Descriptor instead.
+func (*SaveJobResponse) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SaveJobResponse) GetOldJob() *Job {
+	if x != nil {
+		return x.OldJob
+	}
+	return nil
+}
+
+type DeleteJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	PurgeLogs     bool                   `protobuf:"varint,2,opt,name=purge_logs,json=purgeLogs,proto3" json:"purge_logs,omitempty"` // 已废弃，删除任务总是同时删除执行日志，保留日志用keep_logs
+	Namespace     string                 `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`                   // 为空表示default
+	KeepLogs      bool                   `protobuf:"varint,4,opt,name=keep_logs,json=keepLogs,proto3" json:"keep_logs,omitempty"`    // 保留任务的执行日志，之后按全局保留策略清理
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteJobRequest) Reset() {
+	*x = DeleteJobRequest{}
+	mi := &file_cronpb_cron_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteJobRequest) ProtoMessage() {}
+
+func (x *DeleteJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteJobRequest.ProtoReflect.Descriptor instead.
+func (*DeleteJobRequest) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteJobRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DeleteJobRequest) GetPurgeLogs() bool {
+	if x != nil {
+		return x.PurgeLogs
+	}
+	return false
+}
+
+func (x *DeleteJobRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *DeleteJobRequest) GetKeepLogs() bool {
+	if x != nil {
+		return x.KeepLogs
+	}
+	return false
+}
+
+type DeleteJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OldJob        *Job                   `protobuf:"bytes,1,opt,name=old_job,json=oldJob,proto3" json:"old_job,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteJobResponse) Reset() {
+	*x = DeleteJobResponse{}
+	mi := &file_cronpb_cron_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteJobResponse) ProtoMessage() {}
+
+func (x *DeleteJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteJobResponse.ProtoReflect.Descriptor instead.
+func (*DeleteJobResponse) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteJobResponse) GetOldJob() *Job {
+	if x != nil {
+		return x.OldJob
+	}
+	return nil
+}
+
+type GetJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Namespace     string                 `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"` // 为空表示default
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobRequest) Reset() {
+	*x = GetJobRequest{}
+	mi := &file_cronpb_cron_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobRequest) ProtoMessage() {}
+
+func (x *GetJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobRequest.ProtoReflect.Descriptor instead.
+func (*GetJobRequest) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetJobRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GetJobRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+type GetJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Item          *JobListItem           `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobResponse) Reset() {
+	*x = GetJobResponse{}
+	mi := &file_cronpb_cron_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobResponse) ProtoMessage() {}
+
+func (x *GetJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobResponse.ProtoReflect.Descriptor instead.
+func (*GetJobResponse) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetJobResponse) GetItem() *JobListItem {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+type ListJobsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"` // 为空表示所有有查看权限的命名空间
+	Tags          []string               `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty"`           // 同时包含这些标签
+	Owner         string                 `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"`
+	Team          string                 `protobuf:"bytes,4,opt,name=team,proto3" json:"team,omitempty"`
+	Keyword       string                 `protobuf:"bytes,5,opt,name=keyword,proto3" json:"keyword,omitempty"` // 在名称、说明、命令、负责人、团队和标签中搜索，不区分大小写
+	Sort          string                 `protobuf:"bytes,6,opt,name=sort,proto3" json:"sort,omitempty"`       // name / createTime / updateTime / owner，前面加-表示倒序，为空按全名
+	Skip          int32                  `protobuf:"varint,7,opt,name=skip,proto3" json:"skip,omitempty"`
+	Limit         int32                  `protobuf:"varint,8,opt,name=limit,proto3" json:"limit,omitempty"` // 0表示不限制
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsRequest) Reset() {
+	*x = ListJobsRequest{}
+	mi := &file_cronpb_cron_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsRequest) ProtoMessage() {}
+
+func (x *ListJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsRequest.ProtoReflect.Descriptor instead.
+func (*ListJobsRequest) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListJobsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ListJobsRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ListJobsRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *ListJobsRequest) GetTeam() string {
+	if x != nil {
+		return x.Team
+	}
+	return ""
+}
+
+func (x *ListJobsRequest) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+func (x *ListJobsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListJobsRequest) GetSkip() int32 {
+	if x != nil {
+		return x.Skip
+	}
+	return 0
+}
+
+func (x *ListJobsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListJobsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*JobListItem         `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"` // 符合条件的总数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsResponse) Reset() {
+	*x = ListJobsResponse{}
+	mi := &file_cronpb_cron_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsResponse) ProtoMessage() {}
+
+func (x *ListJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsResponse.ProtoReflect.Descriptor instead.
+func (*ListJobsResponse) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListJobsResponse) GetItems() []*JobListItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *ListJobsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type KillJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Namespace     string                 `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"` // 为空表示default
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KillJobRequest) Reset() {
+	*x = KillJobRequest{}
+	mi := &file_cronpb_cron_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KillJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KillJobRequest) ProtoMessage() {}
+
+func (x *KillJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KillJobRequest.ProtoReflect.Descriptor instead.
+func (*KillJobRequest) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *KillJobRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *KillJobRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+type KillJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KillJobResponse) Reset() {
+	*x = KillJobResponse{}
+	mi := &file_cronpb_cron_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KillJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KillJobResponse) ProtoMessage() {}
+
+func (x *KillJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KillJobResponse.ProtoReflect.Descriptor instead.
+func (*KillJobResponse) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{15}
+}
+
+type RunJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Namespace     string                 `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`                                                                     // 为空表示default
+	Params        map[string]string      `protobuf:"bytes,3,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 覆盖任务参数的默认值，按参数类型解析
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunJobRequest) Reset() {
+	*x = RunJobRequest{}
+	mi := &file_cronpb_cron_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunJobRequest) ProtoMessage() {}
+
+func (x *RunJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunJobRequest.ProtoReflect.Descriptor instead.
+func (*RunJobRequest) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RunJobRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RunJobRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *RunJobRequest) GetParams() map[string]string {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+type RunJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunJobResponse) Reset() {
+	*x = RunJobResponse{}
+	mi := &file_cronpb_cron_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunJobResponse) ProtoMessage() {}
+
+func (x *RunJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunJobResponse.ProtoReflect.Descriptor instead.
+func (*RunJobResponse) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{17}
+}
+
+// 执行日志，时间都是毫秒
+type JobLog struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobName       string                 `protobuf:"bytes,1,opt,name=job_name,json=jobName,proto3" json:"job_name,omitempty"` // 任务全名 命名空间/任务名称
+	Command       string                 `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`                // 实际执行的命令，模板渲染之后
+	ExecId        string                 `protobuf:"bytes,3,opt,name=exec_id,json=execId,proto3" json:"exec_id,omitempty"`
+	Worker        string                 `protobuf:"bytes,4,opt,name=worker,proto3" json:"worker,omitempty"`
+	Err           string                 `protobuf:"bytes,5,opt,name=err,proto3" json:"err,omitempty"`
+	Output        string                 `protobuf:"bytes,6,opt,name=output,proto3" json:"output,omitempty"`
+	TimedOut      bool                   `protobuf:"varint,7,opt,name=timed_out,json=timedOut,proto3" json:"timed_out,omitempty"`
+	PlanTime      int64                  `protobuf:"varint,8,opt,name=plan_time,json=planTime,proto3" json:"plan_time,omitempty"`
+	ScheduleTime  int64                  `protobuf:"varint,9,opt,name=schedule_time,json=scheduleTime,proto3" json:"schedule_time,omitempty"`
+	StartTime     int64                  `protobuf:"varint,10,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       int64                  `protobuf:"varint,11,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobLog) Reset() {
+	*x = JobLog{}
+	mi := &file_cronpb_cron_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobLog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobLog) ProtoMessage() {}
+
+func (x *JobLog) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobLog.ProtoReflect.Descriptor instead.
+func (*JobLog) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *JobLog) GetJobName() string {
+	if x != nil {
+		return x.JobName
+	}
+	return ""
+}
+
+func (x *JobLog) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *JobLog) GetExecId() string {
+	if x != nil {
+		return x.ExecId
+	}
+	return ""
+}
+
+func (x *JobLog) GetWorker() string {
+	if x != nil {
+		return x.Worker
+	}
+	return ""
+}
+
+func (x *JobLog) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+func (x *JobLog) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *JobLog) GetTimedOut() bool {
+	if x != nil {
+		return x.TimedOut
+	}
+	return false
+}
+
+func (x *JobLog) GetPlanTime() int64 {
+	if x != nil {
+		return x.PlanTime
+	}
+	return 0
+}
+
+func (x *JobLog) GetScheduleTime() int64 {
+	if x != nil {
+		return x.ScheduleTime
+	}
+	return 0
+}
+
+func (x *JobLog) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *JobLog) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+type ListLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                             // 任务名称，为空表示命名空间中的所有任务
+	StartTime     int64                  `protobuf:"varint,2,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"` // 开始时间 >= start_time，毫秒
+	EndTime       int64                  `protobuf:"varint,3,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`       // 开始时间 < end_time，毫秒
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`                         // success / failed
+	Worker        string                 `protobuf:"bytes,5,opt,name=worker,proto3" json:"worker,omitempty"`
+	Keyword       string                 `protobuf:"bytes,6,opt,name=keyword,proto3" json:"keyword,omitempty"` // 在输出和错误原因中搜索，必须同时指定name或start_time
+	Skip          int64                  `protobuf:"varint,7,opt,name=skip,proto3" json:"skip,omitempty"`
+	Limit         int64                  `protobuf:"varint,8,opt,name=limit,proto3" json:"limit,omitempty"`        // 0表示默认的20条
+	Namespace     string                 `protobuf:"bytes,9,opt,name=namespace,proto3" json:"namespace,omitempty"` // 为空时，指定了name表示default，没有指定name表示所有命名空间
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLogsRequest) Reset() {
+	*x = ListLogsRequest{}
+	mi := &file_cronpb_cron_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLogsRequest) ProtoMessage() {}
+
+func (x *ListLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLogsRequest.ProtoReflect.Descriptor instead.
+func (*ListLogsRequest) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListLogsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ListLogsRequest) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *ListLogsRequest) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *ListLogsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListLogsRequest) GetWorker() string {
+	if x != nil {
+		return x.Worker
+	}
+	return ""
+}
+
+func (x *ListLogsRequest) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+func (x *ListLogsRequest) GetSkip() int64 {
+	if x != nil {
+		return x.Skip
+	}
+	return 0
+}
+
+func (x *ListLogsRequest) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListLogsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+type ListLogsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int64                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Logs          []*JobLog              `protobuf:"bytes,2,rep,name=logs,proto3" json:"logs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLogsResponse) Reset() {
+	*x = ListLogsResponse{}
+	mi := &file_cronpb_cron_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLogsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLogsResponse) ProtoMessage() {}
+
+func (x *ListLogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLogsResponse.ProtoReflect.Descriptor instead.
+func (*ListLogsResponse) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListLogsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListLogsResponse) GetLogs() []*JobLog {
+	if x != nil {
+		return x.Logs
+	}
+	return nil
+}
+
+// worker日志模块状态
+type LogSinkStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	QueueLen      int32                  `protobuf:"varint,1,opt,name=queue_len,json=queueLen,proto3" json:"queue_len,omitempty"`
+	SpoolSegments int32                  `protobuf:"varint,2,opt,name=spool_segments,json=spoolSegments,proto3" json:"spool_segments,omitempty"`
+	SpoolLogs     int32                  `protobuf:"varint,3,opt,name=spool_logs,json=spoolLogs,proto3" json:"spool_logs,omitempty"`
+	DroppedLogs   int64                  `protobuf:"varint,4,opt,name=dropped_logs,json=droppedLogs,proto3" json:"dropped_logs,omitempty"`
+	FlushErrors   int64                  `protobuf:"varint,5,opt,name=flush_errors,json=flushErrors,proto3" json:"flush_errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogSinkStats) Reset() {
+	*x = LogSinkStats{}
+	mi := &file_cronpb_cron_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogSinkStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogSinkStats) ProtoMessage() {}
+
+func (x *LogSinkStats) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogSinkStats.ProtoReflect.Descriptor instead.
+func (*LogSinkStats) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *LogSinkStats) GetQueueLen() int32 {
+	if x != nil {
+		return x.QueueLen
+	}
+	return 0
+}
+
+func (x *LogSinkStats) GetSpoolSegments() int32 {
+	if x != nil {
+		return x.SpoolSegments
+	}
+	return 0
+}
+
+func (x *LogSinkStats) GetSpoolLogs() int32 {
+	if x != nil {
+		return x.SpoolLogs
+	}
+	return 0
+}
+
+func (x *LogSinkStats) GetDroppedLogs() int64 {
+	if x != nil {
+		return x.DroppedLogs
+	}
+	return 0
+}
+
+func (x *LogSinkStats) GetFlushErrors() int64 {
+	if x != nil {
+		return x.FlushErrors
+	}
+	return 0
+}
+
+// worker节点
+type WorkerInfo struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Ip             string                 `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+	Hostname       string                 `protobuf:"bytes,3,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Pid            int32                  `protobuf:"varint,4,opt,name=pid,proto3" json:"pid,omitempty"`
+	Version        string                 `protobuf:"bytes,5,opt,name=version,proto3" json:"version,omitempty"`
+	StartTime      int64                  `protobuf:"varint,6,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	Labels         map[string]string      `protobuf:"bytes,7,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	CpuNum         int32                  `protobuf:"varint,8,opt,name=cpu_num,json=cpuNum,proto3" json:"cpu_num,omitempty"`
+	MemTotal       uint64                 `protobuf:"varint,9,opt,name=mem_total,json=memTotal,proto3" json:"mem_total,omitempty"`
+	MaxConcurrency int32                  `protobuf:"varint,10,opt,name=max_concurrency,json=maxConcurrency,proto3" json:"max_concurrency,omitempty"`
+	RunningJobs    int32                  `protobuf:"varint,11,opt,name=running_jobs,json=runningJobs,proto3" json:"running_jobs,omitempty"`
+	FreeSlots      int32                  `protobuf:"varint,12,opt,name=free_slots,json=freeSlots,proto3" json:"free_slots,omitempty"` // 不限制时为-1
+	State          string                 `protobuf:"bytes,13,opt,name=state,proto3" json:"state,omitempty"`                           // active / cordoned / draining / drained
+	LogSink        *LogSinkStats          `protobuf:"bytes,14,opt,name=log_sink,json=logSink,proto3" json:"log_sink,omitempty"`
+	UpdateTime     int64                  `protobuf:"varint,15,opt,name=update_time,json=updateTime,proto3" json:"update_time,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *WorkerInfo) Reset() {
+	*x = WorkerInfo{}
+	mi := &file_cronpb_cron_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkerInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkerInfo) ProtoMessage() {}
+
+func (x *WorkerInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkerInfo.ProtoReflect.Descriptor instead.
+func (*WorkerInfo) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *WorkerInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *WorkerInfo) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *WorkerInfo) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *WorkerInfo) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *WorkerInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *WorkerInfo) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *WorkerInfo) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *WorkerInfo) GetCpuNum() int32 {
+	if x != nil {
+		return x.CpuNum
+	}
+	return 0
+}
+
+func (x *WorkerInfo) GetMemTotal() uint64 {
+	if x != nil {
+		return x.MemTotal
+	}
+	return 0
+}
+
+func (x *WorkerInfo) GetMaxConcurrency() int32 {
+	if x != nil {
+		return x.MaxConcurrency
+	}
+	return 0
+}
+
+func (x *WorkerInfo) GetRunningJobs() int32 {
+	if x != nil {
+		return x.RunningJobs
+	}
+	return 0
+}
+
+func (x *WorkerInfo) GetFreeSlots() int32 {
+	if x != nil {
+		return x.FreeSlots
+	}
+	return 0
+}
+
+func (x *WorkerInfo) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *WorkerInfo) GetLogSink() *LogSinkStats {
+	if x != nil {
+		return x.LogSink
+	}
+	return nil
+}
+
+func (x *WorkerInfo) GetUpdateTime() int64 {
+	if x != nil {
+		return x.UpdateTime
+	}
+	return 0
+}
+
+type ListWorkersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkersRequest) Reset() {
+	*x = ListWorkersRequest{}
+	mi := &file_cronpb_cron_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkersRequest) ProtoMessage() {}
+
+func (x *ListWorkersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkersRequest.ProtoReflect.Descriptor instead.
+func (*ListWorkersRequest) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{23}
+}
+
+type ListWorkersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Workers       []*WorkerInfo          `protobuf:"bytes,1,rep,name=workers,proto3" json:"workers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkersResponse) Reset() {
+	*x = ListWorkersResponse{}
+	mi := &file_cronpb_cron_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkersResponse) ProtoMessage() {}
+
+func (x *ListWorkersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkersResponse.ProtoReflect.Descriptor instead.
+func (*ListWorkersResponse) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListWorkersResponse) GetWorkers() []*WorkerInfo {
+	if x != nil {
+		return x.Workers
+	}
+	return nil
+}
+
+type WatchJobsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 先把当前所有任务作为PUT事件发送，再推送之后的变化，中间不会漏掉事件
+	IncludeExisting bool   `protobuf:"varint,1,opt,name=include_existing,json=includeExisting,proto3" json:"include_existing,omitempty"`
+	Namespace       string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"` // 为空表示所有有查看权限的命名空间
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *WatchJobsRequest) Reset() {
+	*x = WatchJobsRequest{}
+	mi := &file_cronpb_cron_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchJobsRequest) ProtoMessage() {}
+
+func (x *WatchJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchJobsRequest.ProtoReflect.Descriptor instead.
+func (*WatchJobsRequest) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *WatchJobsRequest) GetIncludeExisting() bool {
+	if x != nil {
+		return x.IncludeExisting
+	}
+	return false
+}
+
+func (x *WatchJobsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+// 任务变化事件
+type JobEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          JobEvent_Type          `protobuf:"varint,1,opt,name=type,proto3,enum=crontab.v1.JobEvent_Type" json:"type,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`          // 任务全名 命名空间/任务名称
+	Job           *Job                   `protobuf:"bytes,3,opt,name=job,proto3" json:"job,omitempty"`            // DELETE时为空
+	Revision      int64                  `protobuf:"varint,4,opt,name=revision,proto3" json:"revision,omitempty"` // 事件对应的etcd revision
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobEvent) Reset() {
+	*x = JobEvent{}
+	mi := &file_cronpb_cron_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobEvent) ProtoMessage() {}
+
+func (x *JobEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_cronpb_cron_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobEvent.ProtoReflect.Descriptor instead.
+func (*JobEvent) Descriptor() ([]byte, []int) {
+	return file_cronpb_cron_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *JobEvent) GetType() JobEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return JobEvent_PUT
+}
+
+func (x *JobEvent) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *JobEvent) GetJob() *Job {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+func (x *JobEvent) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+var File_cronpb_cron_proto protoreflect.FileDescriptor
+
+const file_cronpb_cron_proto_rawDesc = "" +
+	"\n" +
+	"\x11cronpb/cron.proto\x12\n" +
+	"crontab.v1\"D\n" +
+	"\fLogRetention\x12\x17\n" +
+	"\amax_age\x18\x01 \x01(\x03R\x06maxAge\x12\x1b\n" +
+	"\tmax_count\x18\x02 \x01(\x03R\bmaxCount\"\xb7\x01\n" +
+	"\tJobNotify\x12\x1d\n" +
+	"\n" +
+	"on_failure\x18\x01 \x01(\bR\tonFailure\x12\x1d\n" +
+	"\n" +
+	"on_timeout\x18\x02 \x01(\bR\tonTimeout\x121\n" +
+	"\x14consecutive_failures\x18\x03 \x01(\x05R\x13consecutiveFailures\x12\x1f\n" +
+	"\von_recovery\x18\x04 \x01(\bR\n" +
+	"onRecovery\x12\x18\n" +
+	"\atargets\x18\x05 \x03(\tR\atargets\"\xa3\x04\n" +
+	"\x03Job\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\acommand\x18\x02 \x01(\tR\acommand\x12\x1b\n" +
+	"\tcron_expr\x18\x03 \x01(\tR\bcronExpr\x12\x12\n" +
+	"\x04mode\x18\x04 \x01(\tR\x04mode\x12\x18\n" +
+	"\atimeout\x18\x05 \x01(\x05R\atimeout\x122\n" +
+	"\x15expect_success_within\x18\x06 \x01(\x03R\x13expectSuccessWithin\x126\n" +
+	"\tretention\x18\a \x01(\v2\x18.crontab.v1.LogRetentionR\tretention\x12-\n" +
+	"\x06notify\x18\b \x01(\v2\x15.crontab.v1.JobNotifyR\x06notify\x12\x1c\n" +
+	"\tnamespace\x18\t \x01(\tR\tnamespace\x12 \n" +
+	"\vdescription\x18\n" +
+	" \x01(\tR\vdescription\x12\x14\n" +
+	"\x05owner\x18\v \x01(\tR\x05owner\x12\x12\n" +
+	"\x04team\x18\f \x01(\tR\x04team\x12\x12\n" +
+	"\x04tags\x18\r \x03(\tR\x04tags\x12\x1f\n" +
+	"\vcreate_time\x18\x0e \x01(\x03R\n" +
+	"createTime\x12\x1f\n" +
+	"\vupdate_time\x18\x0f \x01(\x03R\n" +
+	"updateTime\x12,\n" +
+	"\x06params\x18\x10 \x03(\v2\x14.crontab.v1.JobParamR\x06params\x12\x1a\n" +
+	"\btemplate\x18\x11 \x01(\bR\btemplate\"n\n" +
+	"\bJobParam\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x18\n" +
+	"\adefault\x18\x03 \x01(\tR\adefault\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\"\xd0\x01\n" +
+	"\x10JobWatchdogState\x122\n" +
+	"\x15expect_success_within\x18\x01 \x01(\x03R\x13expectSuccessWithin\x12*\n" +
+	"\x11last_success_time\x18\x02 \x01(\x03R\x0flastSuccessTime\x12\x18\n" +
+	"\aoverdue\x18\x03 \x01(\bR\aoverdue\x12#\n" +
+	"\roverdue_since\x18\x04 \x01(\x03R\foverdueSince\x12\x1d\n" +
+	"\n" +
+	"check_time\x18\x05 \x01(\x03R\tcheckTime\"j\n" +
+	"\vJobListItem\x12!\n" +
+	"\x03job\x18\x01 \x01(\v2\x0f.crontab.v1.JobR\x03job\x128\n" +
+	"\bwatchdog\x18\x02 \x01(\v2\x1c.crontab.v1.JobWatchdogStateR\bwatchdog\"3\n" +
+	"\x0eSaveJobRequest\x12!\n" +
+	"\x03job\x18\x01 \x01(\v2\x0f.crontab.v1.JobR\x03job\";\n" +
+	"\x0fSaveJobResponse\x12(\n" +
+	"\aold_job\x18\x01 \x01(\v2\x0f.crontab.v1.JobR\x06oldJob\"\x80\x01\n" +
+	"\x10DeleteJobRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"purge_logs\x18\x02 \x01(\bR\tpurgeLogs\x12\x1c\n" +
+	"\tnamespace\x18\x03 \x01(\tR\tnamespace\x12\x1b\n" +
+	"\tkeep_logs\x18\x04 \x01(\bR\bkeepLogs\"=\n" +
+	"\x11DeleteJobResponse\x12(\n" +
+	"\aold_job\x18\x01 \x01(\v2\x0f.crontab.v1.JobR\x06oldJob\"A\n" +
+	"\rGetJobRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1c\n" +
+	"\tnamespace\x18\x02 \x01(\tR\tnamespace\"=\n" +
+	"\x0eGetJobResponse\x12+\n" +
+	"\x04item\x18\x01 \x01(\v2\x17.crontab.v1.JobListItemR\x04item\"\xc5\x01\n" +
+	"\x0fListJobsRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x12\n" +
+	"\x04tags\x18\x02 \x03(\tR\x04tags\x12\x14\n" +
+	"\x05owner\x18\x03 \x01(\tR\x05owner\x12\x12\n" +
+	"\x04team\x18\x04 \x01(\tR\x04team\x12\x18\n" +
+	"\akeyword\x18\x05 \x01(\tR\akeyword\x12\x12\n" +
+	"\x04sort\x18\x06 \x01(\tR\x04sort\x12\x12\n" +
+	"\x04skip\x18\a \x01(\x05R\x04skip\x12\x14\n" +
+	"\x05limit\x18\b \x01(\x05R\x05limit\"W\n" +
+	"\x10ListJobsResponse\x12-\n" +
+	"\x05items\x18\x01 \x03(\v2\x17.crontab.v1.JobListItemR\x05items\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\"B\n" +
+	"\x0eKillJobRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1c\n" +
+	"\tnamespace\x18\x02 \x01(\tR\tnamespace\"\x11\n" +
+	"\x0fKillJobResponse\"\xbb\x01\n" +
+	"\rRunJobRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1c\n" +
+	"\tnamespace\x18\x02 \x01(\tR\tnamespace\x12=\n" +
+	"\x06params\x18\x03 \x03(\v2%.crontab.v1.RunJobRequest.ParamsEntryR\x06params\x1a9\n" +
+	"\vParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x10\n" +
+	"\x0eRunJobResponse\"\xb1\x02\n" +
+	"\x06JobLog\x12\x19\n" +
+	"\bjob_name\x18\x01 \x01(\tR\ajobName\x12\x18\n" +
+	"\acommand\x18\x02 \x01(\tR\acommand\x12\x17\n" +
+	"\aexec_id\x18\x03 \x01(\tR\x06execId\x12\x16\n" +
+	"\x06worker\x18\x04 \x01(\tR\x06worker\x12\x10\n" +
+	"\x03err\x18\x05 \x01(\tR\x03err\x12\x16\n" +
+	"\x06output\x18\x06 \x01(\tR\x06output\x12\x1b\n" +
+	"\ttimed_out\x18\a \x01(\bR\btimedOut\x12\x1b\n" +
+	"\tplan_time\x18\b \x01(\x03R\bplanTime\x12#\n" +
+	"\rschedule_time\x18\t \x01(\x03R\fscheduleTime\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\n" +
+	" \x01(\x03R\tstartTime\x12\x19\n" +
+	"\bend_time\x18\v \x01(\x03R\aendTime\"\xf1\x01\n" +
+	"\x0fListLogsRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x02 \x01(\x03R\tstartTime\x12\x19\n" +
+	"\bend_time\x18\x03 \x01(\x03R\aendTime\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x16\n" +
+	"\x06worker\x18\x05 \x01(\tR\x06worker\x12\x18\n" +
+	"\akeyword\x18\x06 \x01(\tR\akeyword\x12\x12\n" +
+	"\x04skip\x18\a \x01(\x03R\x04skip\x12\x14\n" +
+	"\x05limit\x18\b \x01(\x03R\x05limit\x12\x1c\n" +
+	"\tnamespace\x18\t \x01(\tR\tnamespace\"P\n" +
+	"\x10ListLogsResponse\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x03R\x05total\x12&\n" +
+	"\x04logs\x18\x02 \x03(\v2\x12.crontab.v1.JobLogR\x04logs\"\xb7\x01\n" +
+	"\fLogSinkStats\x12\x1b\n" +
+	"\tqueue_len\x18\x01 \x01(\x05R\bqueueLen\x12%\n" +
+	"\x0espool_segments\x18\x02 \x01(\x05R\rspoolSegments\x12\x1d\n" +
+	"\n" +
+	"spool_logs\x18\x03 \x01(\x05R\tspoolLogs\x12!\n" +
+	"\fdropped_logs\x18\x04 \x01(\x03R\vdroppedLogs\x12!\n" +
+	"\fflush_errors\x18\x05 \x01(\x03R\vflushErrors\"\x97\x04\n" +
+	"\n" +
+	"WorkerInfo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x0e\n" +
+	"\x02ip\x18\x02 \x01(\tR\x02ip\x12\x1a\n" +
+	"\bhostname\x18\x03 \x01(\tR\bhostname\x12\x10\n" +
+	"\x03pid\x18\x04 \x01(\x05R\x03pid\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\tR\aversion\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x06 \x01(\x03R\tstartTime\x12:\n" +
+	"\x06labels\x18\a \x03(\v2\".crontab.v1.WorkerInfo.LabelsEntryR\x06labels\x12\x17\n" +
+	"\acpu_num\x18\b \x01(\x05R\x06cpuNum\x12\x1b\n" +
+	"\tmem_total\x18\t \x01(\x04R\bmemTotal\x12'\n" +
+	"\x0fmax_concurrency\x18\n" +
+	" \x01(\x05R\x0emaxConcurrency\x12!\n" +
+	"\frunning_jobs\x18\v \x01(\x05R\vrunningJobs\x12\x1d\n" +
+	"\n" +
+	"free_slots\x18\f \x01(\x05R\tfreeSlots\x12\x14\n" +
+	"\x05state\x18\r \x01(\tR\x05state\x123\n" +
+	"\blog_sink\x18\x0e \x01(\v2\x18.crontab.v1.LogSinkStatsR\alogSink\x12\x1f\n" +
+	"\vupdate_time\x18\x0f \x01(\x03R\n" +
+	"updateTime\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x14\n" +
+	"\x12ListWorkersRequest\"G\n" +
+	"\x13ListWorkersResponse\x120\n" +
+	"\aworkers\x18\x01 \x03(\v2\x16.crontab.v1.WorkerInfoR\aworkers\"[\n" +
+	"\x10WatchJobsRequest\x12)\n" +
+	"\x10include_existing\x18\x01 \x01(\bR\x0fincludeExisting\x12\x1c\n" +
+	"\tnamespace\x18\x02 \x01(\tR\tnamespace\"\xa9\x01\n" +
+	"\bJobEvent\x12-\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x19.crontab.v1.JobEvent.TypeR\x04type\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12!\n" +
+	"\x03job\x18\x03 \x01(\v2\x0f.crontab.v1.JobR\x03job\x12\x1a\n" +
+	"\brevision\x18\x04 \x01(\x03R\brevision\"\x1b\n" +
+	"\x04Type\x12\a\n" +
+	"\x03PUT\x10\x00\x12\n" +
+	"\n" +
+	"\x06DELETE\x10\x012\x82\x05\n" +
+	"\vCronService\x12B\n" +
+	"\aSaveJob\x12\x1a.crontab.v1.SaveJobRequest\x1a\x1b.crontab.v1.SaveJobResponse\x12H\n" +
+	"\tDeleteJob\x12\x1c.crontab.v1.DeleteJobRequest\x1a\x1d.crontab.v1.DeleteJobResponse\x12?\n" +
+	"\x06GetJob\x12\x19.crontab.v1.GetJobRequest\x1a\x1a.crontab.v1.GetJobResponse\x12E\n" +
+	"\bListJobs\x12\x1b.crontab.v1.ListJobsRequest\x1a\x1c.crontab.v1.ListJobsResponse\x12B\n" +
+	"\aKillJob\x12\x1a.crontab.v1.KillJobRequest\x1a\x1b.crontab.v1.KillJobResponse\x12?\n" +
+	"\x06RunJob\x12\x19.crontab.v1.RunJobRequest\x1a\x1a.crontab.v1.RunJobResponse\x12E\n" +
+	"\bListLogs\x12\x1b.crontab.v1.ListLogsRequest\x1a\x1c.crontab.v1.ListLogsResponse\x12N\n" +
+	"\vListWorkers\x12\x1e.crontab.v1.ListWorkersRequest\x1a\x1f.crontab.v1.ListWorkersResponse\x12A\n" +
+	"\tWatchJobs\x12\x1c.crontab.v1.WatchJobsRequest\x1a\x14.crontab.v1.JobEvent0\x01B(\n" +
+	"\rio.crontab.v1P\x01Z\x15crontab/cronpb;cronpbb\x06proto3"
+
+var (
+	file_cronpb_cron_proto_rawDescOnce sync.Once
+	file_cronpb_cron_proto_rawDescData []byte
+)
+
+func file_cronpb_cron_proto_rawDescGZIP() []byte {
+	file_cronpb_cron_proto_rawDescOnce.Do(func() {
+		file_cronpb_cron_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_cronpb_cron_proto_rawDesc), len(file_cronpb_cron_proto_rawDesc)))
+	})
+	return file_cronpb_cron_proto_rawDescData
+}
+
+var file_cronpb_cron_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_cronpb_cron_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
+var file_cronpb_cron_proto_goTypes = []any{
+	(JobEvent_Type)(0),          // 0: crontab.v1.JobEvent.Type
+	(*LogRetention)(nil),        // 1: crontab.v1.LogRetention
+	(*JobNotify)(nil),           // 2: crontab.v1.JobNotify
+	(*Job)(nil),                 // 3: crontab.v1.Job
+	(*JobParam)(nil),            // 4: crontab.v1.JobParam
+	(*JobWatchdogState)(nil),    // 5: crontab.v1.JobWatchdogState
+	(*JobListItem)(nil),         // 6: crontab.v1.JobListItem
+	(*SaveJobRequest)(nil),      // 7: crontab.v1.SaveJobRequest
+	(*SaveJobResponse)(nil),     // 8: crontab.v1.SaveJobResponse
+	(*DeleteJobRequest)(nil),    // 9: crontab.v1.DeleteJobRequest
+	(*DeleteJobResponse)(nil),   // 10: crontab.v1.DeleteJobResponse
+	(*GetJobRequest)(nil),       // 11: crontab.v1.GetJobRequest
+	(*GetJobResponse)(nil),      // 12: crontab.v1.GetJobResponse
+	(*ListJobsRequest)(nil),     // 13: crontab.v1.ListJobsRequest
+	(*ListJobsResponse)(nil),    // 14: crontab.v1.ListJobsResponse
+	(*KillJobRequest)(nil),      // 15: crontab.v1.KillJobRequest
+	(*KillJobResponse)(nil),     // 16: crontab.v1.KillJobResponse
+	(*RunJobRequest)(nil),       // 17: crontab.v1.RunJobRequest
+	(*RunJobResponse)(nil),      // 18: crontab.v1.RunJobResponse
+	(*JobLog)(nil),              // 19: crontab.v1.JobLog
+	(*ListLogsRequest)(nil),     // 20: crontab.v1.ListLogsRequest
+	(*ListLogsResponse)(nil),    // 21: crontab.v1.ListLogsResponse
+	(*LogSinkStats)(nil),        // 22: crontab.v1.LogSinkStats
+	(*WorkerInfo)(nil),          // 23: crontab.v1.WorkerInfo
+	(*ListWorkersRequest)(nil),  // 24: crontab.v1.ListWorkersRequest
+	(*ListWorkersResponse)(nil), // 25: crontab.v1.ListWorkersResponse
+	(*WatchJobsRequest)(nil),    // 26: crontab.v1.WatchJobsRequest
+	(*JobEvent)(nil),            // 27: crontab.v1.JobEvent
+	nil,                         // 28: crontab.v1.RunJobRequest.ParamsEntry
+	nil,                         // 29: crontab.v1.WorkerInfo.LabelsEntry
+}
+var file_cronpb_cron_proto_depIdxs = []int32{
+	1,  // 0: crontab.v1.Job.retention:type_name -> crontab.v1.LogRetention
+	2,  // 1: crontab.v1.Job.notify:type_name -> crontab.v1.JobNotify
+	4,  // 2: crontab.v1.Job.params:type_name -> crontab.v1.JobParam
+	3,  // 3: crontab.v1.JobListItem.job:type_name -> crontab.v1.Job
+	5,  // 4: crontab.v1.JobListItem.watchdog:type_name -> crontab.v1.JobWatchdogState
+	3,  // 5: crontab.v1.SaveJobRequest.job:type_name -> crontab.v1.Job
+	3,  // 6: crontab.v1.SaveJobResponse.old_job:type_name -> crontab.v1.Job
+	3,  // 7: crontab.v1.DeleteJobResponse.old_job:type_name -> crontab.v1.Job
+	6,  // 8: crontab.v1.GetJobResponse.item:type_name -> crontab.v1.JobListItem
+	6,  // 9: crontab.v1.ListJobsResponse.items:type_name -> crontab.v1.JobListItem
+	28, // 10: crontab.v1.RunJobRequest.params:type_name -> crontab.v1.RunJobRequest.ParamsEntry
+	19, // 11: crontab.v1.ListLogsResponse.logs:type_name -> crontab.v1.JobLog
+	29, // 12: crontab.v1.WorkerInfo.labels:type_name -> crontab.v1.WorkerInfo.LabelsEntry
+	22, // 13: crontab.v1.WorkerInfo.log_sink:type_name -> crontab.v1.LogSinkStats
+	23, // 14: crontab.v1.ListWorkersResponse.workers:type_name -> crontab.v1.WorkerInfo
+	0,  // 15: crontab.v1.JobEvent.type:type_name -> crontab.v1.JobEvent.Type
+	3,  // 16: crontab.v1.JobEvent.job:type_name -> crontab.v1.Job
+	7,  // 17: crontab.v1.CronService.SaveJob:input_type -> crontab.v1.SaveJobRequest
+	9,  // 18: crontab.v1.CronService.DeleteJob:input_type -> crontab.v1.DeleteJobRequest
+	11, // 19: crontab.v1.CronService.GetJob:input_type -> crontab.v1.GetJobRequest
+	13, // 20: crontab.v1.CronService.ListJobs:input_type -> crontab.v1.ListJobsRequest
+	15, // 21: crontab.v1.CronService.KillJob:input_type -> crontab.v1.KillJobRequest
+	17, // 22: crontab.v1.CronService.RunJob:input_type -> crontab.v1.RunJobRequest
+	20, // 23: crontab.v1.CronService.ListLogs:input_type -> crontab.v1.ListLogsRequest
+	24, // 24: crontab.v1.CronService.ListWorkers:input_type -> crontab.v1.ListWorkersRequest
+	26, // 25: crontab.v1.CronService.WatchJobs:input_type -> crontab.v1.WatchJobsRequest
+	8,  // 26: crontab.v1.CronService.SaveJob:output_type -> crontab.v1.SaveJobResponse
+	10, // 27: crontab.v1.CronService.DeleteJob:output_type -> crontab.v1.DeleteJobResponse
+	12, // 28: crontab.v1.CronService.GetJob:output_type -> crontab.v1.GetJobResponse
+	14, // 29: crontab.v1.CronService.ListJobs:output_type -> crontab.v1.ListJobsResponse
+	16, // 30: crontab.v1.CronService.KillJob:output_type -> crontab.v1.KillJobResponse
+	18, // 31: crontab.v1.CronService.RunJob:output_type -> crontab.v1.RunJobResponse
+	21, // 32: crontab.v1.CronService.ListLogs:output_type -> crontab.v1.ListLogsResponse
+	25, // 33: crontab.v1.CronService.ListWorkers:output_type -> crontab.v1.ListWorkersResponse
+	27, // 34: crontab.v1.CronService.WatchJobs:output_type -> crontab.v1.JobEvent
+	26, // [26:35] is the sub-list for method output_type
+	17, // [17:26] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
+}
+
+func init() { file_cronpb_cron_proto_init() }
+func file_cronpb_cron_proto_init() {
+	if File_cronpb_cron_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_cronpb_cron_proto_rawDesc), len(file_cronpb_cron_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   29,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_cronpb_cron_proto_goTypes,
+		DependencyIndexes: file_cronpb_cron_proto_depIdxs,
+		EnumInfos:         file_cronpb_cron_proto_enumTypes,
+		MessageInfos:      file_cronpb_cron_proto_msgTypes,
+	}.Build()
+	File_cronpb_cron_proto = out.File
+	file_cronpb_cron_proto_goTypes = nil
+	file_cronpb_cron_proto_depIdxs = nil
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"../common"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"os"
+	"sync"
+)
+
+const (
+	// 日志格式
+	FORMAT_JSON   = "json"
+	FORMAT_LOGFMT = "logfmt"
+)
+
+// 日志配置
+type Config struct {
+	Level      string // debug / info / warn / error，默认info
+	Format     string // json / logfmt，默认logfmt
+	File       string // 日志文件路径，为空则输出到标准输出
+	MaxSize    int    // 单个日志文件的最大大小，MB
+	MaxBackups int    // 最多保留的旧日志文件个数
+	MaxAge     int    // 旧日志文件最多保留的天数
+}
+
+// 给所有日志附加全局字段，例如worker节点ID
+type globalFieldHook struct {
+	lock   sync.RWMutex
+	fields logrus.Fields
+}
+
+func (hook *globalFieldHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (hook *globalFieldHook) Fire(entry *logrus.Entry) (err error) {
+	var (
+		key   string
+		value interface{}
+		ok    bool
+	)
+	hook.lock.RLock()
+	defer hook.lock.RUnlock()
+	for key, value = range hook.fields {
+		// 不覆盖调用方指定的字段
+		if _, ok = entry.Data[key]; !ok {
+			entry.Data[key] = value
+		}
+	}
+	return
+}
+
+var (
+	// 单例，未初始化前按默认配置输出到标准输出
+	G_logger *logrus.Logger
+
+	globalHook = &globalFieldHook{fields: logrus.Fields{}}
+)
+
+func init() {
+	G_logger = logrus.New()
+	G_logger.SetOutput(os.Stdout)
+	G_logger.SetFormatter(&logrus.TextFormatter{DisableColors: true, FullTimestamp: true})
+	G_logger.AddHook(globalHook)
+}
+
+// 按模块打日志，自带component字段
+func Component(name string) *logrus.Entry {
+	return G_logger.WithField("component", name)
+}
+
+// 设置全局字段，之后的每条日志都会带上
+func SetGlobalField(key string, value interface{}) {
+	globalHook.lock.Lock()
+	globalHook.fields[key] = value
+	globalHook.lock.Unlock()
+}
+
+// 按配置初始化日志
+func InitLogger(config *Config) (err error) {
+	var (
+		level logrus.Level
+	)
+	level = logrus.InfoLevel
+	if config.Level != "" {
+		if level, err = logrus.ParseLevel(config.Level); err != nil {
+			return
+		}
+	}
+	G_logger.SetLevel(level)
+
+	switch config.Format {
+	case FORMAT_JSON:
+		G_logger.SetFormatter(&logrus.JSONFormatter{})
+	case FORMAT_LOGFMT, "":
+		G_logger.SetFormatter(&logrus.TextFormatter{DisableColors: true, FullTimestamp: true})
+	default:
+		return common.ERR_UNKNOWN_LOG_FORMAT
+	}
+
+	// 写入文件，按大小切割
+	if config.File != "" {
+		G_logger.SetOutput(&lumberjack.Logger{
+			Filename:   config.File,
+			MaxSize:    config.MaxSize,
+			MaxBackups: config.MaxBackups,
+			MaxAge:     config.MaxAge,
+			LocalTime:  true,
+		})
+	}
+	return
+}
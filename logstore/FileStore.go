@@ -0,0 +1,374 @@
+package logstore
+
+import (
+	"../common"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// json-lines文件日志存储，一行一条日志
+// 查询需要扫描整个文件，只适合日志量不大的场景
+// 写入和删除都对旁边的.lock文件加排他锁，master和worker两个进程可以共用一个文件，加锁方式见lockFile
+// 日志文件本身不加锁，删除日志时可以直接替换，读取也不需要等待写入方
+type FileStore struct {
+	path     string
+	lockPath string
+}
+
+// 打开锁文件并加排他锁，关闭返回的文件即释放锁
+// 锁文件不会被替换，所有进程锁的都是同一个文件
+func (store *FileStore) lock() (locked *os.File, err error) {
+	if locked, err = os.OpenFile(store.lockPath, os.O_CREATE|os.O_RDWR, 0644); err != nil {
+		return
+	}
+	if err = lockFile(locked); err != nil {
+		locked.Close()
+		locked = nil
+	}
+	return
+}
+
+// 追加写入，一个批次一次write
+// worker补发重试同一批日志时，执行ID和节点都相同的日志已经写入过，跳过
+func (store *FileStore) Append(logs []*common.JobLog) (err error) {
+	var (
+		locked  *os.File
+		file    *os.File
+		buffer  bytes.Buffer
+		jobLog  *common.JobLog
+		line    []byte
+		written map[string]bool
+	)
+	if len(logs) == 0 {
+		return
+	}
+	if locked, err = store.lock(); err != nil {
+		return
+	}
+	defer locked.Close() // 写完关闭日志文件之后再释放锁
+	if file, err = os.OpenFile(store.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644); err != nil {
+		return
+	}
+	defer file.Close()
+	// 持有锁时读出已经写入的执行ID，O_APPEND不影响从头读取
+	written = make(map[string]bool)
+	if err = store.scan(file, func(jobLog *common.JobLog, line []byte) {
+		if jobLog.ExecId != "" {
+			written[fileLogKey(jobLog)] = true
+		}
+	}); err != nil {
+		return
+	}
+	for _, jobLog = range logs {
+		if jobLog.ExecId != "" {
+			if written[fileLogKey(jobLog)] {
+				continue
+			}
+			written[fileLogKey(jobLog)] = true
+		}
+		if line, err = json.Marshal(jobLog); err != nil {
+			return
+		}
+		buffer.Write(line)
+		buffer.WriteByte('\n')
+	}
+	if buffer.Len() == 0 {
+		return
+	}
+	_, err = file.Write(buffer.Bytes())
+	return
+}
+
+// 去重用的日志标识，和mongodb的_id一致
+func fileLogKey(jobLog *common.JobLog) string {
+	return jobLog.ExecId + "@" + jobLog.Worker
+}
+
+// 遍历文件中的每条日志
+func (store *FileStore) scan(file *os.File, visit func(jobLog *common.JobLog, line []byte)) (err error) {
+	var (
+		scanner *bufio.Scanner
+		jobLog  *common.JobLog
+	)
+	scanner = bufio.NewScanner(file)
+	// 任务输出可能很长，放大单行的缓冲区
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		jobLog = &common.JobLog{}
+		if err = json.Unmarshal(scanner.Bytes(), jobLog); err != nil {
+			continue // 跳过格式错误的行
+		}
+		visit(jobLog, scanner.Bytes())
+	}
+	return scanner.Err()
+}
+
+// 查询日志，读取全部匹配的日志后排序分页
+func (store *FileStore) Query(query *common.JobLogQuery) (logArr []*common.JobLog, err error) {
+	var (
+		file  *os.File
+		field string
+		order int
+		end   int64
+	)
+	logArr = make([]*common.JobLog, 0)
+	if file, err = os.Open(store.path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil // 还没有日志
+		}
+		return
+	}
+	defer file.Close()
+	if err = store.scan(file, func(jobLog *common.JobLog, line []byte) {
+		if matchFilter(&query.Filter, jobLog) {
+			logArr = append(logArr, jobLog)
+		}
+	}); err != nil {
+		return
+	}
+
+	field = sortField(query)
+	order = sortOrder(query)
+	sort.SliceStable(logArr, func(i, j int) bool {
+		var (
+			a  int64
+			b  int64
+			sa string
+			sb string
+		)
+		switch field {
+		case common.LOG_SORT_PLAN_TIME:
+			a, b = logArr[i].PlanTime, logArr[j].PlanTime
+		case common.LOG_SORT_END_TIME:
+			a, b = logArr[i].EndTime, logArr[j].EndTime
+		default:
+			a, b = logArr[i].StartTime, logArr[j].StartTime
+		}
+		// 排序字段相同时按节点、执行ID排序，分页读取时顺序固定
+		if a == b {
+			if sa, sb = logArr[i].Worker, logArr[j].Worker; sa == sb {
+				sa, sb = logArr[i].ExecId, logArr[j].ExecId
+			}
+			if order > 0 {
+				return sa < sb
+			}
+			return sa > sb
+		}
+		if order > 0 {
+			return a < b
+		}
+		return a > b
+	})
+
+	// 分页
+	if query.Skip >= int64(len(logArr)) {
+		logArr = logArr[:0]
+		return
+	}
+	end = int64(len(logArr))
+	if query.Limit > 0 && query.Skip+query.Limit < end {
+		end = query.Skip + query.Limit
+	}
+	logArr = logArr[query.Skip:end]
+	return
+}
+
+// 统计日志条数
+func (store *FileStore) Count(filter *common.JobLogFilter) (count int64, err error) {
+	var (
+		file *os.File
+	)
+	if file, err = os.Open(store.path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	defer file.Close()
+	err = store.scan(file, func(jobLog *common.JobLog, line []byte) {
+		if matchFilter(filter, jobLog) {
+			count++
+		}
+	})
+	return
+}
+
+// 日志中出现过的任务名称
+func (store *FileStore) JobNames() (nameArr []string, err error) {
+	var (
+		file    *os.File
+		nameSet map[string]bool
+	)
+	nameArr = make([]string, 0)
+	if file, err = os.Open(store.path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	defer file.Close()
+	nameSet = make(map[string]bool)
+	err = store.scan(file, func(jobLog *common.JobLog, line []byte) {
+		if !nameSet[jobLog.JobName] {
+			nameSet[jobLog.JobName] = true
+			nameArr = append(nameArr, jobLog.JobName)
+		}
+	})
+	return
+}
+
+// 按任务分组统计，扫描整个文件在内存中汇总
+func (store *FileStore) Stats(filter *common.JobLogFilter) (statsArr []*common.JobStats, err error) {
+	var (
+		file     *os.File
+		builders map[string]*statsBuilder
+		builder  *statsBuilder
+	)
+	statsArr = make([]*common.JobStats, 0)
+	if file, err = os.Open(store.path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	defer file.Close()
+	builders = make(map[string]*statsBuilder)
+	if err = store.scan(file, func(jobLog *common.JobLog, line []byte) {
+		if !matchFilter(filter, jobLog) {
+			return
+		}
+		if builder = builders[jobLog.JobName]; builder == nil {
+			builder = newStatsBuilder(jobLog.JobName)
+			builders[jobLog.JobName] = builder
+		}
+		builder.add(jobLog)
+	}); err != nil {
+		return
+	}
+	for _, builder = range builders {
+		statsArr = append(statsArr, builder.build())
+	}
+	sortStats(statsArr)
+	return
+}
+
+// 删除过期日志
+func (store *FileStore) DeleteBefore(jobName string, before int64) (deleted int64, err error) {
+	if err = store.rewrite(func(jobLog *common.JobLog, line []byte) []byte {
+		if jobLog.StartTime < before && (jobName == "" || jobLog.JobName == jobName) {
+			deleted++
+			return nil
+		}
+		return line
+	}); err != nil {
+		deleted = 0
+	}
+	return
+}
+
+// 修改日志中的任务名称
+func (store *FileStore) RenameJob(oldName string, newName string) (renamed int64, err error) {
+	if err = store.rewrite(func(jobLog *common.JobLog, line []byte) []byte {
+		var (
+			newLine []byte
+			jsonErr error
+		)
+		if jobLog.JobName != oldName {
+			return line
+		}
+		jobLog.JobName = newName
+		if newLine, jsonErr = json.Marshal(jobLog); jsonErr != nil {
+			return line
+		}
+		renamed++
+		return newLine
+	}); err != nil {
+		renamed = 0
+	}
+	return
+}
+
+// 重写日志文件：transform返回每一行的新内容，返回nil表示删除该行
+// 持有锁时把结果写到临时文件，关闭原文件后再替换
+func (store *FileStore) rewrite(transform func(jobLog *common.JobLog, line []byte) []byte) (err error) {
+	var (
+		locked  *os.File
+		file    *os.File
+		tmpFile *os.File
+		writer  *bufio.Writer
+		tmpPath string
+	)
+	if locked, err = store.lock(); err != nil {
+		return
+	}
+	defer locked.Close()
+	if file, err = os.Open(store.path); err != nil {
+		if os.IsNotExist(err) { // 还没有写入过日志
+			err = nil
+		}
+		return
+	}
+
+	tmpPath = store.path + ".tmp"
+	if tmpFile, err = os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644); err != nil {
+		return
+	}
+	writer = bufio.NewWriter(tmpFile)
+	err = store.scan(file, func(jobLog *common.JobLog, line []byte) {
+		if line = transform(jobLog, line); line == nil {
+			return
+		}
+		writer.Write(line)
+		writer.WriteByte('\n')
+	})
+	// windows上打开着的文件不能被替换，替换之前先关闭
+	file.Close()
+	if err != nil {
+		goto FAIL
+	}
+	if err = writer.Flush(); err != nil {
+		goto FAIL
+	}
+	if err = tmpFile.Sync(); err != nil {
+		goto FAIL
+	}
+	tmpFile.Close()
+	// 仍然持有锁，等待的写入方拿到锁后打开的是替换后的文件
+	if err = replaceFile(tmpPath, store.path); err != nil {
+		os.Remove(tmpPath)
+	}
+	return
+
+FAIL:
+	tmpFile.Close()
+	os.Remove(tmpPath)
+	return
+}
+
+// 检查日志文件所在目录是否还在
+func (store *FileStore) Ping(ctx context.Context) (err error) {
+	_, err = os.Stat(filepath.Dir(store.path))
+	return
+}
+
+// 文件存储不需要关闭
+func (store *FileStore) Close() (err error) {
+	return
+}
+
+// 创建文件存储
+func NewFileStore(config *Config) (store *FileStore, err error) {
+	if err = os.MkdirAll(filepath.Dir(config.Path), 0755); err != nil {
+		return
+	}
+	store = &FileStore{
+		path:     config.Path,
+		lockPath: config.Path + ".lock",
+	}
+	return
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package logstore
+
+import (
+	"os"
+	"syscall"
+)
+
+// 对打开的锁文件加排他锁（flock），关闭文件时释放
+func lockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+}
+
+// 用临时文件替换日志文件，正在读取旧文件的进程不受影响
+func replaceFile(tmpPath string, path string) error {
+	return os.Rename(tmpPath, path)
+}
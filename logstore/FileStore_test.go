@@ -0,0 +1,139 @@
+package logstore
+
+import (
+	"../common"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func newTestFileStore(t *testing.T) (store *FileStore, cleanup func()) {
+	var (
+		dir string
+		err error
+	)
+	if dir, err = ioutil.TempDir("", "filestore"); err != nil {
+		t.Fatal(err)
+	}
+	if store, err = NewFileStore(&Config{Path: filepath.Join(dir, "job.log")}); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return store, func() { os.RemoveAll(dir) }
+}
+
+func TestFileStore(t *testing.T) {
+	var (
+		store   *FileStore
+		cleanup func()
+		logArr  []*common.JobLog
+		count   int64
+		err     error
+	)
+	store, cleanup = newTestFileStore(t)
+	defer cleanup()
+	if logArr, err = store.Query(&common.JobLogQuery{}); err != nil || len(logArr) != 0 {
+		t.Fatalf("文件不存在时 Query = %v, %v", logArr, err)
+	}
+	if err = store.Append([]*common.JobLog{
+		{JobName: "a/job1", ExecId: "1", StartTime: 100, EndTime: 400},
+		{JobName: "a/job1", ExecId: "2", StartTime: 200, EndTime: 250, Err: "exit status 1"},
+		{JobName: "a/job2", ExecId: "3", StartTime: 300, EndTime: 350},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 默认按开始时间倒序，分页
+	if logArr, err = store.Query(&common.JobLogQuery{Skip: 1, Limit: 1}); err != nil || len(logArr) != 1 || logArr[0].ExecId != "2" {
+		t.Errorf("分页查询 = %v, %v", logArr, err)
+	}
+	if logArr, err = store.Query(&common.JobLogQuery{SortField: common.LOG_SORT_END_TIME, SortOrder: 1}); err != nil || len(logArr) != 3 || logArr[0].ExecId != "2" || logArr[2].ExecId != "1" {
+		t.Errorf("按结束时间正序 = %v, %v", logArr, err)
+	}
+	if count, err = store.Count(&common.JobLogFilter{JobName: "a/job1", Status: common.LOG_STATUS_SUCCESS}); err != nil || count != 1 {
+		t.Errorf("Count = %d, %v", count, err)
+	}
+
+	if count, err = store.RenameJob("a/job1", "b/job1"); err != nil || count != 2 {
+		t.Errorf("RenameJob = %d, %v", count, err)
+	}
+	if count, err = store.DeleteBefore("b/job1", 200); err != nil || count != 1 {
+		t.Errorf("DeleteBefore = %d, %v", count, err)
+	}
+	if count, err = store.DeleteBefore("", 1000); err != nil || count != 2 {
+		t.Errorf("删除所有任务的日志 = %d, %v", count, err)
+	}
+	if count, err = store.Count(&common.JobLogFilter{}); err != nil || count != 0 {
+		t.Errorf("删除后 Count = %d, %v", count, err)
+	}
+}
+
+// 并发写入和重写时不丢日志
+func TestFileStoreConcurrentAppend(t *testing.T) {
+	var (
+		store   *FileStore
+		cleanup func()
+		wg      sync.WaitGroup
+		count   int64
+		i       int
+		err     error
+	)
+	store, cleanup = newTestFileStore(t)
+	defer cleanup()
+	for i = 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var (
+				jobErr error
+			)
+			if jobErr = store.Append([]*common.JobLog{{JobName: "a/job1", ExecId: fmt.Sprint(i), StartTime: int64(1000 + i)}}); jobErr != nil {
+				t.Error(jobErr)
+			}
+			// 重写文件时的写入方要等锁，拿到锁后打开的是替换后的文件
+			if _, jobErr = store.DeleteBefore("a/job1", 0); jobErr != nil {
+				t.Error(jobErr)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if count, err = store.Count(&common.JobLogFilter{JobName: "a/job1"}); err != nil || count != 20 {
+		t.Errorf("Count = %d, %v", count, err)
+	}
+}
+
+// worker补发重试时同一条日志可能写入多次，按执行ID和节点去重
+func TestFileStoreAppendDedupe(t *testing.T) {
+	var (
+		store   *FileStore
+		cleanup func()
+		batch   []*common.JobLog
+		count   int64
+		err     error
+	)
+	store, cleanup = newTestFileStore(t)
+	defer cleanup()
+	batch = []*common.JobLog{
+		{JobName: "a/job1", ExecId: "1", Worker: "w1", StartTime: 100},
+		{JobName: "a/job1", ExecId: "2", Worker: "w1", StartTime: 200},
+	}
+	if err = store.Append(batch); err != nil {
+		t.Fatal(err)
+	}
+	// 补发同一批，另外同一个执行ID在其他节点上执行的广播任务、批次内重复和老版本没有执行ID的日志
+	if err = store.Append(append(batch,
+		&common.JobLog{JobName: "a/job1", ExecId: "1", Worker: "w2", StartTime: 100},
+		&common.JobLog{JobName: "a/job1", ExecId: "3", Worker: "w1", StartTime: 300},
+		&common.JobLog{JobName: "a/job1", ExecId: "3", Worker: "w1", StartTime: 300},
+		&common.JobLog{JobName: "a/job1", StartTime: 400},
+		&common.JobLog{JobName: "a/job1", StartTime: 400},
+	)); err != nil {
+		t.Fatal(err)
+	}
+	if count, err = store.Count(&common.JobLogFilter{}); err != nil || count != 6 {
+		t.Errorf("Count = %d, %v，期望6", count, err)
+	}
+}
@@ -0,0 +1,51 @@
+//go:build windows
+// +build windows
+
+package logstore
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	lockfileExclusiveLock = 0x2 // LOCKFILE_EXCLUSIVE_LOCK
+
+	// 替换日志文件的重试次数和间隔
+	replaceRetryTimes    = 20
+	replaceRetryInterval = 50 * time.Millisecond
+)
+
+var (
+	procLockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+)
+
+// 对打开的锁文件加排他锁（LockFileEx，锁住整个文件范围），关闭文件时释放
+// 只锁.lock文件，日志文件不加锁，其他进程查询日志不会被强制锁挡住
+func lockFile(file *os.File) (err error) {
+	var (
+		overlapped syscall.Overlapped
+		ret        uintptr
+	)
+	if ret, _, err = procLockFileEx.Call(file.Fd(), lockfileExclusiveLock, 0, 0xFFFFFFFF, 0xFFFFFFFF, uintptr(unsafe.Pointer(&overlapped))); ret != 0 {
+		err = nil
+	}
+	return
+}
+
+// 用临时文件替换日志文件
+// windows上文件被打开时不能替换，其他进程查询日志时会短暂打开文件，等它读完再重试
+func replaceFile(tmpPath string, path string) (err error) {
+	var (
+		i int
+	)
+	for i = 0; i < replaceRetryTimes; i++ {
+		if err = os.Rename(tmpPath, path); err == nil {
+			return
+		}
+		time.Sleep(replaceRetryInterval)
+	}
+	return
+}
@@ -0,0 +1,57 @@
+//go:build windows
+// +build windows
+
+package logstore
+
+import (
+	"../common"
+	"os"
+	"testing"
+	"time"
+)
+
+// windows上打开着的文件不能替换，删除日志要在关闭日志文件之后替换，并等待其他读取方关闭
+func TestFileStoreDeleteBeforeWindows(t *testing.T) {
+	var (
+		store   *FileStore
+		cleanup func()
+		locked  *os.File
+		reader  *os.File
+		logArr  []*common.JobLog
+		deleted int64
+		err     error
+	)
+	store, cleanup = newTestFileStore(t)
+	defer cleanup()
+	if err = store.Append([]*common.JobLog{
+		{JobName: "a/job1", ExecId: "1", StartTime: 100},
+		{JobName: "a/job1", ExecId: "2", StartTime: 200},
+		{JobName: "a/job2", ExecId: "3", StartTime: 300},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if deleted, err = store.DeleteBefore("a/job1", 150); err != nil || deleted != 1 {
+		t.Fatalf("DeleteBefore = %d, %v", deleted, err)
+	}
+
+	// 其他进程正在读取日志文件，读完之后替换
+	if reader, err = os.Open(store.path); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		reader.Close()
+	}()
+	if deleted, err = store.DeleteBefore("", 250); err != nil || deleted != 1 {
+		t.Fatalf("读取方打开文件时 DeleteBefore = %d, %v", deleted, err)
+	}
+
+	// 写入方持有锁时仍然可以查询
+	if locked, err = store.lock(); err != nil {
+		t.Fatal(err)
+	}
+	defer locked.Close()
+	if logArr, err = store.Query(&common.JobLogQuery{}); err != nil || len(logArr) != 1 || logArr[0].ExecId != "3" {
+		t.Errorf("持有锁时 Query = %v, %v", logArr, err)
+	}
+}
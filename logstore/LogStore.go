@@ -0,0 +1,176 @@
+package logstore
+
+import (
+	"../common"
+	"context"
+	"strings"
+)
+
+const (
+	// mongodb存储，多节点部署使用
+	STORE_MONGODB = "mongodb"
+	// sqlite本地文件存储，单机部署使用，master和worker可以共用一个文件
+	STORE_SQLITE = "sqlite"
+	// json-lines文件存储，一行一条日志
+	STORE_FILE = "file"
+)
+
+// 任务日志存储，master查询日志，worker写入日志
+type LogStore interface {
+	// 批量写入日志
+	Append(logs []*common.JobLog) (err error)
+	// 按条件查询日志，支持排序和分页
+	Query(query *common.JobLogQuery) (logArr []*common.JobLog, err error)
+	// 统计符合条件的日志条数
+	Count(filter *common.JobLogFilter) (count int64, err error)
+	// 日志中出现过的所有任务名称，用于清理已删除任务遗留的日志
+	JobNames() (nameArr []string, err error)
+	// 按任务分组统计符合条件的日志：执行次数、成功率、耗时分位数、调度延迟，在存储端聚合，不读取日志内容
+	Stats(filter *common.JobLogFilter) (statsArr []*common.JobStats, err error)
+	// 删除startTime早于before(毫秒)的日志，jobName为空表示所有任务
+	DeleteBefore(jobName string, before int64) (deleted int64, err error)
+	// 修改日志中的任务名称，用于任务迁移到命名空间
+	RenameJob(oldName string, newName string) (renamed int64, err error)
+	// 检查存储是否可用，用于健康检查
+	Ping(ctx context.Context) (err error)
+	// 关闭存储
+	Close() (err error)
+}
+
+// 日志存储配置，从master.json / worker.json 中读取
+type Config struct {
+	Type                  string // mongodb / sqlite / file，默认mongodb
+	MongodbUri            string
+	MongodbConnectTimeout int // 毫秒
+	MongodbDatabase       string
+	MongodbCollection     string
+	Path                  string // sqlite和file存储的文件路径
+}
+
+// 在存储请求失败时回调，用于统计错误次数
+type errorHookStore struct {
+	LogStore
+	onError func(err error)
+}
+
+func (store *errorHookStore) hook(err error) error {
+	if err != nil {
+		store.onError(err)
+	}
+	return err
+}
+
+func (store *errorHookStore) Append(logs []*common.JobLog) (err error) {
+	return store.hook(store.LogStore.Append(logs))
+}
+
+func (store *errorHookStore) Query(query *common.JobLogQuery) (logArr []*common.JobLog, err error) {
+	logArr, err = store.LogStore.Query(query)
+	store.hook(err)
+	return
+}
+
+func (store *errorHookStore) Count(filter *common.JobLogFilter) (count int64, err error) {
+	count, err = store.LogStore.Count(filter)
+	store.hook(err)
+	return
+}
+
+func (store *errorHookStore) JobNames() (nameArr []string, err error) {
+	nameArr, err = store.LogStore.JobNames()
+	store.hook(err)
+	return
+}
+
+func (store *errorHookStore) Stats(filter *common.JobLogFilter) (statsArr []*common.JobStats, err error) {
+	statsArr, err = store.LogStore.Stats(filter)
+	store.hook(err)
+	return
+}
+
+func (store *errorHookStore) DeleteBefore(jobName string, before int64) (deleted int64, err error) {
+	deleted, err = store.LogStore.DeleteBefore(jobName, before)
+	store.hook(err)
+	return
+}
+
+func (store *errorHookStore) RenameJob(oldName string, newName string) (renamed int64, err error) {
+	renamed, err = store.LogStore.RenameJob(oldName, newName)
+	store.hook(err)
+	return
+}
+
+// 包装日志存储，每次请求失败都会调用onError
+func WithErrorHook(store LogStore, onError func(err error)) LogStore {
+	return &errorHookStore{
+		LogStore: store,
+		onError:  onError,
+	}
+}
+
+// 根据配置创建日志存储
+func NewLogStore(config *Config) (store LogStore, err error) {
+	switch config.Type {
+	case "", STORE_MONGODB:
+		return NewMongoStore(config)
+	case STORE_SQLITE:
+		return NewSqliteStore(config)
+	case STORE_FILE:
+		return NewFileStore(config)
+	}
+	err = common.ERR_UNKNOWN_LOG_STORE
+	return
+}
+
+// 排序字段只允许白名单中的值，默认按startTime
+func sortField(query *common.JobLogQuery) string {
+	switch query.SortField {
+	case common.LOG_SORT_PLAN_TIME, common.LOG_SORT_END_TIME:
+		return query.SortField
+	}
+	return common.LOG_SORT_START_TIME
+}
+
+// 排序方向，默认倒序
+func sortOrder(query *common.JobLogQuery) int {
+	if query.SortOrder > 0 {
+		return 1
+	}
+	return -1
+}
+
+// 判断一条日志是否符合过滤条件，供不支持查询语言的存储使用
+func matchFilter(filter *common.JobLogFilter, jobLog *common.JobLog) bool {
+	if filter.JobName != "" && jobLog.JobName != filter.JobName {
+		return false
+	}
+	if filter.JobName == "" && filter.Namespace != "" && !strings.HasPrefix(jobLog.JobName, filter.Namespace+"/") {
+		return false
+	}
+	if filter.StartFrom > 0 && jobLog.StartTime < filter.StartFrom {
+		return false
+	}
+	if filter.StartTo > 0 && jobLog.StartTime >= filter.StartTo {
+		return false
+	}
+	if filter.EndFrom > 0 && jobLog.EndTime < filter.EndFrom {
+		return false
+	}
+	switch filter.Status {
+	case common.LOG_STATUS_SUCCESS:
+		if jobLog.Err != "" {
+			return false
+		}
+	case common.LOG_STATUS_FAILED:
+		if jobLog.Err == "" {
+			return false
+		}
+	}
+	if filter.Worker != "" && jobLog.Worker != filter.Worker {
+		return false
+	}
+	if filter.Keyword != "" && !strings.Contains(jobLog.Output, filter.Keyword) && !strings.Contains(jobLog.Err, filter.Keyword) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package logstore
+
+import (
+	"../common"
+	"testing"
+)
+
+func TestMatchFilter(t *testing.T) {
+	var (
+		jobLog = &common.JobLog{
+			JobName:   "a/job1",
+			Worker:    "host1",
+			StartTime: 1000,
+			EndTime:   2000,
+			Output:    "connect timeout",
+			Err:       "exit status 1",
+		}
+		cases = []struct {
+			name   string
+			filter common.JobLogFilter
+			match  bool
+		}{
+			{"空条件", common.JobLogFilter{}, true},
+			{"任务名称", common.JobLogFilter{JobName: "a/job1"}, true},
+			{"其他任务", common.JobLogFilter{JobName: "a/job2"}, false},
+			{"命名空间", common.JobLogFilter{Namespace: "a"}, true},
+			{"命名空间前缀不算", common.JobLogFilter{Namespace: "a/j"}, false},
+			{"指定任务时忽略命名空间", common.JobLogFilter{JobName: "a/job1", Namespace: "b"}, true},
+			{"开始时间包含下限", common.JobLogFilter{StartFrom: 1000}, true},
+			{"开始时间早于下限", common.JobLogFilter{StartFrom: 1001}, false},
+			{"开始时间不包含上限", common.JobLogFilter{StartTo: 1000}, false},
+			{"开始时间在上限之前", common.JobLogFilter{StartTo: 1001}, true},
+			{"结束时间包含下限", common.JobLogFilter{EndFrom: 2000}, true},
+			{"结束时间早于下限", common.JobLogFilter{EndFrom: 2001}, false},
+			{"失败", common.JobLogFilter{Status: common.LOG_STATUS_FAILED}, true},
+			{"成功", common.JobLogFilter{Status: common.LOG_STATUS_SUCCESS}, false},
+			{"其他节点", common.JobLogFilter{Worker: "host2"}, false},
+			{"输出中的关键字", common.JobLogFilter{Keyword: "timeout"}, true},
+			{"错误中的关键字", common.JobLogFilter{Keyword: "status 1"}, true},
+			{"关键字区分大小写", common.JobLogFilter{Keyword: "Timeout"}, false},
+		}
+		i int
+	)
+	for i = range cases {
+		if matchFilter(&cases[i].filter, jobLog) != cases[i].match {
+			t.Errorf("%s: 期望 %v", cases[i].name, cases[i].match)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package logstore
+
+import (
+	"../common"
+	"../logger"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"regexp"
+	"time"
+)
+
+// mongodb日志存储
+type MongoStore struct {
+	client         *mongo.Client
+	logCollection  *mongo.Collection
+	requestTimeout time.Duration
+}
+
+// mongodb单个文档最大16MB，输出超过这个长度时只保留末尾
+const mongoMaxOutput = 8 * 1024 * 1024
+
+// 重复插入时的错误码
+const mongoDuplicateKey = 11000
+
+// 写入mongodb的日志文档
+// _id由执行ID和节点生成，worker补发重试同一批日志时，已经写入的日志会因为主键重复被忽略
+type mongoLogDoc struct {
+	Id            string `bson:"_id,omitempty"`
+	common.JobLog `bson:",inline"`
+}
+
+// 批量插入日志
+func (store *MongoStore) Append(logs []*common.JobLog) (err error) {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+		docs       []interface{}
+		jobLog     *common.JobLog
+		doc        *mongoLogDoc
+	)
+	if len(logs) == 0 {
+		return
+	}
+	// InsertMany只接受[]interface{}
+	docs = make([]interface{}, 0, len(logs))
+	for _, jobLog = range logs {
+		doc = &mongoLogDoc{JobLog: *jobLog}
+		if jobLog.ExecId != "" {
+			doc.Id = jobLog.ExecId + "@" + jobLog.Worker
+		}
+		if len(doc.Output) > mongoMaxOutput {
+			doc.Output = doc.Output[len(doc.Output)-mongoMaxOutput:]
+		}
+		docs = append(docs, doc)
+	}
+	ctx, cancelFunc = context.WithTimeout(context.TODO(), store.requestTimeout)
+	defer cancelFunc()
+	// 无序插入：一条日志失败不影响同批的其他日志
+	_, err = store.logCollection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	return mongoInsertError(err)
+}
+
+// 区分插入失败的原因：主键重复说明之前已经写入过，忽略；
+// 其他的单条写入错误是日志本身被拒绝，重试也不会成功；网络等错误原样返回，由调用方重试
+func mongoInsertError(err error) error {
+	var (
+		bulkErr  mongo.BulkWriteException
+		writeErr mongo.BulkWriteError
+		rejected int
+	)
+	if err == nil || !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return err
+	}
+	for _, writeErr = range bulkErr.WriteErrors {
+		if writeErr.Code != mongoDuplicateKey {
+			rejected++
+		}
+	}
+	if rejected == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %d条日志写入失败: %v", common.ERR_LOG_REJECTED, rejected, err)
+}
+
+// 查询日志
+func (store *MongoStore) Query(query *common.JobLogQuery) (logArr []*common.JobLog, err error) {
+	var (
+		findOptions *options.FindOptions
+		cursor      *mongo.Cursor
+		jobLog      *common.JobLog
+	)
+	logArr = make([]*common.JobLog, 0)
+	// 排序字段相同时按_id排序，分页读取时顺序固定
+	findOptions = options.Find().
+		SetSort(bson.D{{Key: sortField(query), Value: sortOrder(query)}, {Key: "_id", Value: sortOrder(query)}}).
+		SetSkip(query.Skip)
+	if query.Limit > 0 {
+		findOptions.SetLimit(query.Limit)
+	}
+
+	// 发起查询
+	if cursor, err = store.logCollection.Find(context.TODO(), mongoFilter(&query.Filter), findOptions); err != nil {
+		return
+	}
+	defer cursor.Close(context.TODO()) // 延迟释放游标
+
+	for cursor.Next(context.TODO()) {
+		jobLog = &common.JobLog{}
+		// 反序列化
+		if err = cursor.Decode(jobLog); err != nil {
+			err = nil
+			continue // 有日志不合格
+		}
+		logArr = append(logArr, jobLog)
+	}
+	return
+}
+
+// 统计日志条数
+func (store *MongoStore) Count(filter *common.JobLogFilter) (count int64, err error) {
+	return store.logCollection.CountDocuments(context.TODO(), mongoFilter(filter))
+}
+
+// 日志中出现过的任务名称
+func (store *MongoStore) JobNames() (nameArr []string, err error) {
+	var (
+		valueArr []interface{}
+		value    interface{}
+		name     string
+		ok       bool
+	)
+	nameArr = make([]string, 0)
+	if valueArr, err = store.logCollection.Distinct(context.TODO(), "jobName", bson.M{}); err != nil {
+		return
+	}
+	for _, value = range valueArr {
+		if name, ok = value.(string); ok {
+			nameArr = append(nameArr, name)
+		}
+	}
+	return
+}
+
+// mongodb按任务分组统计的结果
+type mongoStatsRow struct {
+	JobName        string `bson:"_id"`
+	Total          int64  `bson:"total"`
+	SuccessCount   int64  `bson:"successCount"`
+	ScheduleLagSum int64  `bson:"scheduleLagSum"`
+	ScheduleLagMax int64  `bson:"scheduleLagMax"`
+	DurationMax    int64  `bson:"durationMax"`
+}
+
+// 按任务分组统计，次数和调度延迟用$group聚合
+// 耗时分位数对每个任务按耗时排序后取对应的一条，p95倒序取，需要排序的数据量小
+func (store *MongoStore) Stats(filter *common.JobLogFilter) (statsArr []*common.JobStats, err error) {
+	var (
+		matchDoc bson.M
+		lag      bson.M
+		duration bson.M
+		cursor   *mongo.Cursor
+		row      *mongoStatsRow
+		stats    *common.JobStats
+	)
+	statsArr = make([]*common.JobStats, 0)
+	matchDoc = mongoFilter(filter)
+	lag = bson.M{"$subtract": bson.A{"$scheduleTime", "$planTime"}}
+	duration = bson.M{"$subtract": bson.A{"$endTime", "$startTime"}}
+	if cursor, err = store.logCollection.Aggregate(context.TODO(), bson.A{
+		bson.M{"$match": matchDoc},
+		bson.M{"$group": bson.M{
+			"_id":            "$jobName",
+			"total":          bson.M{"$sum": 1},
+			"successCount":   bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$err", ""}}, 1, 0}}},
+			"scheduleLagSum": bson.M{"$sum": lag},
+			"scheduleLagMax": bson.M{"$max": lag},
+			"durationMax":    bson.M{"$max": duration},
+		}},
+	}, options.Aggregate().SetAllowDiskUse(true)); err != nil {
+		return
+	}
+	defer cursor.Close(context.TODO())
+	for cursor.Next(context.TODO()) {
+		row = &mongoStatsRow{}
+		if err = cursor.Decode(row); err != nil {
+			return
+		}
+		stats = &common.JobStats{
+			JobName:        row.JobName,
+			Total:          row.Total,
+			SuccessCount:   row.SuccessCount,
+			FailCount:      row.Total - row.SuccessCount,
+			SuccessRate:    float64(row.SuccessCount) / float64(row.Total),
+			DurationMax:    row.DurationMax,
+			ScheduleLagAvg: row.ScheduleLagSum / row.Total,
+			ScheduleLagMax: row.ScheduleLagMax,
+		}
+		statsArr = append(statsArr, stats)
+	}
+	if err = cursor.Err(); err != nil {
+		return
+	}
+	for _, stats = range statsArr {
+		if stats.DurationP50, err = store.durationAt(matchDoc, stats.JobName, percentileRank(50, stats.Total)-1, 1); err != nil {
+			return
+		}
+		if stats.DurationP95, err = store.durationAt(matchDoc, stats.JobName, stats.Total-percentileRank(95, stats.Total), -1); err != nil {
+			return
+		}
+	}
+	sortStats(statsArr)
+	return
+}
+
+// 任务的日志按耗时排序后第skip条的耗时，order为1正序，-1倒序
+func (store *MongoStore) durationAt(matchDoc bson.M, jobName string, skip int64, order int) (duration int64, err error) {
+	var (
+		jobMatch bson.M
+		key      string
+		value    interface{}
+		cursor   *mongo.Cursor
+		row      struct {
+			Duration int64 `bson:"duration"`
+		}
+	)
+	jobMatch = bson.M{}
+	for key, value = range matchDoc {
+		jobMatch[key] = value
+	}
+	jobMatch["jobName"] = jobName
+	if cursor, err = store.logCollection.Aggregate(context.TODO(), bson.A{
+		bson.M{"$match": jobMatch},
+		bson.M{"$project": bson.M{"_id": 0, "duration": bson.M{"$subtract": bson.A{"$endTime", "$startTime"}}}},
+		bson.M{"$sort": bson.M{"duration": order}},
+		bson.M{"$skip": skip},
+		bson.M{"$limit": 1},
+	}, options.Aggregate().SetAllowDiskUse(true)); err != nil {
+		return
+	}
+	defer cursor.Close(context.TODO())
+	if cursor.Next(context.TODO()) {
+		if err = cursor.Decode(&row); err != nil {
+			return
+		}
+		duration = row.Duration
+	}
+	// 没有读到结果可能是游标出错，不能当作耗时为0
+	err = cursor.Err()
+	return
+}
+
+// 删除过期日志
+func (store *MongoStore) DeleteBefore(jobName string, before int64) (deleted int64, err error) {
+	var (
+		filter  bson.M
+		delResp *mongo.DeleteResult
+	)
+	filter = bson.M{"startTime": bson.M{"$lt": before}}
+	if jobName != "" {
+		filter["jobName"] = jobName
+	}
+	if delResp, err = store.logCollection.DeleteMany(context.TODO(), filter); err != nil {
+		return
+	}
+	deleted = delResp.DeletedCount
+	return
+}
+
+// 修改日志中的任务名称
+func (store *MongoStore) RenameJob(oldName string, newName string) (renamed int64, err error) {
+	var (
+		updateResp *mongo.UpdateResult
+	)
+	if updateResp, err = store.logCollection.UpdateMany(context.TODO(), bson.M{"jobName": oldName}, bson.M{"$set": bson.M{"jobName": newName}}); err != nil {
+		return
+	}
+	renamed = updateResp.ModifiedCount
+	return
+}
+
+// 过滤条件翻译成mongodb的查询文档
+func mongoFilter(filter *common.JobLogFilter) (doc bson.M) {
+	var (
+		startTime bson.M
+		keyword   bson.M
+	)
+	doc = bson.M{}
+	if filter.JobName != "" {
+		doc["jobName"] = filter.JobName
+	} else if filter.Namespace != "" {
+		// 任务全名以 命名空间/ 开头，前缀匹配可以用上jobName的索引
+		doc["jobName"] = bson.M{"$regex": "^" + regexp.QuoteMeta(filter.Namespace+"/")}
+	}
+	startTime = bson.M{}
+	if filter.StartFrom > 0 {
+		startTime["$gte"] = filter.StartFrom
+	}
+	if filter.StartTo > 0 {
+		startTime["$lt"] = filter.StartTo
+	}
+	if len(startTime) != 0 {
+		doc["startTime"] = startTime
+	}
+	if filter.EndFrom > 0 {
+		doc["endTime"] = bson.M{"$gte": filter.EndFrom}
+	}
+	switch filter.Status {
+	case common.LOG_STATUS_SUCCESS:
+		doc["err"] = ""
+	case common.LOG_STATUS_FAILED:
+		doc["err"] = bson.M{"$ne": ""}
+	}
+	if filter.Worker != "" {
+		doc["worker"] = filter.Worker
+	}
+	if filter.Keyword != "" {
+		// 子串匹配，转义正则特殊字符
+		keyword = bson.M{"$regex": regexp.QuoteMeta(filter.Keyword)}
+		doc["$or"] = bson.A{bson.M{"output": keyword}, bson.M{"err": keyword}}
+	}
+	return
+}
+
+// 创建查询用到的索引，已存在时不会重复创建
+func (store *MongoStore) ensureIndexes() (err error) {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+	)
+	ctx, cancelFunc = context.WithTimeout(context.TODO(), store.requestTimeout)
+	defer cancelFunc()
+	_, err = store.logCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "jobName", Value: 1}, {Key: "startTime", Value: -1}}},
+		{Keys: bson.D{{Key: "jobName", Value: 1}, {Key: "planTime", Value: -1}}},
+		{Keys: bson.D{{Key: "startTime", Value: -1}}},
+		{Keys: bson.D{{Key: "endTime", Value: -1}}},
+		{Keys: bson.D{{Key: "worker", Value: 1}, {Key: "startTime", Value: -1}}},
+	})
+	return
+}
+
+// 检查mongodb是否可达
+func (store *MongoStore) Ping(ctx context.Context) (err error) {
+	return store.client.Ping(ctx, readpref.Primary())
+}
+
+// 断开连接
+func (store *MongoStore) Close() (err error) {
+	return store.client.Disconnect(context.TODO())
+}
+
+// 连接mongodb
+func NewMongoStore(config *Config) (store *MongoStore, err error) {
+	var (
+		client         *mongo.Client
+		ctx            context.Context
+		cancelFunc     context.CancelFunc
+		requestTimeout time.Duration
+		database       string
+		collection     string
+	)
+	requestTimeout = time.Duration(config.MongodbConnectTimeout) * time.Millisecond
+	// 1.建立连接
+	ctx, cancelFunc = context.WithTimeout(context.Background(), requestTimeout)
+	defer cancelFunc()
+	if client, err = mongo.Connect(ctx, &options.ClientOptions{Hosts: []string{config.MongodbUri}}); err != nil {
+		return
+	}
+
+	// 兼容之前写死的库名和集合名
+	if database = config.MongodbDatabase; database == "" {
+		database = "my_db"
+	}
+	if collection = config.MongodbCollection; collection == "" {
+		collection = "my_collection"
+	}
+
+	store = &MongoStore{
+		client:         client,
+		logCollection:  client.Database(database).Collection(collection),
+		requestTimeout: requestTimeout,
+	}
+
+	// mongodb暂时不可用时不影响启动，worker的日志会先写到本地预写文件
+	if err = store.ensureIndexes(); err != nil {
+		logger.Component("logStore").WithError(err).Warn("创建日志索引失败")
+		err = nil
+	}
+	return
+}
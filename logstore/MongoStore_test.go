@@ -0,0 +1,47 @@
+package logstore
+
+import (
+	"../common"
+	"errors"
+	"go.mongodb.org/mongo-driver/mongo"
+	"testing"
+)
+
+func TestMongoInsertError(t *testing.T) {
+	var (
+		network  = errors.New("connection refused")
+		dup      = mongo.BulkWriteError{WriteError: mongo.WriteError{Code: mongoDuplicateKey}}
+		tooLarge = mongo.BulkWriteError{WriteError: mongo.WriteError{Code: 10334}}
+		cases    []struct {
+			name     string
+			err      error
+			want     error
+			rejected bool
+		}
+		i   int
+		err error
+	)
+	cases = []struct {
+		name     string
+		err      error
+		want     error
+		rejected bool
+	}{
+		{"成功", nil, nil, false},
+		{"网络错误原样返回", network, network, false},
+		{"全部主键重复视为成功", mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{dup, dup}}, nil, false},
+		{"单条被拒绝", mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{dup, tooLarge}}, nil, true},
+	}
+	for i = range cases {
+		err = mongoInsertError(cases[i].err)
+		if cases[i].rejected {
+			if !errors.Is(err, common.ERR_LOG_REJECTED) {
+				t.Errorf("%s: 期望ERR_LOG_REJECTED，得到 %v", cases[i].name, err)
+			}
+			continue
+		}
+		if err != cases[i].want {
+			t.Errorf("%s: 期望 %v，得到 %v", cases[i].name, cases[i].want, err)
+		}
+	}
+}
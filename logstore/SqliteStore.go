@@ -0,0 +1,407 @@
+package logstore
+
+import (
+	"../common"
+	"context"
+	"database/sql"
+	_ "modernc.org/sqlite" // 纯go实现的sqlite驱动，不需要cgo
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sqlite日志存储，适合单机部署
+// 开启WAL和busy_timeout，master和worker两个进程可以同时读写同一个文件
+type SqliteStore struct {
+	db *sql.DB
+}
+
+// 建表语句
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS job_log (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	job_name      TEXT NOT NULL,
+	command       TEXT NOT NULL,
+	exec_id       TEXT NOT NULL DEFAULT '',
+	worker        TEXT NOT NULL,
+	err           TEXT NOT NULL,
+	output        TEXT NOT NULL,
+	timed_out     INTEGER NOT NULL DEFAULT 0,
+	plan_time     INTEGER NOT NULL,
+	schedule_time INTEGER NOT NULL,
+	start_time    INTEGER NOT NULL,
+	end_time      INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_job_log_start_time ON job_log (job_name, start_time);
+CREATE INDEX IF NOT EXISTS idx_job_log_plan_time ON job_log (job_name, plan_time);
+CREATE INDEX IF NOT EXISTS idx_job_log_all_start_time ON job_log (start_time);
+CREATE INDEX IF NOT EXISTS idx_job_log_end_time ON job_log (end_time);
+CREATE INDEX IF NOT EXISTS idx_job_log_worker ON job_log (worker, start_time);
+`
+
+// 执行ID和节点的唯一索引，worker补发重试同一批日志时，已经写入的日志被INSERT OR IGNORE忽略
+// 老版本的日志没有执行ID，不参与去重
+const sqliteExecIndex = "CREATE UNIQUE INDEX IF NOT EXISTS idx_job_log_exec ON job_log (exec_id, worker) WHERE exec_id != ''"
+
+// 老版本建的表缺少的列，打开时补上
+var sqliteAddedColumns = []struct {
+	name string
+	def  string
+}{
+	{"exec_id", "TEXT NOT NULL DEFAULT ''"},
+	{"timed_out", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// 查询的列，顺序与rows.Scan一致
+const sqliteColumns = "job_name, command, exec_id, worker, err, output, timed_out, plan_time, schedule_time, start_time, end_time"
+
+// 批量插入日志，一个批次一个事务，重复补发的日志被唯一索引忽略
+func (store *SqliteStore) Append(logs []*common.JobLog) (err error) {
+	var (
+		tx     *sql.Tx
+		stmt   *sql.Stmt
+		jobLog *common.JobLog
+	)
+	if len(logs) == 0 {
+		return
+	}
+	if tx, err = store.db.Begin(); err != nil {
+		return
+	}
+	if stmt, err = tx.Prepare("INSERT OR IGNORE INTO job_log (" + sqliteColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"); err != nil {
+		goto FAIL
+	}
+	defer stmt.Close()
+	for _, jobLog = range logs {
+		if _, err = stmt.Exec(jobLog.JobName, jobLog.Command, jobLog.ExecId, jobLog.Worker, jobLog.Err, jobLog.Output,
+			jobLog.TimedOut, jobLog.PlanTime, jobLog.ScheduleTime, jobLog.StartTime, jobLog.EndTime); err != nil {
+			goto FAIL
+		}
+	}
+	return tx.Commit()
+
+FAIL:
+	tx.Rollback()
+	return
+}
+
+// 查询日志
+func (store *SqliteStore) Query(query *common.JobLogQuery) (logArr []*common.JobLog, err error) {
+	var (
+		sqlStr string
+		where  string
+		args   []interface{}
+		order  string
+		limit  int64
+		rows   *sql.Rows
+		jobLog *common.JobLog
+	)
+	logArr = make([]*common.JobLog, 0)
+	// 列名来自白名单，可以直接拼接
+	order = "DESC"
+	if sortOrder(query) > 0 {
+		order = "ASC"
+	}
+	// sqlite的LIMIT -1 表示不限制
+	if limit = query.Limit; limit <= 0 {
+		limit = -1
+	}
+	where, args = sqliteWhere(&query.Filter)
+	sqlStr = "SELECT " + sqliteColumns + " FROM job_log" + where +
+		" ORDER BY " + sqliteColumn(sortField(query)) + " " + order + ", id " + order +
+		" LIMIT ? OFFSET ?"
+	args = append(args, limit, query.Skip)
+	if rows, err = store.db.Query(sqlStr, args...); err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		jobLog = &common.JobLog{}
+		if err = rows.Scan(&jobLog.JobName, &jobLog.Command, &jobLog.ExecId, &jobLog.Worker, &jobLog.Err, &jobLog.Output,
+			&jobLog.TimedOut, &jobLog.PlanTime, &jobLog.ScheduleTime, &jobLog.StartTime, &jobLog.EndTime); err != nil {
+			return
+		}
+		logArr = append(logArr, jobLog)
+	}
+	err = rows.Err()
+	return
+}
+
+// 统计日志条数
+func (store *SqliteStore) Count(filter *common.JobLogFilter) (count int64, err error) {
+	var (
+		where string
+		args  []interface{}
+	)
+	where, args = sqliteWhere(filter)
+	err = store.db.QueryRow("SELECT COUNT(*) FROM job_log"+where, args...).Scan(&count)
+	return
+}
+
+// 日志中出现过的任务名称，可以用上job_name的索引
+func (store *SqliteStore) JobNames() (nameArr []string, err error) {
+	var (
+		rows *sql.Rows
+		name string
+	)
+	nameArr = make([]string, 0)
+	if rows, err = store.db.Query("SELECT DISTINCT job_name FROM job_log"); err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err = rows.Scan(&name); err != nil {
+			return
+		}
+		nameArr = append(nameArr, name)
+	}
+	err = rows.Err()
+	return
+}
+
+// 按任务分组统计，次数和调度延迟用GROUP BY，耗时分位数用窗口函数按耗时排序后取对应的行
+func (store *SqliteStore) Stats(filter *common.JobLogFilter) (statsArr []*common.JobStats, err error) {
+	var (
+		where    string
+		args     []interface{}
+		rows     *sql.Rows
+		stats    *common.JobStats
+		statsMap map[string]*common.JobStats
+		jobName  string
+		lagSum   int64
+		p50Rank  int64
+		p95Rank  int64
+		rank     int64
+		total    int64
+		duration int64
+	)
+	statsArr = make([]*common.JobStats, 0)
+	statsMap = make(map[string]*common.JobStats)
+	where, args = sqliteWhere(filter)
+	if rows, err = store.db.Query("SELECT job_name, COUNT(*), SUM(err = ''), SUM(schedule_time - plan_time), MAX(schedule_time - plan_time), MAX(end_time - start_time)"+
+		" FROM job_log"+where+" GROUP BY job_name", args...); err != nil {
+		return
+	}
+	for rows.Next() {
+		stats = &common.JobStats{}
+		if err = rows.Scan(&stats.JobName, &stats.Total, &stats.SuccessCount, &lagSum, &stats.ScheduleLagMax, &stats.DurationMax); err != nil {
+			rows.Close()
+			return
+		}
+		stats.FailCount = stats.Total - stats.SuccessCount
+		stats.SuccessRate = float64(stats.SuccessCount) / float64(stats.Total)
+		stats.ScheduleLagAvg = lagSum / stats.Total
+		statsMap[stats.JobName] = stats
+		statsArr = append(statsArr, stats)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil || len(statsArr) == 0 {
+		return
+	}
+
+	// 最近秩法：第 ceil(p*n/100) 个，只返回两个分位数所在的行
+	if rows, err = store.db.Query("SELECT job_name, duration, rn, cnt FROM ("+
+		"SELECT job_name, end_time - start_time AS duration,"+
+		" ROW_NUMBER() OVER (PARTITION BY job_name ORDER BY end_time - start_time) AS rn,"+
+		" COUNT(*) OVER (PARTITION BY job_name) AS cnt"+
+		" FROM job_log"+where+
+		") WHERE rn = (50 * cnt + 99) / 100 OR rn = (95 * cnt + 99) / 100", args...); err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err = rows.Scan(&jobName, &duration, &rank, &total); err != nil {
+			return
+		}
+		if stats = statsMap[jobName]; stats == nil {
+			continue
+		}
+		p50Rank, p95Rank = percentileRank(50, stats.Total), percentileRank(95, stats.Total)
+		if rank == p50Rank {
+			stats.DurationP50 = duration
+		}
+		if rank == p95Rank {
+			stats.DurationP95 = duration
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return
+	}
+	sortStats(statsArr)
+	return
+}
+
+// 删除过期日志
+func (store *SqliteStore) DeleteBefore(jobName string, before int64) (deleted int64, err error) {
+	var (
+		result sql.Result
+	)
+	if jobName == "" {
+		result, err = store.db.Exec("DELETE FROM job_log WHERE start_time < ?", before)
+	} else {
+		result, err = store.db.Exec("DELETE FROM job_log WHERE job_name = ? AND start_time < ?", jobName, before)
+	}
+	if err != nil {
+		return
+	}
+	return result.RowsAffected()
+}
+
+// 修改日志中的任务名称
+func (store *SqliteStore) RenameJob(oldName string, newName string) (renamed int64, err error) {
+	var (
+		result sql.Result
+	)
+	if result, err = store.db.Exec("UPDATE job_log SET job_name = ? WHERE job_name = ?", newName, oldName); err != nil {
+		return
+	}
+	return result.RowsAffected()
+}
+
+// 检查数据库文件是否可用
+func (store *SqliteStore) Ping(ctx context.Context) (err error) {
+	return store.db.PingContext(ctx)
+}
+
+// 关闭数据库
+func (store *SqliteStore) Close() (err error) {
+	return store.db.Close()
+}
+
+// 过滤条件翻译成WHERE子句，值都通过参数传递
+func sqliteWhere(filter *common.JobLogFilter) (where string, args []interface{}) {
+	var (
+		conds   []string
+		keyword string
+	)
+	if filter.JobName != "" {
+		conds = append(conds, "job_name = ?")
+		args = append(args, filter.JobName)
+	} else if filter.Namespace != "" {
+		// 任务全名以 命名空间/ 开头
+		conds = append(conds, "job_name LIKE ? ESCAPE '\\'")
+		args = append(args, sqliteLikeEscaper.Replace(filter.Namespace+"/")+"%")
+	}
+	if filter.StartFrom > 0 {
+		conds = append(conds, "start_time >= ?")
+		args = append(args, filter.StartFrom)
+	}
+	if filter.StartTo > 0 {
+		conds = append(conds, "start_time < ?")
+		args = append(args, filter.StartTo)
+	}
+	if filter.EndFrom > 0 {
+		conds = append(conds, "end_time >= ?")
+		args = append(args, filter.EndFrom)
+	}
+	switch filter.Status {
+	case common.LOG_STATUS_SUCCESS:
+		conds = append(conds, "err = ''")
+	case common.LOG_STATUS_FAILED:
+		conds = append(conds, "err != ''")
+	}
+	if filter.Worker != "" {
+		conds = append(conds, "worker = ?")
+		args = append(args, filter.Worker)
+	}
+	if filter.Keyword != "" {
+		// 转义LIKE的通配符，按子串匹配
+		keyword = "%" + sqliteLikeEscaper.Replace(filter.Keyword) + "%"
+		conds = append(conds, "(output LIKE ? ESCAPE '\\' OR err LIKE ? ESCAPE '\\')")
+		args = append(args, keyword, keyword)
+	}
+	if len(conds) != 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+	return
+}
+
+// LIKE通配符转义
+var sqliteLikeEscaper = strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")
+
+// JobLog字段名 -> 表的列名
+func sqliteColumn(field string) string {
+	switch field {
+	case common.LOG_SORT_PLAN_TIME:
+		return "plan_time"
+	case common.LOG_SORT_END_TIME:
+		return "end_time"
+	}
+	return "start_time"
+}
+
+// 给老版本的表补上新增的列和唯一索引
+func sqliteMigrate(db *sql.DB) (err error) {
+	var (
+		rows    *sql.Rows
+		columns map[string]bool
+		cid     int
+		name    string
+		colType string
+		notNull int
+		dflt    sql.NullString
+		pk      int
+		i       int
+		indexed int
+	)
+	if rows, err = db.Query("PRAGMA table_info(job_log)"); err != nil {
+		return
+	}
+	columns = make(map[string]bool)
+	for rows.Next() {
+		if err = rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return
+		}
+		columns[name] = true
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return
+	}
+	for i = range sqliteAddedColumns {
+		if columns[sqliteAddedColumns[i].name] {
+			continue
+		}
+		if _, err = db.Exec("ALTER TABLE job_log ADD COLUMN " + sqliteAddedColumns[i].name + " " + sqliteAddedColumns[i].def); err != nil {
+			return
+		}
+	}
+	// 建唯一索引之前删掉之前重复补发的日志，只保留最早写入的一条
+	if err = db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'idx_job_log_exec'").Scan(&indexed); err != nil || indexed > 0 {
+		return
+	}
+	if _, err = db.Exec("DELETE FROM job_log WHERE exec_id != '' AND id NOT IN (SELECT MIN(id) FROM job_log WHERE exec_id != '' GROUP BY exec_id, worker)"); err != nil {
+		return
+	}
+	_, err = db.Exec(sqliteExecIndex)
+	return
+}
+
+// 打开sqlite文件，不存在则创建
+func NewSqliteStore(config *Config) (store *SqliteStore, err error) {
+	var (
+		db  *sql.DB
+		dsn string
+	)
+	if err = os.MkdirAll(filepath.Dir(config.Path), 0755); err != nil {
+		return
+	}
+	// 另一个进程写入时，最多等待5秒
+	dsn = "file:" + config.Path + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+	if db, err = sql.Open("sqlite", dsn); err != nil {
+		return
+	}
+	if _, err = db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return
+	}
+	if err = sqliteMigrate(db); err != nil {
+		db.Close()
+		return
+	}
+	store = &SqliteStore{
+		db: db,
+	}
+	return
+}
@@ -0,0 +1,34 @@
+package logstore
+
+import (
+	"../common"
+	"reflect"
+	"testing"
+)
+
+func TestSqliteWhere(t *testing.T) {
+	var (
+		cases = []struct {
+			name   string
+			filter common.JobLogFilter
+			where  string
+			args   []interface{}
+		}{
+			{"空条件", common.JobLogFilter{}, "", nil},
+			{"任务名称优先于命名空间", common.JobLogFilter{JobName: "a/job1", Namespace: "b"}, " WHERE job_name = ?", []interface{}{"a/job1"}},
+			{"命名空间转义通配符", common.JobLogFilter{Namespace: "a_b"}, " WHERE job_name LIKE ? ESCAPE '\\'", []interface{}{"a\\_b/%"}},
+			{"时间范围", common.JobLogFilter{StartFrom: 1, StartTo: 2, EndFrom: 3}, " WHERE start_time >= ? AND start_time < ? AND end_time >= ?", []interface{}{int64(1), int64(2), int64(3)}},
+			{"失败", common.JobLogFilter{Status: common.LOG_STATUS_FAILED, Worker: "host1"}, " WHERE err != '' AND worker = ?", []interface{}{"host1"}},
+			{"关键字", common.JobLogFilter{JobName: "a/job1", Keyword: "50%"}, " WHERE job_name = ? AND (output LIKE ? ESCAPE '\\' OR err LIKE ? ESCAPE '\\')", []interface{}{"a/job1", "%50\\%%", "%50\\%%"}},
+		}
+		where string
+		args  []interface{}
+		i     int
+	)
+	for i = range cases {
+		where, args = sqliteWhere(&cases[i].filter)
+		if where != cases[i].where || !reflect.DeepEqual(args, cases[i].args) {
+			t.Errorf("%s: 得到 %q %v，期望 %q %v", cases[i].name, where, args, cases[i].where, cases[i].args)
+		}
+	}
+}
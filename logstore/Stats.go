@@ -0,0 +1,67 @@
+package logstore
+
+import (
+	"../common"
+	"math"
+	"sort"
+)
+
+// 在内存中汇总一个任务的执行统计，供不支持聚合查询的存储使用
+type statsBuilder struct {
+	stats     *common.JobStats
+	durations []int64
+	lagSum    int64
+}
+
+func newStatsBuilder(jobName string) *statsBuilder {
+	return &statsBuilder{
+		stats:     &common.JobStats{JobName: jobName},
+		durations: make([]int64, 0),
+	}
+}
+
+// 累加一条日志
+func (builder *statsBuilder) add(jobLog *common.JobLog) {
+	var (
+		lag int64
+	)
+	builder.stats.Total++
+	if jobLog.Err == "" {
+		builder.stats.SuccessCount++
+	} else {
+		builder.stats.FailCount++
+	}
+	builder.durations = append(builder.durations, jobLog.EndTime-jobLog.StartTime)
+	lag = jobLog.ScheduleTime - jobLog.PlanTime
+	if builder.stats.Total == 1 || lag > builder.stats.ScheduleLagMax {
+		builder.stats.ScheduleLagMax = lag
+	}
+	builder.lagSum += lag
+}
+
+// 计算成功率、平均调度延迟和耗时分位数
+func (builder *statsBuilder) build() *common.JobStats {
+	sort.Slice(builder.durations, func(i, j int) bool { return builder.durations[i] < builder.durations[j] })
+	builder.stats.SuccessRate = 1
+	if builder.stats.Total > 0 {
+		builder.stats.SuccessRate = float64(builder.stats.SuccessCount) / float64(builder.stats.Total)
+		builder.stats.ScheduleLagAvg = builder.lagSum / builder.stats.Total
+		builder.stats.DurationP50 = builder.durations[percentileRank(50, builder.stats.Total)-1]
+		builder.stats.DurationP95 = builder.durations[percentileRank(95, builder.stats.Total)-1]
+		builder.stats.DurationMax = builder.durations[len(builder.durations)-1]
+	}
+	return builder.stats
+}
+
+// p分位数在n个升序排列的值中的位置，从1开始（最近秩法）
+func percentileRank(p int, n int64) (rank int64) {
+	if rank = int64(math.Ceil(float64(p) / 100 * float64(n))); rank < 1 {
+		rank = 1
+	}
+	return
+}
+
+// 按任务名称排序
+func sortStats(statsArr []*common.JobStats) {
+	sort.Slice(statsArr, func(i, j int) bool { return statsArr[i].JobName < statsArr[j].JobName })
+}
@@ -0,0 +1,108 @@
+package logstore
+
+import (
+	"../common"
+	"testing"
+)
+
+func TestPercentileRank(t *testing.T) {
+	var (
+		cases = []struct {
+			p    int
+			n    int64
+			rank int64
+		}{
+			{50, 1, 1},
+			{95, 1, 1},
+			{50, 4, 2},
+			{95, 4, 4},
+			{50, 100, 50},
+			{95, 100, 95},
+			{95, 101, 96},
+		}
+		i    int
+		rank int64
+	)
+	for i = range cases {
+		if rank = percentileRank(cases[i].p, cases[i].n); rank != cases[i].rank {
+			t.Errorf("percentileRank(%d, %d) = %d, want %d", cases[i].p, cases[i].n, rank, cases[i].rank)
+		}
+	}
+}
+
+func TestFileStoreStats(t *testing.T) {
+	var (
+		store    *FileStore
+		cleanup  func()
+		logs     []*common.JobLog
+		statsArr []*common.JobStats
+		stats    *common.JobStats
+		i        int64
+		err      error
+	)
+	store, cleanup = newTestFileStore(t)
+	defer cleanup()
+	if statsArr, err = store.Stats(&common.JobLogFilter{}); err != nil || len(statsArr) != 0 {
+		t.Fatalf("文件不存在时 Stats = %v, %v", statsArr, err)
+	}
+	// a/job1执行10次，耗时1..10ms，最后两次失败；b/job2在统计窗口之前执行过一次
+	for i = 1; i <= 10; i++ {
+		logs = append(logs, &common.JobLog{JobName: "a/job1", ExecId: string(rune('a' + i)), PlanTime: 1000 * i, ScheduleTime: 1000*i + i, StartTime: 1000 * i, EndTime: 1000*i + i})
+	}
+	logs[8].Err, logs[9].Err = "exit status 1", "exit status 1"
+	logs = append(logs, &common.JobLog{JobName: "b/job2", ExecId: "z", PlanTime: 10, ScheduleTime: 10, StartTime: 10, EndTime: 20})
+	if err = store.Append(logs); err != nil {
+		t.Fatal(err)
+	}
+
+	if statsArr, err = store.Stats(&common.JobLogFilter{StartFrom: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	if len(statsArr) != 1 {
+		t.Fatalf("统计了%d个任务，应该只有a/job1", len(statsArr))
+	}
+	stats = statsArr[0]
+	if stats.JobName != "a/job1" || stats.Total != 10 || stats.SuccessCount != 8 || stats.FailCount != 2 || stats.SuccessRate != 0.8 {
+		t.Errorf("次数统计错误: %+v", stats)
+	}
+	if stats.DurationP50 != 5 || stats.DurationP95 != 10 || stats.DurationMax != 10 {
+		t.Errorf("耗时统计错误: %+v", stats)
+	}
+	if stats.ScheduleLagAvg != 5 || stats.ScheduleLagMax != 10 {
+		t.Errorf("调度延迟统计错误: %+v", stats)
+	}
+
+	if statsArr, err = store.Stats(&common.JobLogFilter{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(statsArr) != 2 || statsArr[0].JobName != "a/job1" || statsArr[1].JobName != "b/job2" || statsArr[1].SuccessRate != 1 {
+		t.Errorf("按任务名排序的统计结果错误: %+v %+v", statsArr[0], statsArr[1])
+	}
+	if statsArr, err = store.Stats(&common.JobLogFilter{Namespace: "b"}); err != nil || len(statsArr) != 1 || statsArr[0].JobName != "b/job2" {
+		t.Errorf("按命名空间统计错误: %v, %v", statsArr, err)
+	}
+}
+
+func TestFileStoreJobNames(t *testing.T) {
+	var (
+		store   *FileStore
+		cleanup func()
+		nameArr []string
+		err     error
+	)
+	store, cleanup = newTestFileStore(t)
+	defer cleanup()
+	if nameArr, err = store.JobNames(); err != nil || len(nameArr) != 0 {
+		t.Fatalf("文件不存在时 JobNames = %v, %v", nameArr, err)
+	}
+	if err = store.Append([]*common.JobLog{
+		{JobName: "a/job1", ExecId: "1"},
+		{JobName: "b/job2", ExecId: "2"},
+		{JobName: "a/job1", ExecId: "3"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if nameArr, err = store.JobNames(); err != nil || len(nameArr) != 2 || nameArr[0] != "a/job1" || nameArr[1] != "b/job2" {
+		t.Errorf("JobNames = %v, %v", nameArr, err)
+	}
+}
@@ -2,16 +2,22 @@ package master
 
 import (
 	"../common"
+	"../logger"
+	"context"
 	"encoding/json"
-	"fmt"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"net"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
 var (
 	G_apiServer *ApiServer // 单例对象，默认为nil，首字母大写，其他对象可以访问
+
+	apiLog = logger.Component("api")
 )
 
 // 任务的http接口
@@ -20,7 +26,8 @@ type ApiServer struct {
 }
 
 // 保存任务接口
-// 前端post一个json数据： job={"name":"job1", "command":"echo hello", "cronExpr":"* * * * *"}
+// 前端post一个json数据： job={"namespace":"default", "name":"job1", "command":"echo hello", "cronExpr":"* * * * *"}
+// job中没有命名空间时使用表单中的namespace，都没有则为default
 func handleJobSave(resp http.ResponseWriter, req *http.Request) {
 	var (
 		err     error
@@ -29,19 +36,23 @@ func handleJobSave(resp http.ResponseWriter, req *http.Request) {
 		oldJob  *common.Job
 		bytes   []byte
 	)
-	fmt.Println("进入了handleJobSave方法内部")
 	// 1. 解析post表单
 	if err = req.ParseForm(); err != nil {
 		goto ERR
 	}
 	// 2. 取表单中的job字段
 	postJob = req.PostForm.Get("job") // 表单 key=job / value为json {"name":"job15","command":"echo hello1","cronExpr":"* * * * *"}
-	fmt.Println(postJob)
 	// 3. 反序列化job
 	job = &common.Job{}
 	if err = json.Unmarshal([]byte(postJob), job); err != nil {
 		goto ERR
 	}
+	if job.Namespace == "" {
+		job.Namespace = req.PostForm.Get("namespace")
+	}
+	if _, err = authorizeJob(req.Context(), job.Namespace, job.Name, common.API_ROLE_EDITOR); err != nil {
+		goto ERR
+	}
 	// 4. 将任务job保存到 ETCD 中
 	if oldJob, err = G_jobMgr.SaveJob(job); err != nil {
 		goto ERR
@@ -52,7 +63,7 @@ func handleJobSave(resp http.ResponseWriter, req *http.Request) {
 	}
 	return // 不进入ERR
 ERR:
-	fmt.Println(err)
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
 	//6. 返回异常应答
 	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
 		resp.Write(bytes)
@@ -60,7 +71,7 @@ ERR:
 }
 
 // 删除任务接口
-// post /job/delete name = job1
+// post /job/delete name = job1 [namespace = default] [keepLogs = true]
 func handleJobDelete(resp http.ResponseWriter, req *http.Request) {
 	var (
 		err    error
@@ -71,41 +82,112 @@ func handleJobDelete(resp http.ResponseWriter, req *http.Request) {
 	if err = req.ParseForm(); err != nil {
 		goto ERR
 	}
-	// 删除的任务名称
-	name = req.PostForm.Get("name")
+	// 删除的任务全名
+	if name, err = authorizeJob(req.Context(), req.PostForm.Get("namespace"), req.PostForm.Get("name"), common.API_ROLE_EDITOR); err != nil {
+		goto ERR
+	}
 	// 删除任务
 	if oldJob, err = G_jobMgr.DeleteJob(name); err != nil {
 		goto ERR
 	}
+	// 同时删除任务日志，否则日志会一直留到全局保留策略清理，keepLogs=true 保留
+	if req.PostForm.Get("keepLogs") != "true" {
+		if _, err = G_logMgr.PurgeLog(name); err != nil {
+			goto ERR
+		}
+	}
 	// 正常应答
-	if bytes, err = common.BuildResponse(0, "succrss", oldJob); err == nil {
+	if bytes, err = common.BuildResponse(0, "success", oldJob); err == nil {
 		resp.Write(bytes)
 	}
 	return
 ERR:
-	fmt.Println(err)
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
 	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
 		resp.Write(bytes)
 	}
 }
 
-// 查看etcd中所有的任务列表
-func handleJobList(resp http.ResponseWriter, req *http.Request) {
+// 任务列表的查询条件，tag可以传多个，也可以用逗号分隔
+func parseJobListQuery(values url.Values) (query *common.JobListQuery) {
+	var (
+		tagParam string
+		tag      string
+	)
+	query = &common.JobListQuery{
+		Tags:    make([]string, 0),
+		Owner:   values.Get("owner"),
+		Team:    values.Get("team"),
+		Keyword: values.Get("keyword"),
+		Sort:    values.Get("sort"),
+	}
+	for _, tagParam = range values["tag"] {
+		for _, tag = range strings.Split(tagParam, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				query.Tags = append(query.Tags, tag)
+			}
+		}
+	}
+	return
+}
+
+// 查询有查看权限的任务，附带任务的成功心跳状态，namespace为空表示所有命名空间
+func listJobPage(ctx context.Context, namespace string, query *common.JobListQuery) (page *common.JobListPage, err error) {
 	var (
-		err     error
 		jobList []*common.Job
-		bytes   []byte
+		job     *common.Job
 	)
-	if jobList, err = G_jobMgr.ListJob(); err != nil {
+	if err = checkNamespaceParam(namespace); err != nil {
+		return
+	}
+	if jobList, err = G_jobMgr.ListJob(namespace); err != nil {
+		return
+	}
+	page = &common.JobListPage{}
+	if jobList, page.Total, err = common.QueryJobs(filterReadableJobs(ctx, jobList), query); err != nil {
+		return
+	}
+	page.Jobs = make([]*common.JobListItem, 0, len(jobList))
+	for _, job = range jobList {
+		page.Jobs = append(page.Jobs, &common.JobListItem{
+			Job:      job,
+			Watchdog: G_jobWatchdog.State(job.FullName()),
+		})
+	}
+	return
+}
+
+// 查看etcd中的任务列表，只返回有查看权限的命名空间
+// GET /job/list?namespace=default&tag=db&tag=daily&owner=alice&team=infra&keyword=backup&sort=-updateTime&skip=0&limit=20
+// 不传namespace返回所有命名空间，不传limit返回全部
+func handleJobList(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err   error
+		query *common.JobListQuery
+		page  *common.JobListPage
+		bytes []byte
+	)
+	if err = req.ParseForm(); err != nil {
 		goto ERR
 	}
-	// 正常应答
-	if bytes, err = common.BuildResponse(0, "success", jobList); err == nil {
+	query = parseJobListQuery(req.Form)
+	// 格式错误则不分页
+	if query.Skip, err = strconv.Atoi(req.Form.Get("skip")); err != nil || query.Skip < 0 {
+		query.Skip = 0
+	}
+	if query.Limit, err = strconv.Atoi(req.Form.Get("limit")); err != nil || query.Limit < 0 {
+		query.Limit = 0
+	}
+	if page, err = listJobPage(req.Context(), req.Form.Get("namespace"), query); err != nil {
+		goto ERR
+	}
+	// 正常应答 {"total":100, "jobs":[...]}
+	if bytes, err = common.BuildResponse(0, "success", page); err == nil {
 		resp.Write(bytes)
 	}
 	return
 ERR:
-	fmt.Println(err)
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
 	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
 		resp.Write(bytes)
 	}
@@ -121,8 +203,10 @@ func handleJobKill(resp http.ResponseWriter, req *http.Request) {
 	if err = req.ParseForm(); err != nil {
 		goto ERR
 	}
-	// 要杀死的任务名称
-	name = req.PostForm.Get("name")
+	// 要杀死的任务全名
+	if name, err = authorizeJob(req.Context(), req.PostForm.Get("namespace"), req.PostForm.Get("name"), common.API_ROLE_EDITOR); err != nil {
+		goto ERR
+	}
 	if err = G_jobMgr.KillJob(name); err != nil {
 		goto ERR
 	}
@@ -132,7 +216,225 @@ func handleJobKill(resp http.ResponseWriter, req *http.Request) {
 	}
 	return
 ERR:
-	fmt.Println(err)
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 查询单个任务
+// GET /job/get?name=job10&namespace=default
+func handleJobGet(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err   error
+		name  string
+		job   *common.Job
+		bytes []byte
+	)
+	if err = req.ParseForm(); err != nil {
+		goto ERR
+	}
+	if name, err = authorizeJob(req.Context(), req.Form.Get("namespace"), req.Form.Get("name"), common.API_ROLE_VIEWER); err != nil {
+		goto ERR
+	}
+	if job, err = G_jobMgr.GetJob(name); err != nil {
+		goto ERR
+	}
+	// 正常应答，附带成功心跳状态
+	if bytes, err = common.BuildResponse(0, "success", &common.JobListItem{
+		Job:      job,
+		Watchdog: G_jobWatchdog.State(job.FullName()),
+	}); err == nil {
+		resp.Write(bytes)
+	}
+	return
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 应用任务清单，在一个etcd事务中完成新建、修改和删除
+// post /job/apply manifest=... prune=true dryRun=true
+func handleJobApply(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err      error
+		manifest *common.JobManifest
+		result   *common.ApplyResult
+		bytes    []byte
+	)
+	if err = req.ParseForm(); err != nil {
+		goto ERR
+	}
+	// 表单 manifest=YAML或JSON格式的清单 prune=true删除清单中没有的任务 dryRun=true只返回变更
+	if manifest, err = common.ParseManifest([]byte(req.PostForm.Get("manifest"))); err != nil {
+		goto ERR
+	}
+	if err = authorizeManifest(req.Context(), manifest); err != nil {
+		goto ERR
+	}
+	if result, err = G_jobMgr.ApplyManifest(manifest, req.PostForm.Get("prune") == "true", req.PostForm.Get("dryRun") == "true"); err != nil {
+		goto ERR
+	}
+	// 正常应答 {"changes":[...], "unchanged":3, "applied":true}
+	if bytes, err = common.BuildResponse(0, "success", result); err == nil {
+		resp.Write(bytes)
+	}
+	return
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 把crontab文件转换成任务，apply=true时同时保存，只新建任务，已存在的同名任务不修改，列在skipped中
+// post /job/import/crontab crontab=... system=true prefix=sys- namespace=default apply=true
+func handleJobImportCrontab(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err       error
+		namespace string
+		result    *common.CrontabImportResult
+		bytes     []byte
+	)
+	if err = req.ParseForm(); err != nil {
+		goto ERR
+	}
+	namespace = common.NormalizeNamespace(req.PostForm.Get("namespace"))
+	if err = common.ValidateNamespace(namespace); err != nil {
+		goto ERR
+	}
+	result = common.ParseCrontab(req.PostForm.Get("crontab"), &common.CrontabImportOptions{
+		System:     req.PostForm.Get("system") == "true",
+		NamePrefix: req.PostForm.Get("prefix"),
+		Namespace:  namespace,
+	})
+	// 名称是根据命令推导的，很容易和已有的任务重名，只新建，不覆盖已有的任务
+	if req.PostForm.Get("apply") == "true" {
+		if err = authorize(req.Context(), namespace, common.API_ROLE_EDITOR); err != nil {
+			goto ERR
+		}
+		if result.Applied, err = G_jobMgr.CreateJobs(&common.JobManifest{
+			Version: common.JOB_MANIFEST_VERSION,
+			Jobs:    result.Jobs,
+		}); err != nil {
+			goto ERR
+		}
+		result.SkipJobs(result.Applied.Existing, "任务已存在")
+	}
+	// 正常应答 {"jobs":[...], "skipped":[...], "warnings":[...]}
+	if bytes, err = common.BuildResponse(0, "success", result); err == nil {
+		resp.Write(bytes)
+	}
+	return
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 导出任务作为备份，直接返回备份文件内容，出错时返回普通的错误应答
+// 只导出有查看权限的命名空间
+// get /job/export?namespace=default，不传namespace导出所有命名空间
+func handleJobExport(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err     error
+		archive *common.JobArchive
+		bytes   []byte
+	)
+	if err = req.ParseForm(); err != nil {
+		goto ERR
+	}
+	if err = checkNamespaceParam(req.Form.Get("namespace")); err != nil {
+		goto ERR
+	}
+	if archive, err = G_jobMgr.ExportJobs(req.Form.Get("namespace")); err != nil {
+		goto ERR
+	}
+	filterReadableArchive(req.Context(), archive)
+	if bytes, err = json.MarshalIndent(archive, "", "  "); err != nil {
+		goto ERR
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("Content-Disposition", "attachment; filename=cron-jobs-"+time.Now().Format("20060102-150405")+".json")
+	resp.Write(bytes)
+	return
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 从备份恢复任务
+// post /job/import archive=备份文件内容 mode=skip|overwrite|fail，mode默认fail
+func handleJobImport(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err     error
+		archive *common.JobArchive
+		mode    string
+		result  *common.JobImportResult
+		bytes   []byte
+	)
+	if err = req.ParseForm(); err != nil {
+		goto ERR
+	}
+	if archive, err = common.UnpackJobArchive([]byte(req.PostForm.Get("archive"))); err != nil {
+		goto ERR
+	}
+	if err = authorizeArchive(req.Context(), archive); err != nil {
+		goto ERR
+	}
+	if mode = req.PostForm.Get("mode"); mode == "" {
+		mode = common.JOB_IMPORT_MODE_FAIL
+	}
+	if result, err = G_jobMgr.ImportJobs(archive, mode); err != nil {
+		goto ERR
+	}
+	// 正常应答 {"created":[...], "overwritten":[...], "skipped":[...]}
+	if bytes, err = common.BuildResponse(0, "success", result); err == nil {
+		resp.Write(bytes)
+	}
+	return
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 立即执行一次任务，不影响原有的调度计划
+// post /job/run name=job10 namespace=default params={"db":"orders"}，params可选，覆盖任务参数的默认值
+func handleJobRun(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err    error
+		name   string
+		params map[string]interface{}
+		bytes  []byte
+	)
+	if err = req.ParseForm(); err != nil {
+		goto ERR
+	}
+	if name, err = authorizeJob(req.Context(), req.PostForm.Get("namespace"), req.PostForm.Get("name"), common.API_ROLE_EDITOR); err != nil {
+		goto ERR
+	}
+	if req.PostForm.Get("params") != "" {
+		if err = json.Unmarshal([]byte(req.PostForm.Get("params")), &params); err != nil {
+			goto ERR
+		}
+	}
+	if err = G_jobMgr.RunJob(name, params); err != nil {
+		goto ERR
+	}
+	// 正常应答
+	if bytes, err = common.BuildResponse(0, "success", nil); err == nil {
+		resp.Write(bytes)
+	}
+	return
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
 	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
 		resp.Write(bytes)
 	}
@@ -142,20 +444,40 @@ ERR:
 func handleJobLog(resp http.ResponseWriter, req *http.Request) {
 	var (
 		err        error
-		name       string // 任务名称
+		filter     *common.JobLogFilter
 		skipParam  string // 从第几条开始
 		limitParam string // 返回多少条
 		skip       int
 		limit      int
-		logArr     []*common.JobLog
+		logPage    *common.JobLogPage
 		bytes      []byte
 	)
 	// 解析Get参数
 	if err = req.ParseForm(); err != nil {
 		goto ERR
 	}
-	// 获取请求参数 /job/log?name=job10&skip=0&limit=10
-	name = req.Form.Get("name")
+	// 获取请求参数 /job/log?name=job10&namespace=default&startTime=1546300800000&endTime=1546387200000&status=failed&worker=host1-3f2a9c&keyword=timeout&skip=0&limit=10
+	// 跟踪新日志时 endFrom=1546387200000&sort=endTime，按结束时间正序返回结束时间不早于endFrom的日志
+	filter = &common.JobLogFilter{
+		Status:  req.Form.Get("status"),
+		Worker:  req.Form.Get("worker"),
+		Keyword: req.Form.Get("keyword"),
+	}
+	// 不传name时查询整个命名空间，namespace也不传则查询所有命名空间
+	if req.Form.Get("name") != "" {
+		if filter.JobName, err = authorizeJob(req.Context(), req.Form.Get("namespace"), req.Form.Get("name"), common.API_ROLE_VIEWER); err != nil {
+			goto ERR
+		}
+	} else {
+		if err = authorizeNamespaceFilter(req.Context(), req.Form.Get("namespace")); err != nil {
+			goto ERR
+		}
+		filter.Namespace = req.Form.Get("namespace")
+	}
+	// 时间范围，毫秒，不传或格式错误则不限制
+	filter.StartFrom, _ = strconv.ParseInt(req.Form.Get("startTime"), 10, 64)
+	filter.StartTo, _ = strconv.ParseInt(req.Form.Get("endTime"), 10, 64)
+	filter.EndFrom, _ = strconv.ParseInt(req.Form.Get("endFrom"), 10, 64)
 	skipParam = req.Form.Get("skip")
 	limitParam = req.Form.Get("limit")
 	if skip, err = strconv.Atoi(skipParam); err != nil {
@@ -164,16 +486,246 @@ func handleJobLog(resp http.ResponseWriter, req *http.Request) {
 	if limit, err = strconv.Atoi(limitParam); err != nil {
 		limit = 20 //默认给20条
 	}
-	if logArr, err = G_logMgr.ListLog(name, int64(skip), int64(limit)); err != nil {
+	if logPage, err = G_logMgr.ListLog(filter, req.Form.Get("sort"), int64(skip), int64(limit)); err != nil {
+		goto ERR
+	}
+	// 正常应答 {"total":100, "logs":[...]}
+	if bytes, err = common.BuildResponse(0, "success", logPage); err == nil {
+		resp.Write(bytes)
+	}
+	return
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 任务日志量，不传name返回命名空间中有查看权限的所有任务
+// GET /job/log/stats?name=job10&namespace=default
+func handleJobLogStats(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err      error
+		name     string
+		job      *common.Job
+		jobList  []*common.Job
+		stats    *common.JobLogStats
+		statsArr []*common.JobLogStats
+		bytes    []byte
+	)
+	if err = req.ParseForm(); err != nil {
+		goto ERR
+	}
+	if req.Form.Get("name") != "" {
+		if name, err = authorizeJob(req.Context(), req.Form.Get("namespace"), req.Form.Get("name"), common.API_ROLE_VIEWER); err != nil {
+			goto ERR
+		}
+		if job, err = G_jobMgr.GetJob(name); err != nil {
+			goto ERR
+		}
+		jobList = []*common.Job{job}
+	} else {
+		if err = checkNamespaceParam(req.Form.Get("namespace")); err != nil {
+			goto ERR
+		}
+		if jobList, err = G_jobMgr.ListJob(req.Form.Get("namespace")); err != nil {
+			goto ERR
+		}
+		jobList = filterReadableJobs(req.Context(), jobList)
+	}
+	statsArr = make([]*common.JobLogStats, 0)
+	for _, job = range jobList {
+		if stats, err = G_logMgr.JobLogStats(job.FullName(), G_logPruner.EffectiveRetention(job)); err != nil {
+			goto ERR
+		}
+		statsArr = append(statsArr, stats)
+	}
+	// 正常应答
+	if bytes, err = common.BuildResponse(0, "success", statsArr); err == nil {
+		resp.Write(bytes)
+	}
+	return
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 删除任务的全部日志
+// post /job/log/purge name=job10 namespace=default
+func handleJobLogPurge(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err     error
+		name    string
+		deleted int64
+		bytes   []byte
+	)
+	if err = req.ParseForm(); err != nil {
+		goto ERR
+	}
+	if name, err = authorizeJob(req.Context(), req.PostForm.Get("namespace"), req.PostForm.Get("name"), common.API_ROLE_EDITOR); err != nil {
+		goto ERR
+	}
+	if deleted, err = G_logMgr.PurgeLog(name); err != nil {
+		goto ERR
+	}
+	// 正常应答，返回删除的条数
+	if bytes, err = common.BuildResponse(0, "success", deleted); err == nil {
+		resp.Write(bytes)
+	}
+	return
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 解析统计时间窗口，支持 1h / 30m / 7d，默认24小时，返回窗口的起始时间(毫秒)
+func parseStatsWindow(window string) (since int64, err error) {
+	var (
+		duration time.Duration
+		days     int
+	)
+	if window == "" {
+		window = "24h"
+	}
+	if strings.HasSuffix(window, "d") { // time.ParseDuration不支持天
+		if days, err = strconv.Atoi(strings.TrimSuffix(window, "d")); err != nil {
+			err = common.ERR_INVALID_STATS_WINDOW
+			return
+		}
+		duration = time.Duration(days) * 24 * time.Hour
+	} else if duration, err = time.ParseDuration(window); err != nil {
+		err = common.ERR_INVALID_STATS_WINDOW
+		return
+	}
+	since = time.Now().Add(-duration).UnixNano() / 1000 / 1000
+	return
+}
+
+// 任务执行统计：成功率、失败次数、耗时分位数、调度延迟
+// GET /job/stats?name=job10&namespace=default&window=24h
+func handleJobStats(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err   error
+		name  string
+		since int64
+		stats *common.JobStats
+		bytes []byte
+	)
+	if err = req.ParseForm(); err != nil {
+		goto ERR
+	}
+	if req.Form.Get("name") == "" {
+		err = common.ERR_JOB_NAME_REQUIRED
+		goto ERR
+	}
+	if name, err = authorizeJob(req.Context(), req.Form.Get("namespace"), req.Form.Get("name"), common.API_ROLE_VIEWER); err != nil {
+		goto ERR
+	}
+	if since, err = parseStatsWindow(req.Form.Get("window")); err != nil {
+		goto ERR
+	}
+	if stats, err = G_logMgr.JobStats(name, since); err != nil {
+		goto ERR
+	}
+	// 正常应答
+	if bytes, err = common.BuildResponse(0, "success", stats); err == nil {
+		resp.Write(bytes)
+	}
+	return
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 集群概览：有查看权限的任务中表现最差的任务
+// GET /job/stats/summary?window=7d&top=10&namespace=default，不传namespace统计所有命名空间
+func handleJobStatsSummary(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err      error
+		since    int64
+		top      int
+		jobList  []*common.Job
+		job      *common.Job
+		nameArr  []string
+		statsArr []*common.JobStats
+		bytes    []byte
+	)
+	if err = req.ParseForm(); err != nil {
+		goto ERR
+	}
+	if since, err = parseStatsWindow(req.Form.Get("window")); err != nil {
+		goto ERR
+	}
+	if top, err = strconv.Atoi(req.Form.Get("top")); err != nil {
+		top = 10 // 默认返回10个
+	}
+	if err = checkNamespaceParam(req.Form.Get("namespace")); err != nil {
+		goto ERR
+	}
+	if jobList, err = G_jobMgr.ListJob(req.Form.Get("namespace")); err != nil {
+		goto ERR
+	}
+	for _, job = range filterReadableJobs(req.Context(), jobList) {
+		nameArr = append(nameArr, job.FullName())
+	}
+	if statsArr, err = G_logMgr.WorstJobStats(req.Form.Get("namespace"), nameArr, since, top); err != nil {
+		goto ERR
+	}
+	// 正常应答
+	if bytes, err = common.BuildResponse(0, "success", statsArr); err == nil {
+		resp.Write(bytes)
+	}
+	return
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 广播任务的执行汇总，按调度时间点返回每个节点的执行结果
+// GET /job/broadcast?name=job10&namespace=default&skip=0&limit=10
+func handleJobBroadcast(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err        error
+		name       string
+		skipParam  string
+		limitParam string
+		skip       int
+		limit      int
+		tickArr    []*common.BroadcastTick
+		bytes      []byte
+	)
+	if err = req.ParseForm(); err != nil {
+		goto ERR
+	}
+	if name, err = authorizeJob(req.Context(), req.Form.Get("namespace"), req.Form.Get("name"), common.API_ROLE_VIEWER); err != nil {
+		goto ERR
+	}
+	skipParam = req.Form.Get("skip")
+	limitParam = req.Form.Get("limit")
+	if skip, err = strconv.Atoi(skipParam); err != nil {
+		skip = 0
+	}
+	if limit, err = strconv.Atoi(limitParam); err != nil {
+		limit = 20
+	}
+	if tickArr, err = G_logMgr.ListBroadcastTicks(name, int64(skip), int64(limit)); err != nil {
 		goto ERR
 	}
 	// 正常应答
-	if bytes, err = common.BuildResponse(0, "success", logArr); err == nil {
+	if bytes, err = common.BuildResponse(0, "success", tickArr); err == nil {
 		resp.Write(bytes)
 	}
 	return
 ERR:
-	fmt.Println(err)
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
 	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
 		resp.Write(bytes)
 	}
@@ -182,7 +734,7 @@ ERR:
 // 服务发现模块，返回所有节点
 func handleWorkerList(resp http.ResponseWriter, req *http.Request) {
 	var (
-		workerArr []string
+		workerArr []*common.WorkerInfo
 		err       error
 		bytes     []byte
 	)
@@ -195,7 +747,97 @@ func handleWorkerList(resp http.ResponseWriter, req *http.Request) {
 	}
 	return
 ERR:
-	fmt.Println(err)
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 查询单个节点的详细信息
+// GET /worker/detail?id=host1-3f2a9c
+func handleWorkerDetail(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err        error
+		workerId   string
+		workerInfo *common.WorkerInfo
+		bytes      []byte
+	)
+	if err = req.ParseForm(); err != nil {
+		goto ERR
+	}
+	workerId = req.Form.Get("id")
+	if workerInfo, err = G_workerMgr.GetWorker(workerId); err != nil {
+		goto ERR
+	}
+	// 正常应答
+	if bytes, err = common.BuildResponse(0, "success", workerInfo); err == nil {
+		resp.Write(bytes)
+	}
+	return
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 节点维护：禁止调度 / 排空，前端post  id=host1-3f2a9c，需要所有命名空间的admin权限
+func handleWorkerCordonFlag(flag string) func(resp http.ResponseWriter, req *http.Request) {
+	return func(resp http.ResponseWriter, req *http.Request) {
+		var (
+			err        error
+			workerId   string
+			workerInfo *common.WorkerInfo
+			bytes      []byte
+		)
+		if err = req.ParseForm(); err != nil {
+			goto ERR
+		}
+		if err = authorize(req.Context(), "", common.API_ROLE_ADMIN); err != nil {
+			goto ERR
+		}
+		workerId = req.PostForm.Get("id")
+		if workerInfo, err = G_workerMgr.CordonWorker(workerId, flag); err != nil {
+			goto ERR
+		}
+		// 正常应答，返回节点信息，排空进度通过 /worker/detail 轮询state和runningJobs
+		if bytes, err = common.BuildResponse(0, "success", workerInfo); err == nil {
+			resp.Write(bytes)
+		}
+		return
+	ERR:
+		apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+		if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+			resp.Write(bytes)
+		}
+	}
+}
+
+// 节点恢复调度
+// post /worker/uncordon id=host1-3f2a9c
+func handleWorkerUncordon(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err      error
+		workerId string
+		bytes    []byte
+	)
+	if err = req.ParseForm(); err != nil {
+		goto ERR
+	}
+	if err = authorize(req.Context(), "", common.API_ROLE_ADMIN); err != nil {
+		goto ERR
+	}
+	workerId = req.PostForm.Get("id")
+	if err = G_workerMgr.UncordonWorker(workerId); err != nil {
+		goto ERR
+	}
+	// 正常应答
+	if bytes, err = common.BuildResponse(0, "success", nil); err == nil {
+		resp.Write(bytes)
+	}
+	return
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
 	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
 		resp.Write(bytes)
 	}
@@ -212,12 +854,39 @@ func InitApiServer(err error) error {
 	)
 	// 配置路由
 	mux = http.NewServeMux()
-	mux.HandleFunc("/job/save", handleJobSave) // 处理请求
-	mux.HandleFunc("/job/delete", handleJobDelete)
-	mux.HandleFunc("/job/list", handleJobList)
-	mux.HandleFunc("/job/kill", handleJobKill)
-	mux.HandleFunc("/job/log", handleJobLog) // 日志查询
-	mux.HandleFunc("/worker/list", handleWorkerList)
+	handle := func(path string, handler http.HandlerFunc) {
+		mux.HandleFunc(path, instrumentHandler(path, requireToken(handler))) // 统计请求次数和耗时，校验token
+	}
+	handle("/job/save", handleJobSave) // 处理请求
+	handle("/job/delete", handleJobDelete)
+	handle("/job/list", handleJobList)
+	handle("/job/kill", handleJobKill)
+	handle("/job/get", handleJobGet)
+	handle("/job/run", handleJobRun)
+	handle("/job/apply", handleJobApply)
+	handle("/job/import/crontab", handleJobImportCrontab)
+	handle("/job/export", handleJobExport)
+	handle("/job/import", handleJobImport)
+	handle("/job/log", handleJobLog) // 日志查询
+	handle("/job/log/stats", handleJobLogStats)
+	handle("/job/log/purge", handleJobLogPurge)
+	handle("/job/broadcast", handleJobBroadcast)
+	handle("/job/stats", handleJobStats)
+	handle("/job/stats/summary", handleJobStatsSummary)
+	handle("/worker/list", handleWorkerList)
+	handle("/worker/detail", handleWorkerDetail)
+	handle("/worker/cordon", handleWorkerCordonFlag(common.WORKER_FLAG_CORDON))
+	handle("/worker/uncordon", handleWorkerUncordon)
+	handle("/worker/drain", handleWorkerCordonFlag(common.WORKER_FLAG_DRAIN))
+	mux.Handle("/metrics", promhttp.Handler()) // prometheus监控指标
+	mux.HandleFunc("/healthz", handleHealthz)  // 存活检查
+	mux.HandleFunc("/readyz", handleReadyz)    // 就绪检查
+
+	// REST接口，自己校验token和统计指标
+	mux.HandleFunc(API_V1_PREFIX+"/", handleApiV1)
+
+	// 实时事件流，允许通过token参数认证
+	mux.HandleFunc("/events", instrumentHandler("/events", handleEvents))
 
 	staticDir = http.Dir(G_config.Webroot) // 静态文件目录  相对地址，相对于当前项目来说的！！！！
 	staticHandler = http.FileServer(staticDir)
@@ -227,7 +896,6 @@ func InitApiServer(err error) error {
 
 	// 启动TCP监听
 	if listener, err = net.Listen("tcp", ":"+strconv.Itoa(G_config.ApiPort)); err != nil { // 本机任意ip的端口都可以
-		fmt.Println(err)
 		return err
 	}
 
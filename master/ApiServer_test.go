@@ -0,0 +1,46 @@
+package master
+
+import (
+	"../common"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseStatsWindow(t *testing.T) {
+	var (
+		cases = []struct {
+			window   string
+			duration time.Duration
+			err      error
+		}{
+			{"", 24 * time.Hour, nil},
+			{"30m", 30 * time.Minute, nil},
+			{"1h", time.Hour, nil},
+			{"7d", 7 * 24 * time.Hour, nil},
+			{"xd", 0, common.ERR_INVALID_STATS_WINDOW},
+			{"7x", 0, common.ERR_INVALID_STATS_WINDOW},
+		}
+		i      int
+		now    int64
+		since  int64
+		expect int64
+		err    error
+	)
+	for i = range cases {
+		now = time.Now().UnixNano() / 1000 / 1000
+		since, err = parseStatsWindow(cases[i].window)
+		if !errors.Is(err, cases[i].err) {
+			t.Errorf("parseStatsWindow(%q) err = %v, want %v", cases[i].window, err, cases[i].err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		// 允许1秒的误差
+		expect = now - int64(cases[i].duration/time.Millisecond)
+		if since < expect-1000 || since > expect+1000 {
+			t.Errorf("parseStatsWindow(%q) = %d, want about %d", cases[i].window, since, expect)
+		}
+	}
+}
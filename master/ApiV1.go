@@ -0,0 +1,780 @@
+package master
+
+import (
+	"../common"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// REST接口 /api/v1
+// 和老接口并存：资源化的路径，JSON请求体，按语义返回http状态码，出错时返回带错误码的json
+// 接口定义见 webroot/openapi.yaml，通过 GET /api/v1/openapi.yaml 获取
+// 任务所在的命名空间通过查询参数namespace指定，不传为default
+
+const (
+	API_V1_PREFIX = "/api/v1"
+
+	// 请求体大小上限，清单和备份可能比较大
+	apiV1MaxBodySize = 10 << 20
+)
+
+// 带http状态码和错误码的错误
+type apiV1Error struct {
+	status  int
+	code    string
+	message string
+}
+
+func (apiErr *apiV1Error) Error() string {
+	return apiErr.message
+}
+
+func newApiV1Error(status int, code string, message string) error {
+	return &apiV1Error{status: status, code: code, message: message}
+}
+
+// 已知错误 -> http状态码和错误码
+var apiV1ErrorTable = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{common.ERR_JOB_NOT_FOUND, http.StatusNotFound, common.API_ERR_JOB_NOT_FOUND},
+	{common.ERR_WORKER_NOT_FOUND, http.StatusNotFound, common.API_ERR_WORKER_NOT_FOUND},
+	{common.ERR_JOB_NAME_REQUIRED, http.StatusBadRequest, common.API_ERR_JOB_NAME_REQUIRED},
+	{common.ERR_INVALID_JOB_MODE, http.StatusBadRequest, common.API_ERR_INVALID_JOB_MODE},
+	{common.ERR_NOTIFY_TARGET_NOT_FOUND, http.StatusBadRequest, common.API_ERR_NOTIFY_TARGET_NOT_FOUND},
+	{common.ERR_INVALID_STATS_WINDOW, http.StatusBadRequest, common.API_ERR_INVALID_STATS_WINDOW},
+	{common.ERR_UNSUPPORTED_MANIFEST_VERSION, http.StatusBadRequest, common.API_ERR_UNSUPPORTED_MANIFEST_VERSION},
+	{common.ERR_DUPLICATE_JOB_NAME, http.StatusBadRequest, common.API_ERR_DUPLICATE_JOB_NAME},
+	{common.ERR_UNSUPPORTED_ARCHIVE_VERSION, http.StatusBadRequest, common.API_ERR_UNSUPPORTED_ARCHIVE_VERSION},
+	{common.ERR_INVALID_IMPORT_MODE, http.StatusBadRequest, common.API_ERR_INVALID_IMPORT_MODE},
+	{common.ERR_INVALID_NAMESPACE, http.StatusBadRequest, common.API_ERR_INVALID_NAMESPACE},
+	{common.ERR_INVALID_JOB_NAME, http.StatusBadRequest, common.API_ERR_INVALID_JOB_NAME},
+	{common.ERR_UNAUTHORIZED, http.StatusUnauthorized, common.API_ERR_UNAUTHORIZED},
+	{common.ERR_FORBIDDEN, http.StatusForbidden, common.API_ERR_FORBIDDEN},
+	{common.ERR_JOB_QUOTA_EXCEEDED, http.StatusForbidden, common.API_ERR_QUOTA_EXCEEDED},
+	{common.ERR_INVALID_JOB_SORT, http.StatusBadRequest, common.API_ERR_INVALID_JOB_SORT},
+	{common.ERR_INVALID_LOG_SORT, http.StatusBadRequest, common.API_ERR_INVALID_LOG_SORT},
+	{common.ERR_LOG_KEYWORD_UNSCOPED, http.StatusBadRequest, common.API_ERR_LOG_KEYWORD_UNSCOPED},
+	{common.ERR_INVALID_LOG_RETENTION, http.StatusBadRequest, common.API_ERR_INVALID_LOG_RETENTION},
+	{common.ERR_INVALID_JOB_PARAM, http.StatusBadRequest, common.API_ERR_INVALID_JOB_PARAM},
+	{common.ERR_INVALID_COMMAND_TEMPLATE, http.StatusBadRequest, common.API_ERR_INVALID_COMMAND_TEMPLATE},
+	{common.ERR_TOO_MANY_CHANGES, http.StatusBadRequest, common.API_ERR_TOO_MANY_CHANGES},
+	{common.ERR_APPLY_CONFLICT, http.StatusConflict, common.API_ERR_APPLY_CONFLICT},
+	{common.ERR_JOB_IMPORT_CONFLICT, http.StatusConflict, common.API_ERR_JOB_CONFLICT},
+}
+
+// 路由处理函数，返回状态码和应答内容，data为nil时不返回body
+type apiV1Handler func(req *http.Request, params map[string]string) (status int, data interface{}, err error)
+
+type apiV1Route struct {
+	method   string
+	segments []string // 路径按/拆分，{name}表示参数
+	pattern  string   // 原始路径，用于监控指标
+	handler  apiV1Handler
+}
+
+var apiV1Routes []*apiV1Route
+
+func addApiV1Route(method string, pattern string, handler apiV1Handler) {
+	apiV1Routes = append(apiV1Routes, &apiV1Route{
+		method:   method,
+		segments: strings.Split(strings.Trim(pattern, "/"), "/"),
+		pattern:  API_V1_PREFIX + pattern,
+		handler:  handler,
+	})
+}
+
+func init() {
+	addApiV1Route("GET", "/jobs", apiV1ListJobs)
+	addApiV1Route("GET", "/jobs/{name}", apiV1GetJob)
+	addApiV1Route("PUT", "/jobs/{name}", apiV1PutJob)
+	addApiV1Route("DELETE", "/jobs/{name}", apiV1DeleteJob)
+	addApiV1Route("POST", "/jobs/{name}/kill", apiV1KillJob)
+	addApiV1Route("POST", "/jobs/{name}/run", apiV1RunJob)
+	addApiV1Route("GET", "/jobs/{name}/logs", apiV1ListJobLogs)
+	addApiV1Route("DELETE", "/jobs/{name}/logs", apiV1PurgeJobLogs)
+	addApiV1Route("GET", "/jobs/{name}/logs/stats", apiV1JobLogStats)
+	addApiV1Route("GET", "/jobs/{name}/stats", apiV1JobStats)
+	addApiV1Route("GET", "/jobs/{name}/broadcasts", apiV1JobBroadcasts)
+	addApiV1Route("GET", "/logs", apiV1ListLogs)
+	addApiV1Route("GET", "/stats/summary", apiV1StatsSummary)
+	addApiV1Route("GET", "/workers", apiV1ListWorkers)
+	addApiV1Route("GET", "/workers/{id}", apiV1GetWorker)
+	addApiV1Route("POST", "/workers/{id}/cordon", apiV1CordonWorker(common.WORKER_FLAG_CORDON))
+	addApiV1Route("POST", "/workers/{id}/drain", apiV1CordonWorker(common.WORKER_FLAG_DRAIN))
+	addApiV1Route("POST", "/workers/{id}/uncordon", apiV1UncordonWorker)
+	addApiV1Route("POST", "/apply", apiV1Apply)
+	addApiV1Route("GET", "/export", apiV1Export)
+	addApiV1Route("POST", "/import", apiV1Import)
+	addApiV1Route("POST", "/import/crontab", apiV1ImportCrontab)
+}
+
+// 匹配路径，返回路径参数
+func (route *apiV1Route) match(segments []string) (params map[string]string, ok bool) {
+	var (
+		i       int
+		segment string
+	)
+	if len(segments) != len(route.segments) {
+		return
+	}
+	params = make(map[string]string)
+	for i, segment = range route.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[strings.Trim(segment, "{}")] = segments[i]
+			continue
+		}
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// /api/v1/ 下所有请求的入口
+func handleApiV1(resp http.ResponseWriter, req *http.Request) {
+	var (
+		path     string
+		segments []string
+		route    *apiV1Route
+		matched  *apiV1Route
+		params   map[string]string
+		ok       bool
+		allowed  []string
+		caller   *ApiTokenConfig
+	)
+	path = strings.Trim(strings.TrimPrefix(req.URL.Path, API_V1_PREFIX), "/")
+	// 接口定义不需要token
+	if path == "openapi.yaml" {
+		resp.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		http.ServeFile(resp, req, filepath.Join(G_config.Webroot, "openapi.yaml"))
+		return
+	}
+	if caller = authenticate(req.Header.Get("Authorization")); caller == nil {
+		writeApiV1Error(resp, req, common.ERR_UNAUTHORIZED)
+		return
+	}
+	req = req.WithContext(withPrincipal(req.Context(), caller))
+
+	segments = strings.Split(path, "/")
+	allowed = make([]string, 0)
+	for _, route = range apiV1Routes {
+		if params, ok = route.match(segments); !ok {
+			continue
+		}
+		if route.method == req.Method {
+			matched = route
+			break
+		}
+		allowed = append(allowed, route.method)
+	}
+	if matched == nil {
+		if len(allowed) > 0 {
+			resp.Header().Set("Allow", strings.Join(allowed, ", "))
+			writeApiV1Error(resp, req, newApiV1Error(http.StatusMethodNotAllowed, common.API_ERR_METHOD_NOT_ALLOWED, "不支持的请求方法 "+req.Method))
+		} else {
+			writeApiV1Error(resp, req, newApiV1Error(http.StatusNotFound, common.API_ERR_NOT_FOUND, "接口不存在"))
+		}
+		return
+	}
+
+	// 按路由统计请求次数和耗时
+	instrumentHandler(matched.pattern, func(resp http.ResponseWriter, req *http.Request) {
+		var (
+			status int
+			data   interface{}
+			err    error
+		)
+		req.Body = http.MaxBytesReader(resp, req.Body, apiV1MaxBodySize)
+		if status, data, err = matched.handler(req, params); err != nil {
+			writeApiV1Error(resp, req, err)
+			return
+		}
+		writeApiV1(resp, status, data)
+	})(resp, req)
+}
+
+// 正常应答
+func writeApiV1(resp http.ResponseWriter, status int, data interface{}) {
+	var (
+		bytes []byte
+		err   error
+	)
+	if data == nil {
+		resp.WriteHeader(status)
+		return
+	}
+	if bytes, err = json.Marshal(data); err != nil {
+		writeApiV1Error(resp, nil, err)
+		return
+	}
+	resp.Header().Set("Content-Type", "application/json; charset=utf-8")
+	resp.WriteHeader(status)
+	resp.Write(bytes)
+}
+
+// 错误应答，未知错误返回500
+func writeApiV1Error(resp http.ResponseWriter, req *http.Request, err error) {
+	var (
+		apiErr *apiV1Error
+		i      int
+		bytes  []byte
+	)
+	if !errors.As(err, &apiErr) {
+		apiErr = &apiV1Error{status: http.StatusInternalServerError, code: common.API_ERR_INTERNAL, message: err.Error()}
+		for i = range apiV1ErrorTable {
+			if errors.Is(err, apiV1ErrorTable[i].err) {
+				apiErr.status = apiV1ErrorTable[i].status
+				apiErr.code = apiV1ErrorTable[i].code
+				break
+			}
+		}
+	}
+	if apiErr.status >= http.StatusInternalServerError && req != nil {
+		apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	}
+	bytes, _ = json.Marshal(&common.ApiErrorResponse{Error: &common.ApiError{Code: apiErr.code, Message: apiErr.message}})
+	resp.Header().Set("Content-Type", "application/json; charset=utf-8")
+	resp.WriteHeader(apiErr.status)
+	resp.Write(bytes)
+}
+
+// 解析json请求体
+func decodeApiV1Body(req *http.Request, value interface{}) (err error) {
+	if err = json.NewDecoder(req.Body).Decode(value); err != nil {
+		return newApiV1Error(http.StatusBadRequest, common.API_ERR_INVALID_BODY, "请求体格式错误: "+err.Error())
+	}
+	return
+}
+
+// 读取整个请求体
+func readApiV1Body(req *http.Request) (body []byte, err error) {
+	if body, err = ioutil.ReadAll(req.Body); err != nil {
+		return nil, newApiV1Error(http.StatusBadRequest, common.API_ERR_INVALID_BODY, "读取请求体失败: "+err.Error())
+	}
+	return
+}
+
+// 整数查询参数，不传使用默认值，格式错误返回400
+func queryInt64(req *http.Request, key string, defaultValue int64) (value int64, err error) {
+	var (
+		param string
+	)
+	if param = req.URL.Query().Get(key); param == "" {
+		return defaultValue, nil
+	}
+	if value, err = strconv.ParseInt(param, 10, 64); err != nil || value < 0 {
+		return 0, newApiV1Error(http.StatusBadRequest, common.API_ERR_INVALID_PARAMETER, "参数格式错误: "+key)
+	}
+	return
+}
+
+// 布尔查询参数
+func queryBool(req *http.Request, key string) (value bool, err error) {
+	var (
+		param string
+	)
+	if param = req.URL.Query().Get(key); param == "" {
+		return false, nil
+	}
+	if value, err = strconv.ParseBool(param); err != nil {
+		return false, newApiV1Error(http.StatusBadRequest, common.API_ERR_INVALID_PARAMETER, "参数格式错误: "+key)
+	}
+	return
+}
+
+// 路径中的任务名称和查询参数namespace组成任务全名，同时校验权限
+func apiV1JobName(req *http.Request, params map[string]string, role string) (fullName string, err error) {
+	return authorizeJob(req.Context(), req.URL.Query().Get("namespace"), params["name"], role)
+}
+
+// GET /api/v1/jobs?namespace=default&tag=db&owner=alice&team=infra&keyword=backup&sort=-updateTime&skip=0&limit=20
+// 不传namespace返回有查看权限的所有命名空间，不传limit返回全部
+func apiV1ListJobs(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		query *common.JobListQuery
+		skip  int64
+		limit int64
+		page  *common.JobListPage
+	)
+	query = parseJobListQuery(req.URL.Query())
+	if skip, err = queryInt64(req, "skip", 0); err != nil {
+		return
+	}
+	if limit, err = queryInt64(req, "limit", 0); err != nil {
+		return
+	}
+	query.Skip, query.Limit = int(skip), int(limit)
+	if page, err = listJobPage(req.Context(), req.URL.Query().Get("namespace"), query); err != nil {
+		return
+	}
+	return http.StatusOK, page, nil
+}
+
+// GET /api/v1/jobs/{name}
+func apiV1GetJob(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		name string
+		job  *common.Job
+	)
+	if name, err = apiV1JobName(req, params, common.API_ROLE_VIEWER); err != nil {
+		return
+	}
+	if job, err = G_jobMgr.GetJob(name); err != nil {
+		return
+	}
+	return http.StatusOK, &common.JobListItem{Job: job, Watchdog: G_jobWatchdog.State(job.FullName())}, nil
+}
+
+// PUT /api/v1/jobs/{name}，新建返回201，修改返回200
+func apiV1PutJob(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		job    *common.Job
+		oldJob *common.Job
+	)
+	job = &common.Job{}
+	if err = decodeApiV1Body(req, job); err != nil {
+		return
+	}
+	// 以路径中的名称为准，请求体中可以不带
+	if job.Name != "" && job.Name != params["name"] {
+		err = newApiV1Error(http.StatusBadRequest, common.API_ERR_NAME_MISMATCH, "请求体中的任务名称和路径不一致")
+		return
+	}
+	job.Name = params["name"]
+	// 命名空间以查询参数为准，请求体中可以不带
+	if job.Namespace != "" && job.Namespace != common.NormalizeNamespace(req.URL.Query().Get("namespace")) {
+		err = newApiV1Error(http.StatusBadRequest, common.API_ERR_NAME_MISMATCH, "请求体中的命名空间和查询参数不一致")
+		return
+	}
+	job.Namespace = req.URL.Query().Get("namespace")
+	if _, err = apiV1JobName(req, params, common.API_ROLE_EDITOR); err != nil {
+		return
+	}
+	if oldJob, err = G_jobMgr.SaveJob(job); err != nil {
+		return
+	}
+	if oldJob == nil {
+		return http.StatusCreated, job, nil
+	}
+	return http.StatusOK, job, nil
+}
+
+// DELETE /api/v1/jobs/{name}?keepLogs=true，默认同时删除任务日志
+func apiV1DeleteJob(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		name     string
+		keepLogs bool
+		oldJob   *common.Job
+	)
+	if keepLogs, err = queryBool(req, "keepLogs"); err != nil {
+		return
+	}
+	if name, err = apiV1JobName(req, params, common.API_ROLE_EDITOR); err != nil {
+		return
+	}
+	if oldJob, err = G_jobMgr.DeleteJob(name); err != nil {
+		return
+	}
+	if oldJob == nil {
+		err = common.ERR_JOB_NOT_FOUND
+		return
+	}
+	if !keepLogs {
+		if _, err = G_logMgr.PurgeLog(name); err != nil {
+			return
+		}
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+// POST /api/v1/jobs/{name}/kill，异步执行，返回202
+func apiV1KillJob(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		name string
+	)
+	if name, err = apiV1JobName(req, params, common.API_ROLE_EDITOR); err != nil {
+		return
+	}
+	if _, err = G_jobMgr.GetJob(name); err != nil {
+		return
+	}
+	if err = G_jobMgr.KillJob(name); err != nil {
+		return
+	}
+	return http.StatusAccepted, nil, nil
+}
+
+// POST /api/v1/jobs/{name}/run，异步执行，返回202
+// 请求体可选 {"params": {"db": "orders"}}，覆盖任务参数的默认值
+func apiV1RunJob(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		name       string
+		body       []byte
+		runRequest *common.JobRunRequest
+	)
+	if name, err = apiV1JobName(req, params, common.API_ROLE_EDITOR); err != nil {
+		return
+	}
+	if body, err = readApiV1Body(req); err != nil {
+		return
+	}
+	runRequest = &common.JobRunRequest{}
+	if strings.TrimSpace(string(body)) != "" {
+		if err = json.Unmarshal(body, runRequest); err != nil {
+			err = newApiV1Error(http.StatusBadRequest, common.API_ERR_INVALID_BODY, "请求体格式错误: "+err.Error())
+			return
+		}
+	}
+	if err = G_jobMgr.RunJob(name, runRequest.Params); err != nil {
+		return
+	}
+	return http.StatusAccepted, nil, nil
+}
+
+// 从查询参数解析日志过滤条件和分页，name为空时按命名空间过滤，同时校验权限
+func parseApiV1LogQuery(req *http.Request, name string) (filter *common.JobLogFilter, skip int64, limit int64, err error) {
+	var (
+		query url.Values
+	)
+	query = req.URL.Query()
+	filter = &common.JobLogFilter{
+		Status:  query.Get("status"),
+		Worker:  query.Get("worker"),
+		Keyword: query.Get("keyword"),
+	}
+	if name != "" {
+		if filter.JobName, err = authorizeJob(req.Context(), query.Get("namespace"), name, common.API_ROLE_VIEWER); err != nil {
+			return
+		}
+	} else {
+		if err = authorizeNamespaceFilter(req.Context(), query.Get("namespace")); err != nil {
+			return
+		}
+		filter.Namespace = query.Get("namespace")
+	}
+	if filter.StartFrom, err = queryInt64(req, "startTime", 0); err != nil {
+		return
+	}
+	if filter.StartTo, err = queryInt64(req, "endTime", 0); err != nil {
+		return
+	}
+	if filter.EndFrom, err = queryInt64(req, "endFrom", 0); err != nil {
+		return
+	}
+	if skip, err = queryInt64(req, "skip", 0); err != nil {
+		return
+	}
+	limit, err = queryInt64(req, "limit", 20)
+	return
+}
+
+// GET /api/v1/jobs/{name}/logs
+func apiV1ListJobLogs(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		filter *common.JobLogFilter
+		skip   int64
+		limit  int64
+	)
+	if filter, skip, limit, err = parseApiV1LogQuery(req, params["name"]); err != nil {
+		return
+	}
+	if data, err = G_logMgr.ListLog(filter, req.URL.Query().Get("sort"), skip, limit); err != nil {
+		return
+	}
+	return http.StatusOK, data, nil
+}
+
+// GET /api/v1/logs?name=job10&namespace=default，不传name查询整个命名空间，namespace也不传查询所有命名空间
+func apiV1ListLogs(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		filter *common.JobLogFilter
+		skip   int64
+		limit  int64
+	)
+	if filter, skip, limit, err = parseApiV1LogQuery(req, req.URL.Query().Get("name")); err != nil {
+		return
+	}
+	if data, err = G_logMgr.ListLog(filter, req.URL.Query().Get("sort"), skip, limit); err != nil {
+		return
+	}
+	return http.StatusOK, data, nil
+}
+
+// DELETE /api/v1/jobs/{name}/logs
+func apiV1PurgeJobLogs(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		name    string
+		deleted int64
+	)
+	if name, err = apiV1JobName(req, params, common.API_ROLE_EDITOR); err != nil {
+		return
+	}
+	if deleted, err = G_logMgr.PurgeLog(name); err != nil {
+		return
+	}
+	return http.StatusOK, map[string]int64{"deleted": deleted}, nil
+}
+
+// GET /api/v1/jobs/{name}/logs/stats
+func apiV1JobLogStats(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		name string
+		job  *common.Job
+	)
+	if name, err = apiV1JobName(req, params, common.API_ROLE_VIEWER); err != nil {
+		return
+	}
+	if job, err = G_jobMgr.GetJob(name); err != nil {
+		return
+	}
+	if data, err = G_logMgr.JobLogStats(name, G_logPruner.EffectiveRetention(job)); err != nil {
+		return
+	}
+	return http.StatusOK, data, nil
+}
+
+// GET /api/v1/jobs/{name}/stats?window=24h
+func apiV1JobStats(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		name  string
+		since int64
+	)
+	if name, err = apiV1JobName(req, params, common.API_ROLE_VIEWER); err != nil {
+		return
+	}
+	if since, err = parseStatsWindow(req.URL.Query().Get("window")); err != nil {
+		return
+	}
+	if data, err = G_logMgr.JobStats(name, since); err != nil {
+		return
+	}
+	return http.StatusOK, data, nil
+}
+
+// GET /api/v1/jobs/{name}/broadcasts?skip=0&limit=20
+func apiV1JobBroadcasts(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		name    string
+		skip    int64
+		limit   int64
+		tickArr []*common.BroadcastTick
+	)
+	if name, err = apiV1JobName(req, params, common.API_ROLE_VIEWER); err != nil {
+		return
+	}
+	if skip, err = queryInt64(req, "skip", 0); err != nil {
+		return
+	}
+	if limit, err = queryInt64(req, "limit", 20); err != nil {
+		return
+	}
+	if tickArr, err = G_logMgr.ListBroadcastTicks(name, skip, limit); err != nil {
+		return
+	}
+	return http.StatusOK, map[string]interface{}{"ticks": tickArr}, nil
+}
+
+// GET /api/v1/stats/summary?window=7d&top=10&namespace=default，只统计有查看权限的任务
+func apiV1StatsSummary(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		since    int64
+		top      int64
+		jobList  []*common.Job
+		job      *common.Job
+		nameArr  []string
+		statsArr []*common.JobStats
+	)
+	if since, err = parseStatsWindow(req.URL.Query().Get("window")); err != nil {
+		return
+	}
+	if top, err = queryInt64(req, "top", 10); err != nil {
+		return
+	}
+	if err = checkNamespaceParam(req.URL.Query().Get("namespace")); err != nil {
+		return
+	}
+	if jobList, err = G_jobMgr.ListJob(req.URL.Query().Get("namespace")); err != nil {
+		return
+	}
+	for _, job = range filterReadableJobs(req.Context(), jobList) {
+		nameArr = append(nameArr, job.FullName())
+	}
+	if statsArr, err = G_logMgr.WorstJobStats(req.URL.Query().Get("namespace"), nameArr, since, int(top)); err != nil {
+		return
+	}
+	return http.StatusOK, map[string]interface{}{"jobs": statsArr}, nil
+}
+
+// GET /api/v1/workers
+func apiV1ListWorkers(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		workerArr []*common.WorkerInfo
+	)
+	if workerArr, err = G_workerMgr.ListWorkers(); err != nil {
+		return
+	}
+	return http.StatusOK, map[string]interface{}{"workers": workerArr}, nil
+}
+
+// GET /api/v1/workers/{id}
+func apiV1GetWorker(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	if data, err = G_workerMgr.GetWorker(params["id"]); err != nil {
+		return
+	}
+	return http.StatusOK, data, nil
+}
+
+// POST /api/v1/workers/{id}/cordon
+// POST /api/v1/workers/{id}/drain
+func apiV1CordonWorker(flag string) apiV1Handler {
+	return func(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+		if err = authorize(req.Context(), "", common.API_ROLE_ADMIN); err != nil {
+			return
+		}
+		if data, err = G_workerMgr.CordonWorker(params["id"], flag); err != nil {
+			return
+		}
+		return http.StatusOK, data, nil
+	}
+}
+
+// POST /api/v1/workers/{id}/uncordon
+func apiV1UncordonWorker(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	if err = authorize(req.Context(), "", common.API_ROLE_ADMIN); err != nil {
+		return
+	}
+	if err = G_workerMgr.UncordonWorker(params["id"]); err != nil {
+		return
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+// POST /api/v1/apply?prune=true&dryRun=true，请求体为YAML或JSON格式的清单
+func apiV1Apply(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		body     []byte
+		manifest *common.JobManifest
+		prune    bool
+		dryRun   bool
+	)
+	if prune, err = queryBool(req, "prune"); err != nil {
+		return
+	}
+	if dryRun, err = queryBool(req, "dryRun"); err != nil {
+		return
+	}
+	if body, err = readApiV1Body(req); err != nil {
+		return
+	}
+	if manifest, err = common.ParseManifest(body); err != nil {
+		if !errors.Is(err, common.ERR_UNSUPPORTED_MANIFEST_VERSION) {
+			err = newApiV1Error(http.StatusBadRequest, common.API_ERR_INVALID_BODY, "清单格式错误: "+err.Error())
+		}
+		return
+	}
+	if err = authorizeManifest(req.Context(), manifest); err != nil {
+		return
+	}
+	if data, err = G_jobMgr.ApplyManifest(manifest, prune, dryRun); err != nil {
+		return
+	}
+	return http.StatusOK, data, nil
+}
+
+// GET /api/v1/export?namespace=default，只导出有查看权限的任务
+func apiV1Export(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		archive *common.JobArchive
+	)
+	if err = checkNamespaceParam(req.URL.Query().Get("namespace")); err != nil {
+		return
+	}
+	if archive, err = G_jobMgr.ExportJobs(req.URL.Query().Get("namespace")); err != nil {
+		return
+	}
+	filterReadableArchive(req.Context(), archive)
+	return http.StatusOK, archive, nil
+}
+
+// POST /api/v1/import?mode=skip|overwrite|fail，请求体为备份文件
+func apiV1Import(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		body    []byte
+		archive *common.JobArchive
+		mode    string
+	)
+	if body, err = readApiV1Body(req); err != nil {
+		return
+	}
+	if archive, err = common.UnpackJobArchive(body); err != nil {
+		if !errors.Is(err, common.ERR_UNSUPPORTED_ARCHIVE_VERSION) {
+			err = newApiV1Error(http.StatusBadRequest, common.API_ERR_INVALID_BODY, "备份格式错误: "+err.Error())
+		}
+		return
+	}
+	if err = authorizeArchive(req.Context(), archive); err != nil {
+		return
+	}
+	if mode = req.URL.Query().Get("mode"); mode == "" {
+		mode = common.JOB_IMPORT_MODE_FAIL
+	}
+	if data, err = G_jobMgr.ImportJobs(archive, mode); err != nil {
+		return
+	}
+	return http.StatusOK, data, nil
+}
+
+// POST /api/v1/import/crontab?system=true&prefix=sys-&namespace=default&apply=true，请求体为crontab文件
+func apiV1ImportCrontab(req *http.Request, params map[string]string) (status int, data interface{}, err error) {
+	var (
+		body      []byte
+		system    bool
+		apply     bool
+		namespace string
+		result    *common.CrontabImportResult
+	)
+	namespace = common.NormalizeNamespace(req.URL.Query().Get("namespace"))
+	if err = common.ValidateNamespace(namespace); err != nil {
+		return
+	}
+	if system, err = queryBool(req, "system"); err != nil {
+		return
+	}
+	if apply, err = queryBool(req, "apply"); err != nil {
+		return
+	}
+	if body, err = readApiV1Body(req); err != nil {
+		return
+	}
+	result = common.ParseCrontab(string(body), &common.CrontabImportOptions{
+		System:     system,
+		NamePrefix: req.URL.Query().Get("prefix"),
+		Namespace:  namespace,
+	})
+	if apply {
+		if err = authorize(req.Context(), namespace, common.API_ROLE_EDITOR); err != nil {
+			return
+		}
+		// 只新建，不覆盖已有的同名任务
+		if result.Applied, err = G_jobMgr.CreateJobs(&common.JobManifest{
+			Version: common.JOB_MANIFEST_VERSION,
+			Jobs:    result.Jobs,
+		}); err != nil {
+			return
+		}
+		result.SkipJobs(result.Applied.Existing, "任务已存在")
+	}
+	return http.StatusOK, result, nil
+}
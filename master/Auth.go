@@ -0,0 +1,259 @@
+package master
+
+import (
+	"../common"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// 接口权限：每个token有一个角色和可以访问的命名空间
+// viewer 只读，editor 另外可以修改任务、手动执行和强杀，admin 另外可以管理worker（需要所有命名空间）
+// 兼容老配置：apiToken 等同于所有命名空间的admin，没有配置任何token时不校验，所有请求都是admin
+
+const (
+	// 所有命名空间
+	allNamespaces = "*"
+)
+
+var (
+	// 角色 -> 级别，高级别包含低级别的权限
+	roleLevels = map[string]int{
+		common.API_ROLE_VIEWER: 1,
+		common.API_ROLE_EDITOR: 2,
+		common.API_ROLE_ADMIN:  3,
+	}
+
+	// 没有配置token时的调用方
+	anonymousAdmin = &ApiTokenConfig{Name: "anonymous", Role: common.API_ROLE_ADMIN, Namespaces: []string{allNamespaces}}
+
+	// 老配置apiToken对应的调用方
+	legacyAdmin = &ApiTokenConfig{Name: "apiToken", Role: common.API_ROLE_ADMIN, Namespaces: []string{allNamespaces}}
+)
+
+type principalKey struct{}
+
+// 校验 Bearer <token> 格式的认证信息，返回调用方，认证失败返回nil，http和gRPC共用
+func authenticate(authorization string) *ApiTokenConfig {
+	var (
+		token       string
+		tokenConfig *ApiTokenConfig
+	)
+	if G_config.ApiToken == "" && len(G_config.ApiTokens) == 0 {
+		return anonymousAdmin
+	}
+	token = strings.TrimPrefix(authorization, "Bearer ")
+	if token == "" {
+		return nil
+	}
+	if G_config.ApiToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(G_config.ApiToken)) == 1 {
+		return legacyAdmin
+	}
+	for _, tokenConfig = range G_config.ApiTokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(tokenConfig.Token)) == 1 {
+			return tokenConfig
+		}
+	}
+	return nil
+}
+
+// 是否可以用该角色访问命名空间，namespace为空表示整个集群，需要所有命名空间的权限
+func (tokenConfig *ApiTokenConfig) Allow(namespace string, role string) bool {
+	var (
+		allowed string
+	)
+	if roleLevels[tokenConfig.Role] < roleLevels[role] {
+		return false
+	}
+	for _, allowed = range tokenConfig.Namespaces {
+		if allowed == allNamespaces || (namespace != "" && allowed == namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// 是否可以查看该命名空间
+func (tokenConfig *ApiTokenConfig) CanRead(namespace string) bool {
+	return tokenConfig.Allow(namespace, common.API_ROLE_VIEWER)
+}
+
+// 把调用方放入请求上下文
+func withPrincipal(ctx context.Context, tokenConfig *ApiTokenConfig) context.Context {
+	return context.WithValue(ctx, principalKey{}, tokenConfig)
+}
+
+// 请求上下文中的调用方，没有经过认证的上下文没有任何权限
+func principalFrom(ctx context.Context) *ApiTokenConfig {
+	var (
+		tokenConfig *ApiTokenConfig
+		ok          bool
+	)
+	if tokenConfig, ok = ctx.Value(principalKey{}).(*ApiTokenConfig); !ok {
+		return &ApiTokenConfig{}
+	}
+	return tokenConfig
+}
+
+// 校验调用方对命名空间的权限
+func authorize(ctx context.Context, namespace string, role string) (err error) {
+	if !principalFrom(ctx).Allow(namespace, role) {
+		if namespace == "" {
+			namespace = "所有命名空间"
+		}
+		err = fmt.Errorf("%w: 需要 %s 的 %s 权限", common.ERR_FORBIDDEN, namespace, role)
+	}
+	return
+}
+
+// 校验命名空间和权限，返回任务全名，namespace为空表示默认命名空间
+func authorizeJob(ctx context.Context, namespace string, name string, role string) (fullName string, err error) {
+	namespace = common.NormalizeNamespace(namespace)
+	if err = common.ValidateNamespace(namespace); err != nil {
+		return
+	}
+	if err = authorize(ctx, namespace, role); err != nil {
+		return
+	}
+	return common.JobFullName(namespace, name), nil
+}
+
+// 列表接口的命名空间参数，为空表示所有命名空间
+func checkNamespaceParam(namespace string) (err error) {
+	if namespace != "" {
+		err = common.ValidateNamespace(namespace)
+	}
+	return
+}
+
+// 日志查询的命名空间：指定了命名空间时校验权限，没有指定时需要所有命名空间的查看权限
+func authorizeNamespaceFilter(ctx context.Context, namespace string) (err error) {
+	if err = checkNamespaceParam(namespace); err != nil {
+		return
+	}
+	return authorize(ctx, namespace, common.API_ROLE_VIEWER)
+}
+
+// 按查看权限过滤任务列表
+func filterReadableJobs(ctx context.Context, jobList []*common.Job) (readable []*common.Job) {
+	var (
+		tokenConfig *ApiTokenConfig
+		job         *common.Job
+	)
+	tokenConfig = principalFrom(ctx)
+	readable = make([]*common.Job, 0, len(jobList))
+	for _, job = range jobList {
+		if tokenConfig.CanRead(common.NormalizeNamespace(job.Namespace)) {
+			readable = append(readable, job)
+		}
+	}
+	return
+}
+
+// 只保留有查看权限的任务
+func filterReadableArchive(ctx context.Context, archive *common.JobArchive) {
+	var (
+		tokenConfig *ApiTokenConfig
+		entries     []*common.JobArchiveEntry
+		entry       *common.JobArchiveEntry
+	)
+	tokenConfig = principalFrom(ctx)
+	entries = make([]*common.JobArchiveEntry, 0, len(archive.Jobs))
+	for _, entry = range archive.Jobs {
+		if tokenConfig.CanRead(common.NormalizeNamespace(entry.Job.Namespace)) {
+			entries = append(entries, entry)
+		}
+	}
+	archive.Jobs = entries
+}
+
+// 校验备份中每个任务的命名空间都有修改权限
+func authorizeArchive(ctx context.Context, archive *common.JobArchive) (err error) {
+	var (
+		entry *common.JobArchiveEntry
+	)
+	for _, entry = range archive.Jobs {
+		if entry.Job == nil {
+			continue
+		}
+		if err = authorize(ctx, common.NormalizeNamespace(entry.Job.Namespace), common.API_ROLE_EDITOR); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// 校验清单中每个任务的命名空间都有修改权限，prune时删除的任务也在这些命名空间中
+func authorizeManifest(ctx context.Context, manifest *common.JobManifest) (err error) {
+	var (
+		job       *common.Job
+		namespace string
+	)
+	if manifest.Namespace != "" {
+		if err = authorize(ctx, manifest.Namespace, common.API_ROLE_EDITOR); err != nil {
+			return
+		}
+	}
+	for _, job = range manifest.Jobs {
+		if namespace = job.Namespace; namespace == "" {
+			namespace = manifest.Namespace
+		}
+		if err = authorize(ctx, common.NormalizeNamespace(namespace), common.API_ROLE_EDITOR); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// 校验token和命名空间配置
+func (conf *Config) validateAccess() (err error) {
+	var (
+		tokenConfig     *ApiTokenConfig
+		namespace       string
+		namespaceConfig *NamespaceConfig
+		ok              bool
+	)
+	for _, tokenConfig = range conf.ApiTokens {
+		if tokenConfig.Token == "" {
+			return fmt.Errorf("apiTokens: %s 的token为空", tokenConfig.Name)
+		}
+		if _, ok = roleLevels[tokenConfig.Role]; !ok {
+			return fmt.Errorf("apiTokens: %s 的角色 %s 不存在", tokenConfig.Name, tokenConfig.Role)
+		}
+		for _, namespace = range tokenConfig.Namespaces {
+			if namespace == allNamespaces {
+				continue
+			}
+			if err = common.ValidateNamespace(namespace); err != nil {
+				return fmt.Errorf("apiTokens: %s: %w", tokenConfig.Name, err)
+			}
+		}
+	}
+	for _, namespaceConfig = range conf.Namespaces {
+		if err = common.ValidateNamespace(namespaceConfig.Name); err != nil {
+			return fmt.Errorf("namespaces: %s: %w", namespaceConfig.Name, err)
+		}
+	}
+	return
+}
+
+// 校验token，失败返回401，成功后把调用方放入请求上下文
+func requireToken(handler http.HandlerFunc) http.HandlerFunc {
+	return func(resp http.ResponseWriter, req *http.Request) {
+		var (
+			tokenConfig *ApiTokenConfig
+			bytes       []byte
+			err         error
+		)
+		if tokenConfig = authenticate(req.Header.Get("Authorization")); tokenConfig == nil {
+			resp.WriteHeader(http.StatusUnauthorized)
+			if bytes, err = common.BuildResponse(-1, common.ERR_UNAUTHORIZED.Error(), nil); err == nil {
+				resp.Write(bytes)
+			}
+			return
+		}
+		handler(resp, req.WithContext(withPrincipal(req.Context(), tokenConfig)))
+	}
+}
@@ -1,6 +1,9 @@
 package master
 
 import (
+	"../common"
+	"../logger"
+	"../logstore"
 	"encoding/json"
 	"io/ioutil"
 )
@@ -17,9 +20,122 @@ type Config struct {
 	ApiWriteTimeout       int      `json:"apiWriteTimeout"`
 	EtcdEndPoints         []string `json:"etcdEndPoints"`
 	EtcdDialTimeout       int      `json:"etcdDialTimeout"`
+	EtcdMaxTxnOps         int      `json:"etcdMaxTxnOps"` // 单个事务最多的操作数，与etcd的--max-txn-ops一致
 	Webroot               string   `json:"webroot"`
 	MongodbUri            string   `json:"mongodbUri"`
 	MongodbConnectTimeout int      `json:"mongodbConnectTimeout"`
+	LogStore              string   `json:"logStore"` // 日志存储类型 mongodb / sqlite / file
+	MongodbDatabase       string   `json:"mongodbDatabase"`
+	MongodbCollection     string   `json:"mongodbCollection"`
+	LogStorePath          string   `json:"logStorePath"`         // sqlite和file存储的文件路径
+	LogRetentionMaxAge    int64    `json:"logRetentionMaxAge"`   // 日志最长保留时间，秒，0表示不限制
+	LogRetentionMaxCount  int64    `json:"logRetentionMaxCount"` // 每个任务最多保留的日志条数，0表示不限制
+	LogPruneInterval      int      `json:"logPruneInterval"`     // 清理过期日志的间隔，毫秒
+	LoggerLevel           string   `json:"loggerLevel"`          // 进程日志级别 debug / info / warn / error
+	LoggerFormat          string   `json:"loggerFormat"`         // 进程日志格式 logfmt / json
+	LoggerFile            string   `json:"loggerFile"`           // 进程日志文件，为空则输出到标准输出
+	LoggerMaxSize         int      `json:"loggerMaxSize"`        // 单个日志文件最大大小，MB，超过后切割
+	LoggerMaxBackups      int      `json:"loggerMaxBackups"`     // 最多保留的旧日志文件个数
+	LoggerMaxAge          int      `json:"loggerMaxAge"`         // 旧日志文件最多保留的天数
+
+	// 任务失败通知
+	NotifyTargets []*NotifyTargetConfig `json:"notifyTargets"` // 通知渠道
+	NotifyTimeout int                   `json:"notifyTimeout"` // 发送一条通知的超时时间，毫秒
+
+	WatchdogInterval int `json:"watchdogInterval"` // 检查任务成功心跳的间隔，毫秒
+
+	ElectionTTL int `json:"electionTtl"` // 选主会话的租约，秒，leader和etcd断开超过这个时间后由其他master接替发送通知
+
+	ShutdownTimeout int `json:"shutdownTimeout"` // 退出时等待正在处理的请求结束的最长时间，毫秒
+
+	ApiToken string `json:"apiToken"` // 接口访问token，等同于所有命名空间的admin，为空且没有配置apiTokens则不校验
+
+	ApiTokens  []*ApiTokenConfig  `json:"apiTokens"`  // 按命名空间授权的接口访问token
+	Namespaces []*NamespaceConfig `json:"namespaces"` // 命名空间配额，没有配置的命名空间不限制
+
+	GrpcPort int `json:"grpcPort"` // gRPC服务端口，0表示不启动
+}
+
+// 通知渠道配置，任务的通知规则按name引用
+type NotifyTargetConfig struct {
+	Name         string            `json:"name"`
+	Type         string            `json:"type"`     // webhook / email / slack
+	Url          string            `json:"url"`      // webhook和slack的地址
+	Headers      map[string]string `json:"headers"`  // webhook附加的请求头
+	Template     string            `json:"template"` // webhook的body模板(text/template)，为空则发送通知本身的json
+	SmtpAddr     string            `json:"smtpAddr"` // 邮件服务器 host:port
+	SmtpUser     string            `json:"smtpUser"` // 为空则不认证
+	SmtpPassword string            `json:"smtpPassword"`
+	From         string            `json:"from"` // 发件人
+	To           []string          `json:"to"`   // 收件人
+}
+
+// 接口访问token配置
+type ApiTokenConfig struct {
+	Name       string   `json:"name"` // 调用方名称，只用于日志
+	Token      string   `json:"token"`
+	Role       string   `json:"role"`       // viewer / editor / admin
+	Namespaces []string `json:"namespaces"` // 可以访问的命名空间，*表示所有命名空间
+}
+
+// 命名空间配置
+type NamespaceConfig struct {
+	Name              string `json:"name"`
+	MaxJobs           int    `json:"maxJobs"`           // 最多任务数，0表示不限制
+	MaxConcurrentRuns int    `json:"maxConcurrentRuns"` // 整个集群同时执行的任务数，0表示不限制
+}
+
+// 日志存储配置
+func (conf *Config) LogStoreConfig() *logstore.Config {
+	return &logstore.Config{
+		Type:                  conf.LogStore,
+		MongodbUri:            conf.MongodbUri,
+		MongodbConnectTimeout: conf.MongodbConnectTimeout,
+		MongodbDatabase:       conf.MongodbDatabase,
+		MongodbCollection:     conf.MongodbCollection,
+		Path:                  conf.LogStorePath,
+	}
+}
+
+// 进程日志配置
+func (conf *Config) LoggerConfig() *logger.Config {
+	return &logger.Config{
+		Level:      conf.LoggerLevel,
+		Format:     conf.LoggerFormat,
+		File:       conf.LoggerFile,
+		MaxSize:    conf.LoggerMaxSize,
+		MaxBackups: conf.LoggerMaxBackups,
+		MaxAge:     conf.LoggerMaxAge,
+	}
+}
+
+// 是否配置了该通知渠道
+func (conf *Config) HasNotifyTarget(name string) bool {
+	var (
+		targetConfig *NotifyTargetConfig
+	)
+	for _, targetConfig = range conf.NotifyTargets {
+		if targetConfig.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// 命名空间的配额，没有配置返回nil
+func (conf *Config) NamespaceQuota(namespace string) *common.NamespaceQuota {
+	var (
+		namespaceConfig *NamespaceConfig
+	)
+	for _, namespaceConfig = range conf.Namespaces {
+		if namespaceConfig.Name == namespace {
+			return &common.NamespaceQuota{
+				MaxJobs:           namespaceConfig.MaxJobs,
+				MaxConcurrentRuns: namespaceConfig.MaxConcurrentRuns,
+			}
+		}
+	}
+	return nil
 }
 
 // 加载配置
@@ -37,6 +153,27 @@ func InitConfig(finename string) (err error) {
 	if err = json.Unmarshal(content, conf); err != nil {
 		return
 	}
+	if conf.EtcdMaxTxnOps <= 0 {
+		conf.EtcdMaxTxnOps = 128 // etcd的默认值
+	}
+	if conf.LogPruneInterval <= 0 {
+		conf.LogPruneInterval = 10 * 60 * 1000
+	}
+	if conf.NotifyTimeout <= 0 {
+		conf.NotifyTimeout = 5000
+	}
+	if conf.WatchdogInterval <= 0 {
+		conf.WatchdogInterval = 60 * 1000
+	}
+	if conf.ElectionTTL <= 0 {
+		conf.ElectionTTL = 10
+	}
+	if conf.ShutdownTimeout <= 0 {
+		conf.ShutdownTimeout = 10 * 1000
+	}
+	if err = conf.validateAccess(); err != nil {
+		return
+	}
 	// 赋值单例
 	G_config = conf
 	return nil
@@ -0,0 +1,280 @@
+package master
+
+import (
+	"../common"
+	"../logger"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	eventLog = logger.Component("event")
+)
+
+const (
+	// 没有事件时发送注释保持连接，避免被代理断开
+	EVENT_PING_INTERVAL = 15 * time.Second
+)
+
+// 把etcd的一次变化转换为事件，不关心的变化返回nil
+func buildEvent(watchEvent *clientv3.Event) (event *common.Event) {
+	var (
+		key      string
+		err      error
+		runState *common.JobRunState
+		jobLog   *common.JobLog
+	)
+	key = string(watchEvent.Kv.Key)
+	event = &common.Event{Revision: watchEvent.Kv.ModRevision}
+	switch {
+	case strings.HasPrefix(key, common.JOB_SAVE_DIR):
+		event.JobName = common.ExtractJobName(key)
+		if watchEvent.Type == mvccpb.DELETE {
+			event.Type = common.EVENT_JOB_DELETED
+			return
+		}
+		if event.Job, err = common.UnpackJob(watchEvent.Kv.Value); err != nil {
+			return nil
+		}
+		event.Type = common.EVENT_JOB_SAVED
+	case strings.HasPrefix(key, common.JOB_RUNNING_DIR):
+		// 只有开始执行需要推送，结束以执行结果为准
+		if watchEvent.Type != mvccpb.PUT || watchEvent.Kv.Version != 1 {
+			return nil
+		}
+		runState = &common.JobRunState{}
+		if err = json.Unmarshal(watchEvent.Kv.Value, runState); err != nil {
+			return nil
+		}
+		event.Type = common.EVENT_RUN_STARTED
+		event.JobName = runState.JobName
+		event.WorkerId = runState.Worker
+		event.Run = runState
+	case strings.HasPrefix(key, common.JOB_RESULT_DIR):
+		if watchEvent.Type != mvccpb.PUT {
+			return nil
+		}
+		jobLog = &common.JobLog{}
+		if err = json.Unmarshal(watchEvent.Kv.Value, jobLog); err != nil {
+			return nil
+		}
+		event.Type = common.EVENT_RUN_FINISHED
+		event.JobName = jobLog.JobName
+		event.WorkerId = jobLog.Worker
+		event.Log = jobLog
+	case strings.HasPrefix(key, common.JOB_WORKER_DIR):
+		event.WorkerId = common.ExtractWorkerID(key)
+		if watchEvent.Type == mvccpb.DELETE {
+			event.Type = common.EVENT_WORKER_LEFT
+			return
+		}
+		// 心跳刷新注册信息时不推送，只关心第一次注册
+		if watchEvent.Kv.Version != 1 {
+			return nil
+		}
+		event.Type = common.EVENT_WORKER_JOINED
+		event.Worker = buildWorkerInfo(watchEvent.Kv)
+	default:
+		return nil
+	}
+	return
+}
+
+// 调用方是否可以查看该事件
+func canReadEvent(caller *ApiTokenConfig, event *common.Event) bool {
+	var (
+		namespace string
+	)
+	if event.JobName == "" {
+		return true
+	}
+	namespace, _ = common.SplitJobFullName(event.JobName)
+	return caller.CanRead(namespace)
+}
+
+// 当前etcd revision
+func currentRevision(ctx context.Context) (revision int64, err error) {
+	var (
+		getResp *clientv3.GetResponse
+	)
+	if getResp, err = G_jobMgr.kv.Get(ctx, common.JOB_SAVE_DIR, clientv3.WithPrefix(), clientv3.WithCountOnly()); err != nil {
+		return
+	}
+	return getResp.Header.Revision, nil
+}
+
+// 监听 /cron/ 目录，从startRevision开始（包含）回调事件
+// ctx取消或handler返回错误时结束，startRevision已被压缩时返回ERR_EVENT_REVISION_COMPACTED
+func (jobMgr *JobMgr) WatchEvents(ctx context.Context, startRevision int64, handler func(event *common.Event) error) (err error) {
+	var (
+		watchChan  clientv3.WatchChan
+		watchResp  clientv3.WatchResponse
+		watchEvent *clientv3.Event
+		event      *common.Event
+	)
+	watchChan = jobMgr.client.Watch(ctx, common.CRON_DIR, clientv3.WithPrefix(), clientv3.WithRev(startRevision))
+	for watchResp = range watchChan {
+		if watchResp.CompactRevision != 0 {
+			return common.ERR_EVENT_REVISION_COMPACTED
+		}
+		if err = watchResp.Err(); err != nil {
+			return
+		}
+		for _, watchEvent = range watchResp.Events {
+			if event = buildEvent(watchEvent); event == nil {
+				continue
+			}
+			if err = handler(event); err != nil {
+				return
+			}
+		}
+	}
+	// 通道关闭说明ctx已取消
+	return ctx.Err()
+}
+
+// 写一条SSE消息，id是etcd revision，浏览器重连时通过Last-Event-ID带回
+func writeSSE(out io.Writer, id int64, eventType string, data interface{}) (err error) {
+	var (
+		bytes []byte
+	)
+	if bytes, err = json.Marshal(data); err != nil {
+		return
+	}
+	_, err = fmt.Fprintf(out, "id: %d\nevent: %s\ndata: %s\n\n", id, eventType, bytes)
+	return
+}
+
+// 实时事件流(Server-Sent Events)：任务保存/删除、开始/结束执行、worker上线/下线
+// get /events?since=revision 从revision之后继续，浏览器断线重连时自动带上Last-Event-ID
+// 连接后先发送ready事件(id为起始revision)；since已被压缩时发送reset事件后断开，客户端需要重新加载全量数据
+func handleEvents(resp http.ResponseWriter, req *http.Request) {
+	var (
+		err        error
+		bytes      []byte
+		since      string
+		revision   int64
+		controller *http.ResponseController
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+		eventChan  chan *common.Event
+		errChan    chan error
+		event      *common.Event
+		pingTicker *time.Ticker
+		caller     *ApiTokenConfig
+	)
+	// 浏览器的EventSource不能设置请求头，允许通过token参数传递
+	if caller = authenticate(req.Header.Get("Authorization")); caller == nil {
+		caller = authenticate(req.URL.Query().Get("token"))
+	}
+	if caller == nil {
+		resp.WriteHeader(http.StatusUnauthorized)
+		if bytes, err = common.BuildResponse(-1, common.ERR_UNAUTHORIZED.Error(), nil); err == nil {
+			resp.Write(bytes)
+		}
+		return
+	}
+	// 从哪个revision之后继续，都没有则从当前开始
+	if since = req.Header.Get("Last-Event-ID"); since == "" {
+		since = req.URL.Query().Get("since")
+	}
+	if since != "" {
+		if revision, err = strconv.ParseInt(since, 10, 64); err != nil || revision < 0 {
+			resp.WriteHeader(http.StatusBadRequest)
+			if bytes, err = common.BuildResponse(-1, "since格式错误", nil); err == nil {
+				resp.Write(bytes)
+			}
+			return
+		}
+	} else if revision, err = currentRevision(req.Context()); err != nil {
+		goto ERR
+	}
+
+	// 长连接，取消ApiServer的写超时
+	controller = http.NewResponseController(resp)
+	controller.SetWriteDeadline(time.Time{})
+	resp.Header().Set("Content-Type", "text/event-stream")
+	resp.Header().Set("Cache-Control", "no-cache")
+	resp.Header().Set("X-Accel-Buffering", "no") // 关闭nginx缓冲
+	resp.WriteHeader(http.StatusOK)
+	if err = writeSSE(resp, revision, "ready", map[string]int64{"revision": revision}); err != nil {
+		return
+	}
+	controller.Flush()
+
+	// 客户端断开或者master退出时结束
+	ctx, cancelFunc = context.WithCancel(req.Context())
+	defer cancelFunc()
+	go func() {
+		select {
+		case <-shutdownChan:
+			cancelFunc()
+		case <-ctx.Done():
+		}
+	}()
+
+	// 监听协程只负责转换事件，写应答都在当前协程
+	eventChan = make(chan *common.Event, 100)
+	errChan = make(chan error, 1)
+	go func() {
+		errChan <- G_jobMgr.WatchEvents(ctx, revision+1, func(event *common.Event) error {
+			select {
+			case eventChan <- event:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	pingTicker = time.NewTicker(EVENT_PING_INTERVAL)
+	defer pingTicker.Stop()
+	for {
+		select {
+		case event = <-eventChan:
+			// 只推送有查看权限的命名空间的任务和执行事件，worker事件都推送
+			if !canReadEvent(caller, event) {
+				continue
+			}
+			if err = writeSSE(resp, event.Revision, event.Type, event); err != nil {
+				return
+			}
+			controller.Flush()
+		case <-pingTicker.C:
+			if _, err = io.WriteString(resp, ": ping\n\n"); err != nil {
+				return
+			}
+			controller.Flush()
+		case err = <-errChan:
+			// 先把已经转换好的事件发完
+			for len(eventChan) > 0 {
+				if event = <-eventChan; canReadEvent(caller, event) {
+					writeSSE(resp, event.Revision, event.Type, event)
+				}
+			}
+			if err == common.ERR_EVENT_REVISION_COMPACTED {
+				// id为当前revision，浏览器自动重连时从这里继续，不会反复reset
+				if revision, err = currentRevision(context.TODO()); err == nil {
+					writeSSE(resp, revision, "reset", map[string]interface{}{"revision": revision, "message": common.ERR_EVENT_REVISION_COMPACTED.Error()})
+				}
+			} else if err != nil && ctx.Err() == nil {
+				eventLog.WithError(err).Warn("监听事件失败")
+			}
+			controller.Flush()
+			return
+		}
+	}
+
+ERR:
+	apiLog.WithError(err).WithField("path", req.URL.Path).Warn("请求处理失败")
+	if bytes, err = common.BuildResponse(-1, err.Error(), nil); err == nil {
+		resp.Write(bytes)
+	}
+}
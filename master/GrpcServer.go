@@ -0,0 +1,545 @@
+package master
+
+import (
+	"../common"
+	"../cronpb"
+	"../logger"
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// 单例，未配置grpcPort时为nil
+	G_grpcServer *GrpcServer
+
+	grpcLog = logger.Component("grpc")
+
+	// http状态码 -> gRPC状态码，错误分类复用apiV1ErrorTable
+	grpcCodeTable = map[int]codes.Code{
+		http.StatusBadRequest:   codes.InvalidArgument,
+		http.StatusUnauthorized: codes.Unauthenticated,
+		http.StatusForbidden:    codes.PermissionDenied,
+		http.StatusNotFound:     codes.NotFound,
+		http.StatusConflict:     codes.Aborted,
+	}
+)
+
+// 任务的gRPC接口，和ApiServer功能一致，使用单独的端口
+type GrpcServer struct {
+	cronpb.UnimplementedCronServiceServer
+	server *grpc.Server
+}
+
+// 转换为gRPC错误，未知错误返回Internal
+func grpcError(err error) error {
+	var (
+		i    int
+		code codes.Code
+		ok   bool
+	)
+	if err == nil {
+		return nil
+	}
+	if _, ok = status.FromError(err); ok {
+		return err
+	}
+	// 和没有权限一样是403，gRPC有更准确的状态码
+	if errors.Is(err, common.ERR_JOB_QUOTA_EXCEEDED) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	for i = range apiV1ErrorTable {
+		if errors.Is(err, apiV1ErrorTable[i].err) {
+			if code, ok = grpcCodeTable[apiV1ErrorTable[i].status]; ok {
+				return status.Error(code, err.Error())
+			}
+		}
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// 校验元数据中的 authorization: Bearer <token>，返回调用方，认证失败返回nil
+func authenticateGrpc(ctx context.Context) *ApiTokenConfig {
+	var (
+		md     metadata.MD
+		values []string
+	)
+	md, _ = metadata.FromIncomingContext(ctx)
+	if values = md.Get("authorization"); len(values) == 0 {
+		return authenticate("")
+	}
+	return authenticate(values[0])
+}
+
+// 替换流的上下文，带上调用方
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *principalStream) Context() context.Context {
+	return stream.ctx
+}
+
+// 记录请求次数和耗时，和http接口共用监控指标，path为gRPC方法名
+func observeGrpc(method string, startTime time.Time, err error) {
+	metricApiDuration.WithLabelValues(method).Observe(time.Since(startTime).Seconds())
+	metricApiRequests.WithLabelValues(method, status.Code(err).String()).Inc()
+	if status.Code(err) == codes.Internal {
+		grpcLog.WithError(err).WithField("method", method).Warn("请求处理失败")
+	}
+}
+
+// 普通方法：校验token，转换错误，统计指标
+func grpcUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	var (
+		startTime time.Time
+		caller    *ApiTokenConfig
+	)
+	startTime = time.Now()
+	if caller = authenticateGrpc(ctx); caller == nil {
+		err = status.Error(codes.Unauthenticated, common.ERR_UNAUTHORIZED.Error())
+	} else {
+		resp, err = handler(withPrincipal(ctx, caller), req)
+		err = grpcError(err)
+	}
+	observeGrpc(info.FullMethod, startTime, err)
+	return
+}
+
+// 流式方法：同上
+func grpcStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	var (
+		startTime time.Time
+		caller    *ApiTokenConfig
+	)
+	startTime = time.Now()
+	if caller = authenticateGrpc(stream.Context()); caller == nil {
+		err = status.Error(codes.Unauthenticated, common.ERR_UNAUTHORIZED.Error())
+	} else {
+		err = grpcError(handler(srv, &principalStream{ServerStream: stream, ctx: withPrincipal(stream.Context(), caller)}))
+	}
+	observeGrpc(info.FullMethod, startTime, err)
+	return
+}
+
+func jobToPb(job *common.Job) (pbJob *cronpb.Job) {
+	var (
+		param   *common.JobParam
+		pbParam *cronpb.JobParam
+	)
+	if job == nil {
+		return nil
+	}
+	pbJob = &cronpb.Job{
+		Namespace:           job.Namespace,
+		Name:                job.Name,
+		Command:             job.Command,
+		CronExpr:            job.CronExpr,
+		Mode:                job.Mode,
+		Timeout:             int32(job.Timeout),
+		ExpectSuccessWithin: job.ExpectSuccessWithin,
+		Description:         job.Description,
+		Owner:               job.Owner,
+		Team:                job.Team,
+		Tags:                job.Tags,
+		CreateTime:          job.CreateTime,
+		UpdateTime:          job.UpdateTime,
+		Template:            job.Template,
+	}
+	for _, param = range job.Params {
+		pbParam = &cronpb.JobParam{Name: param.Name, Type: param.Type, Description: param.Description}
+		if param.Default != nil {
+			pbParam.Default = fmt.Sprint(param.Default)
+		}
+		pbJob.Params = append(pbJob.Params, pbParam)
+	}
+	if job.Retention != nil {
+		pbJob.Retention = &cronpb.LogRetention{MaxAge: job.Retention.MaxAge, MaxCount: job.Retention.MaxCount}
+	}
+	if job.Notify != nil {
+		pbJob.Notify = &cronpb.JobNotify{
+			OnFailure:           job.Notify.OnFailure,
+			OnTimeout:           job.Notify.OnTimeout,
+			ConsecutiveFailures: int32(job.Notify.ConsecutiveFailures),
+			OnRecovery:          job.Notify.OnRecovery,
+			Targets:             job.Notify.Targets,
+		}
+	}
+	return
+}
+
+func jobFromPb(pbJob *cronpb.Job) (job *common.Job) {
+	var (
+		pbParam *cronpb.JobParam
+		param   *common.JobParam
+	)
+	job = &common.Job{
+		Namespace:           pbJob.GetNamespace(),
+		Name:                pbJob.GetName(),
+		Command:             pbJob.GetCommand(),
+		CronExpr:            pbJob.GetCronExpr(),
+		Mode:                pbJob.GetMode(),
+		Timeout:             int(pbJob.GetTimeout()),
+		ExpectSuccessWithin: pbJob.GetExpectSuccessWithin(),
+		Description:         pbJob.GetDescription(),
+		Owner:               pbJob.GetOwner(),
+		Team:                pbJob.GetTeam(),
+		Tags:                pbJob.GetTags(),
+		Template:            pbJob.GetTemplate(),
+	}
+	for _, pbParam = range pbJob.GetParams() {
+		param = &common.JobParam{Name: pbParam.Name, Type: pbParam.Type, Description: pbParam.Description}
+		if pbParam.Default != "" {
+			param.Default = pbParam.Default
+		}
+		job.Params = append(job.Params, param)
+	}
+	if pbJob.GetRetention() != nil {
+		job.Retention = &common.LogRetention{MaxAge: pbJob.Retention.MaxAge, MaxCount: pbJob.Retention.MaxCount}
+	}
+	if pbJob.GetNotify() != nil {
+		job.Notify = &common.JobNotify{
+			OnFailure:           pbJob.Notify.OnFailure,
+			OnTimeout:           pbJob.Notify.OnTimeout,
+			ConsecutiveFailures: int(pbJob.Notify.ConsecutiveFailures),
+			OnRecovery:          pbJob.Notify.OnRecovery,
+			Targets:             pbJob.Notify.Targets,
+		}
+	}
+	return
+}
+
+func jobListItemToPb(job *common.Job) (item *cronpb.JobListItem) {
+	var (
+		state *common.JobWatchdogState
+	)
+	item = &cronpb.JobListItem{Job: jobToPb(job)}
+	if state = G_jobWatchdog.State(job.FullName()); state != nil {
+		item.Watchdog = &cronpb.JobWatchdogState{
+			ExpectSuccessWithin: state.ExpectSuccessWithin,
+			LastSuccessTime:     state.LastSuccessTime,
+			Overdue:             state.Overdue,
+			OverdueSince:        state.OverdueSince,
+			CheckTime:           state.CheckTime,
+		}
+	}
+	return
+}
+
+func jobLogToPb(jobLog *common.JobLog) *cronpb.JobLog {
+	return &cronpb.JobLog{
+		JobName:      jobLog.JobName,
+		Command:      jobLog.Command,
+		ExecId:       jobLog.ExecId,
+		Worker:       jobLog.Worker,
+		Err:          jobLog.Err,
+		Output:       jobLog.Output,
+		TimedOut:     jobLog.TimedOut,
+		PlanTime:     jobLog.PlanTime,
+		ScheduleTime: jobLog.ScheduleTime,
+		StartTime:    jobLog.StartTime,
+		EndTime:      jobLog.EndTime,
+	}
+}
+
+func workerInfoToPb(workerInfo *common.WorkerInfo) (pbWorker *cronpb.WorkerInfo) {
+	pbWorker = &cronpb.WorkerInfo{
+		Id:             workerInfo.ID,
+		Ip:             workerInfo.IP,
+		Hostname:       workerInfo.Hostname,
+		Pid:            int32(workerInfo.Pid),
+		Version:        workerInfo.Version,
+		StartTime:      workerInfo.StartTime,
+		Labels:         workerInfo.Labels,
+		CpuNum:         int32(workerInfo.CpuNum),
+		MemTotal:       workerInfo.MemTotal,
+		MaxConcurrency: int32(workerInfo.MaxConcurrency),
+		RunningJobs:    int32(workerInfo.RunningJobs),
+		FreeSlots:      int32(workerInfo.FreeSlots),
+		State:          workerInfo.State,
+		UpdateTime:     workerInfo.UpdateTime,
+	}
+	if workerInfo.LogSink != nil {
+		pbWorker.LogSink = &cronpb.LogSinkStats{
+			QueueLen:      int32(workerInfo.LogSink.QueueLen),
+			SpoolSegments: int32(workerInfo.LogSink.SpoolSegments),
+			SpoolLogs:     int32(workerInfo.LogSink.SpoolLogs),
+			DroppedLogs:   workerInfo.LogSink.DroppedLogs,
+			FlushErrors:   workerInfo.LogSink.FlushErrors,
+		}
+	}
+	return
+}
+
+// 保存任务
+func (grpcServer *GrpcServer) SaveJob(ctx context.Context, req *cronpb.SaveJobRequest) (resp *cronpb.SaveJobResponse, err error) {
+	var (
+		job    *common.Job
+		oldJob *common.Job
+	)
+	if req.GetJob() == nil {
+		return nil, status.Error(codes.InvalidArgument, "缺少任务")
+	}
+	job = jobFromPb(req.Job)
+	if _, err = authorizeJob(ctx, job.Namespace, job.Name, common.API_ROLE_EDITOR); err != nil {
+		return
+	}
+	if oldJob, err = G_jobMgr.SaveJob(job); err != nil {
+		return
+	}
+	return &cronpb.SaveJobResponse{OldJob: jobToPb(oldJob)}, nil
+}
+
+// 删除任务，任务不存在返回NotFound
+func (grpcServer *GrpcServer) DeleteJob(ctx context.Context, req *cronpb.DeleteJobRequest) (resp *cronpb.DeleteJobResponse, err error) {
+	var (
+		name   string
+		oldJob *common.Job
+	)
+	if name, err = authorizeJob(ctx, req.Namespace, req.Name, common.API_ROLE_EDITOR); err != nil {
+		return
+	}
+	if oldJob, err = G_jobMgr.DeleteJob(name); err != nil {
+		return
+	}
+	if oldJob == nil {
+		return nil, common.ERR_JOB_NOT_FOUND
+	}
+	// purge_logs已废弃，默认删除日志
+	if !req.KeepLogs {
+		if _, err = G_logMgr.PurgeLog(name); err != nil {
+			return
+		}
+	}
+	return &cronpb.DeleteJobResponse{OldJob: jobToPb(oldJob)}, nil
+}
+
+// 查询单个任务
+func (grpcServer *GrpcServer) GetJob(ctx context.Context, req *cronpb.GetJobRequest) (resp *cronpb.GetJobResponse, err error) {
+	var (
+		name string
+		job  *common.Job
+	)
+	if name, err = authorizeJob(ctx, req.Namespace, req.Name, common.API_ROLE_VIEWER); err != nil {
+		return
+	}
+	if job, err = G_jobMgr.GetJob(name); err != nil {
+		return
+	}
+	return &cronpb.GetJobResponse{Item: jobListItemToPb(job)}, nil
+}
+
+// 任务列表，只返回有查看权限的命名空间，支持过滤、排序和分页
+func (grpcServer *GrpcServer) ListJobs(ctx context.Context, req *cronpb.ListJobsRequest) (resp *cronpb.ListJobsResponse, err error) {
+	var (
+		query *common.JobListQuery
+		page  *common.JobListPage
+		item  *common.JobListItem
+	)
+	if req.Skip < 0 || req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "skip和limit不能为负数")
+	}
+	query = &common.JobListQuery{
+		Tags:    req.Tags,
+		Owner:   req.Owner,
+		Team:    req.Team,
+		Keyword: req.Keyword,
+		Sort:    req.Sort,
+		Skip:    int(req.Skip),
+		Limit:   int(req.Limit),
+	}
+	if page, err = listJobPage(ctx, req.Namespace, query); err != nil {
+		return
+	}
+	resp = &cronpb.ListJobsResponse{Items: make([]*cronpb.JobListItem, 0, len(page.Jobs)), Total: int32(page.Total)}
+	for _, item = range page.Jobs {
+		resp.Items = append(resp.Items, jobListItemToPb(item.Job))
+	}
+	return
+}
+
+// 强杀任务
+func (grpcServer *GrpcServer) KillJob(ctx context.Context, req *cronpb.KillJobRequest) (resp *cronpb.KillJobResponse, err error) {
+	var (
+		name string
+	)
+	if name, err = authorizeJob(ctx, req.Namespace, req.Name, common.API_ROLE_EDITOR); err != nil {
+		return
+	}
+	if _, err = G_jobMgr.GetJob(name); err != nil {
+		return
+	}
+	if err = G_jobMgr.KillJob(name); err != nil {
+		return
+	}
+	return &cronpb.KillJobResponse{}, nil
+}
+
+// 立即执行一次任务
+func (grpcServer *GrpcServer) RunJob(ctx context.Context, req *cronpb.RunJobRequest) (resp *cronpb.RunJobResponse, err error) {
+	var (
+		name   string
+		params map[string]interface{}
+		key    string
+		value  string
+	)
+	if name, err = authorizeJob(ctx, req.Namespace, req.Name, common.API_ROLE_EDITOR); err != nil {
+		return
+	}
+	// 字符串形式的值由master按参数类型解析
+	params = make(map[string]interface{}, len(req.Params))
+	for key, value = range req.Params {
+		params[key] = value
+	}
+	if err = G_jobMgr.RunJob(name, params); err != nil {
+		return
+	}
+	return &cronpb.RunJobResponse{}, nil
+}
+
+// 查询执行日志
+func (grpcServer *GrpcServer) ListLogs(ctx context.Context, req *cronpb.ListLogsRequest) (resp *cronpb.ListLogsResponse, err error) {
+	var (
+		filter  *common.JobLogFilter
+		limit   int64
+		logPage *common.JobLogPage
+		jobLog  *common.JobLog
+	)
+	if req.Skip < 0 || req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "skip和limit不能为负数")
+	}
+	filter = &common.JobLogFilter{
+		StartFrom: req.StartTime,
+		StartTo:   req.EndTime,
+		Status:    req.Status,
+		Worker:    req.Worker,
+		Keyword:   req.Keyword,
+	}
+	if req.Name != "" {
+		if filter.JobName, err = authorizeJob(ctx, req.Namespace, req.Name, common.API_ROLE_VIEWER); err != nil {
+			return
+		}
+	} else {
+		if err = authorizeNamespaceFilter(ctx, req.Namespace); err != nil {
+			return
+		}
+		filter.Namespace = req.Namespace
+	}
+	if limit = req.Limit; limit == 0 {
+		limit = 20
+	}
+	if logPage, err = G_logMgr.ListLog(filter, "", req.Skip, limit); err != nil {
+		return
+	}
+	resp = &cronpb.ListLogsResponse{Total: logPage.Total, Logs: make([]*cronpb.JobLog, 0, len(logPage.Logs))}
+	for _, jobLog = range logPage.Logs {
+		resp.Logs = append(resp.Logs, jobLogToPb(jobLog))
+	}
+	return
+}
+
+// 在线的worker列表
+func (grpcServer *GrpcServer) ListWorkers(ctx context.Context, req *cronpb.ListWorkersRequest) (resp *cronpb.ListWorkersResponse, err error) {
+	var (
+		workerArr  []*common.WorkerInfo
+		workerInfo *common.WorkerInfo
+	)
+	if workerArr, err = G_workerMgr.ListWorkers(); err != nil {
+		return
+	}
+	resp = &cronpb.ListWorkersResponse{Workers: make([]*cronpb.WorkerInfo, 0, len(workerArr))}
+	for _, workerInfo = range workerArr {
+		resp.Workers = append(resp.Workers, workerInfoToPb(workerInfo))
+	}
+	return
+}
+
+// 推送有查看权限的任务变化，直到客户端断开
+func (grpcServer *GrpcServer) WatchJobs(req *cronpb.WatchJobsRequest, stream cronpb.CronService_WatchJobsServer) (err error) {
+	var (
+		caller *ApiTokenConfig
+	)
+	if err = checkNamespaceParam(req.Namespace); err != nil {
+		return
+	}
+	caller = principalFrom(stream.Context())
+	err = G_jobMgr.WatchJobs(stream.Context(), req.IncludeExisting, func(jobEvent *common.JobEvent, revision int64) error {
+		var (
+			event     *cronpb.JobEvent
+			namespace string
+		)
+		namespace = common.NormalizeNamespace(jobEvent.Job.Namespace)
+		if !caller.CanRead(namespace) || (req.Namespace != "" && req.Namespace != namespace) {
+			return nil
+		}
+		event = &cronpb.JobEvent{Name: jobEvent.Job.FullName(), Revision: revision}
+		if jobEvent.EventType == common.JOB_EVENT_DELETE {
+			event.Type = cronpb.JobEvent_DELETE
+		} else {
+			event.Type = cronpb.JobEvent_PUT
+			event.Job = jobToPb(jobEvent.Job)
+		}
+		return stream.Send(event)
+	})
+	// 客户端断开或者master退出
+	if stream.Context().Err() != nil {
+		return nil
+	}
+	return
+}
+
+// 优雅退出：等待普通请求结束，WatchJobs这种长连接在超时后强制断开
+func (grpcServer *GrpcServer) shutdown(ctx context.Context) {
+	var (
+		stopped chan struct{}
+	)
+	stopped = make(chan struct{})
+	go func() {
+		grpcServer.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcLog.Warn("等待gRPC请求结束超时，强制断开")
+		grpcServer.server.Stop()
+	}
+}
+
+// 初始化gRPC服务，未配置端口则不启动
+func InitGrpcServer() (err error) {
+	var (
+		listener net.Listener
+		server   *grpc.Server
+	)
+	if G_config.GrpcPort <= 0 {
+		return
+	}
+	if listener, err = net.Listen("tcp", ":"+strconv.Itoa(G_config.GrpcPort)); err != nil {
+		return
+	}
+	server = grpc.NewServer(
+		grpc.UnaryInterceptor(grpcUnaryInterceptor),
+		grpc.StreamInterceptor(grpcStreamInterceptor),
+	)
+
+	// 赋值单例
+	G_grpcServer = &GrpcServer{
+		server: server,
+	}
+	cronpb.RegisterCronServiceServer(server, G_grpcServer)
+
+	go server.Serve(listener)
+
+	grpcLog.WithField("port", G_config.GrpcPort).Info("gRPC服务已启动")
+	return
+}
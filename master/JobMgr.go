@@ -2,10 +2,14 @@ package master
 
 import (
 	"../common"
+	"../logger"
 	"context"
 	"encoding/json"
+	"fmt"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/mvcc/mvccpb"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -16,19 +20,124 @@ type JobMgr struct {
 	lease  clientv3.Lease
 }
 
+// 校验任务配置，空命名空间会被设置为默认命名空间
+func validateJob(job *common.Job) (err error) {
+	var (
+		targetName string
+	)
+	// 校验命名空间和名称，名称中的/会和命名空间混淆
+	job.Namespace = common.NormalizeNamespace(job.Namespace)
+	if err = common.ValidateNamespace(job.Namespace); err != nil {
+		return
+	}
+	if strings.Contains(job.Name, "/") {
+		err = common.ERR_INVALID_JOB_NAME
+		return
+	}
+	// 校验参数定义和命令模板
+	if err = job.ValidateParams(); err != nil {
+		return
+	}
+	// 校验调度模式
+	switch job.Mode {
+	case "", common.JOB_MODE_SINGLE, common.JOB_MODE_BROADCAST:
+	default:
+		err = common.ERR_INVALID_JOB_MODE
+		return
+	}
+	// 校验日志保留策略
+	if job.Retention != nil {
+		if err = job.Retention.Validate(); err != nil {
+			return
+		}
+	}
+	// 校验通知渠道
+	if job.Notify != nil {
+		for _, targetName = range job.Notify.Targets {
+			if !G_config.HasNotifyTarget(targetName) {
+				err = common.ERR_NOTIFY_TARGET_NOT_FOUND
+				return
+			}
+		}
+	}
+	return
+}
+
+// 命名空间的任务数不能超过配额，jobCount为变更后的任务数
+func checkJobQuota(namespace string, jobCount int) (err error) {
+	var (
+		quota *common.NamespaceQuota
+	)
+	if quota = G_config.NamespaceQuota(namespace); quota != nil && quota.MaxJobs > 0 && jobCount > quota.MaxJobs {
+		err = fmt.Errorf("%w: %s 最多 %d 个任务", common.ERR_JOB_QUOTA_EXCEEDED, namespace, quota.MaxJobs)
+	}
+	return
+}
+
+// 沿用任务中已有的创建和修改时间（从备份恢复），没有创建时间的当作新修改的任务处理
+func keepJobTimes(job *common.Job, oldJob *common.Job, now int64) {
+	if job.CreateTime == 0 {
+		stampJob(job, oldJob, now)
+		return
+	}
+	if job.UpdateTime == 0 {
+		job.UpdateTime = job.CreateTime
+	}
+}
+
+// 填写任务的创建和修改时间，修改时沿用原来的创建时间
+func stampJob(job *common.Job, oldJob *common.Job, now int64) {
+	job.UpdateTime = now
+	job.CreateTime = now
+	if oldJob != nil {
+		job.CreateTime = oldJob.CreateTime
+	}
+}
+
 // 保存任务
 func (jobMgr *JobMgr) SaveJob(job *common.Job) (oldJob *common.Job, err error) { // 为其添加一个SaveJob方法
-	// 将任务保存到 /cron/jobs/任务名 -> json
+	// 将任务保存到 /cron/jobs/命名空间/任务名 -> json
 	var (
 		jobKey    string
 		jobValue  []byte
+		getResp   *clientv3.GetResponse
 		putResp   *clientv3.PutResponse
 		oldJobObj *common.Job
+		created   bool
+		retry     int
 	)
-	jobKey = common.JOB_SAVE_DIR + job.Name
-	if jobValue, err = json.Marshal(job); err != nil { // 将job变成json类型，作为value
+	if err = validateJob(job); err != nil {
 		return
 	}
+	jobKey = common.JOB_SAVE_DIR + job.FullName()
+	for retry = 0; ; retry++ {
+		if getResp, err = jobMgr.kv.Get(context.TODO(), jobKey); err != nil {
+			return
+		}
+		oldJobObj = nil
+		if len(getResp.Kvs) != 0 {
+			// 原任务无法解析时当作新建
+			if oldJobObj, err = common.UnpackJob(getResp.Kvs[0].Value); err != nil {
+				oldJobObj, err = nil, nil
+			}
+		}
+		stampJob(job, oldJobObj, time.Now().UnixNano()/1000/1000)
+		if jobValue, err = json.Marshal(job); err != nil { // 将job变成json类型，作为value
+			return
+		}
+		// 修改任务、命名空间没有配额时直接保存
+		if len(getResp.Kvs) != 0 || G_config.NamespaceQuota(job.Namespace) == nil {
+			break
+		}
+		// 新建任务时检查命名空间的任务数，期间有其他任务被新建时重新计数
+		if created, err = jobMgr.createJobInQuota(job, jobKey, string(jobValue)); err != nil || created {
+			return
+		}
+		if retry >= 3 {
+			err = common.ERR_APPLY_CONFLICT
+			return
+		}
+	}
 	// 保存到etcd, withpreKV()表示 若为更新操作，将old值返回
 	if putResp, err = jobMgr.kv.Put(context.TODO(), jobKey, string(jobValue), clientv3.WithPrevKV()); err != nil {
 		return
@@ -46,7 +155,32 @@ func (jobMgr *JobMgr) SaveJob(job *common.Job) (oldJob *common.Job, err error) {
 	return
 }
 
-// 删除任务
+// 在命名空间的任务数配额内新建任务
+// 计数和写入在同一个revision上比较：计数之后命名空间中有任务被新建或修改、或者任务已被创建，都不写入，返回created=false由调用方重试
+func (jobMgr *JobMgr) createJobInQuota(job *common.Job, jobKey string, jobValue string) (created bool, err error) {
+	var (
+		dirKey    string
+		countResp *clientv3.GetResponse
+		txnResp   *clientv3.TxnResponse
+	)
+	dirKey = common.JOB_SAVE_DIR + job.Namespace + "/"
+	if countResp, err = jobMgr.kv.Get(context.TODO(), dirKey, clientv3.WithPrefix(), clientv3.WithCountOnly()); err != nil {
+		return
+	}
+	if err = checkJobQuota(job.Namespace, int(countResp.Count)+1); err != nil {
+		return
+	}
+	if txnResp, err = jobMgr.kv.Txn(context.TODO()).If(
+		clientv3.Compare(clientv3.CreateRevision(jobKey), "=", 0),
+		clientv3.Compare(clientv3.ModRevision(dirKey), "<", countResp.Header.Revision+1).WithPrefix(),
+	).Then(clientv3.OpPut(jobKey, jobValue)).Commit(); err != nil {
+		return
+	}
+	created = txnResp.Succeeded
+	return
+}
+
+// 删除任务，name为任务全名
 func (jobMgr *JobMgr) DeleteJob(name string) (oldJob *common.Job, err error) {
 	var (
 		jobKey    string
@@ -57,6 +191,10 @@ func (jobMgr *JobMgr) DeleteJob(name string) (oldJob *common.Job, err error) {
 	if delResp, err = jobMgr.kv.Delete(context.TODO(), jobKey, clientv3.WithPrevKV()); err != nil {
 		return
 	}
+	// 最近一次执行结果也一起删掉
+	if _, err = jobMgr.kv.Delete(context.TODO(), common.JOB_RESULT_DIR+name); err != nil {
+		return
+	}
 	// 返回被删除的任务信息
 	oldJobObj = &common.Job{}
 	if len(delResp.PrevKvs) != 0 {
@@ -68,14 +206,32 @@ func (jobMgr *JobMgr) DeleteJob(name string) (oldJob *common.Job, err error) {
 	return
 }
 
-// 删除任务
-func (jobMgr *JobMgr) ListJob() (jobList []*common.Job, err error) {
+// 查询单个任务，name为任务全名
+func (jobMgr *JobMgr) GetJob(name string) (job *common.Job, err error) {
+	var (
+		getResp *clientv3.GetResponse
+	)
+	if getResp, err = jobMgr.kv.Get(context.TODO(), common.JOB_SAVE_DIR+name); err != nil {
+		return
+	}
+	if len(getResp.Kvs) == 0 {
+		err = common.ERR_JOB_NOT_FOUND
+		return
+	}
+	return common.UnpackJob(getResp.Kvs[0].Value)
+}
+
+// 任务列表，namespace为空时返回所有命名空间的任务
+func (jobMgr *JobMgr) ListJob(namespace string) (jobList []*common.Job, err error) {
 	var (
 		dirKey  string
 		getResp *clientv3.GetResponse
 		value   *mvccpb.KeyValue
 	)
 	dirKey = common.JOB_SAVE_DIR
+	if namespace != "" {
+		dirKey = common.JOB_SAVE_DIR + namespace + "/"
+	}
 	// 目录下所有任务信息
 	if getResp, err = jobMgr.kv.Get(context.TODO(), dirKey, clientv3.WithPrefix()); err != nil {
 		return
@@ -93,10 +249,10 @@ func (jobMgr *JobMgr) ListJob() (jobList []*common.Job, err error) {
 	return
 }
 
-// 杀死任务
+// 杀死任务，name为任务全名
 func (jobMgr *JobMgr) KillJob(name string) (err error) {
 	// 核心原理：
-	// 更新 key=/cron/killer/任务名称，所有的workder会监听到变化
+	// 更新 key=/cron/killer/命名空间/任务名称，所有的workder会监听到变化
 	// 如果worker监听到变化，它正在执行该任务，就将该任务杀死！！！！
 	var (
 		killerKey      string
@@ -117,9 +273,433 @@ func (jobMgr *JobMgr) KillJob(name string) (err error) {
 	return
 }
 
+// 立即执行一次任务，原理同强杀：put /cron/run/命名空间/任务名，worker监听到后立即调度
+func (jobMgr *JobMgr) RunJob(name string, params map[string]interface{}) (err error) {
+	var (
+		job            *common.Job
+		runValue       []byte
+		leaseGrantResp *clientv3.LeaseGrantResponse
+	)
+	// 任务必须存在，worker只能执行自己调度表中的任务
+	if job, err = jobMgr.GetJob(name); err != nil {
+		return
+	}
+	// 覆盖的参数必须是任务定义过的，并且符合参数类型
+	if _, err = common.ResolveJobParams(job, params); err != nil {
+		return
+	}
+	if runValue, err = json.Marshal(&common.JobRunRequest{Params: params}); err != nil {
+		return
+	}
+	// 2秒后自动过期
+	if leaseGrantResp, err = jobMgr.lease.Grant(context.TODO(), 2); err != nil {
+		return
+	}
+	_, err = jobMgr.kv.Put(context.TODO(), common.JOB_RUN_DIR+name, string(runValue), clientv3.WithLease(leaseGrantResp.ID))
+	return
+}
+
+// 监听任务变化：includeExisting为true时先把当前所有任务作为保存事件回调，
+// 再从下一个revision开始监听，中间不会漏掉事件。ctx取消或handler返回错误时结束
+func (jobMgr *JobMgr) WatchJobs(ctx context.Context, includeExisting bool, handler func(jobEvent *common.JobEvent, revision int64) error) (err error) {
+	var (
+		getResp    *clientv3.GetResponse
+		kvPair     *mvccpb.KeyValue
+		job        *common.Job
+		watchChan  clientv3.WatchChan
+		watchResp  clientv3.WatchResponse
+		watchEvent *clientv3.Event
+		jobEvent   *common.JobEvent
+	)
+	if getResp, err = jobMgr.kv.Get(ctx, common.JOB_SAVE_DIR, clientv3.WithPrefix()); err != nil {
+		return
+	}
+	if includeExisting {
+		for _, kvPair = range getResp.Kvs {
+			if job, err = common.UnpackJob(kvPair.Value); err != nil {
+				err = nil
+				continue // 忽视反序列化错误
+			}
+			if err = handler(common.BuildJobEvent(common.JOB_EVENT_SAVE, job), kvPair.ModRevision); err != nil {
+				return
+			}
+		}
+	}
+
+	// 从GET时刻的后续版本开始监听
+	watchChan = jobMgr.client.Watch(ctx, common.JOB_SAVE_DIR, clientv3.WithRev(getResp.Header.Revision+1), clientv3.WithPrefix())
+	for watchResp = range watchChan {
+		if err = watchResp.Err(); err != nil {
+			return
+		}
+		for _, watchEvent = range watchResp.Events {
+			switch watchEvent.Type {
+			case mvccpb.PUT:
+				if job, err = common.UnpackJob(watchEvent.Kv.Value); err != nil {
+					err = nil
+					continue
+				}
+				jobEvent = common.BuildJobEvent(common.JOB_EVENT_SAVE, job)
+			case mvccpb.DELETE:
+				jobEvent = common.BuildJobEvent(common.JOB_EVENT_DELETE, common.JobFromFullName(common.ExtractJobName(string(watchEvent.Kv.Key))))
+			}
+			if err = handler(jobEvent, watchEvent.Kv.ModRevision); err != nil {
+				return
+			}
+		}
+	}
+	// 通道关闭说明ctx已取消
+	return ctx.Err()
+}
+
+// 应用清单的选项
+type applyOptions struct {
+	prune         bool // 删除清单涉及的命名空间中清单没有的任务
+	dryRun        bool // 只计算变更不写入
+	preserveTimes bool // 写入时沿用清单中任务的创建和修改时间，用于从备份恢复
+	createOnly    bool // 只新建，已经存在的任务不修改
+}
+
+// 应用任务清单：和etcd中的任务比较得出新建/修改/删除，在一个事务中全部写入
+// prune为true时删除清单涉及的命名空间中清单没有的任务，dryRun为true时只计算变更不写入
+func (jobMgr *JobMgr) ApplyManifest(manifest *common.JobManifest, prune bool, dryRun bool) (result *common.ApplyResult, err error) {
+	return jobMgr.applyManifest(manifest, &applyOptions{prune: prune, dryRun: dryRun})
+}
+
+// 只新建清单中的任务，已经存在的同名任务不修改，列在结果的Existing中
+// 所有新建在一个事务中提交，期间有同名任务被其他人创建则整体失败
+func (jobMgr *JobMgr) CreateJobs(manifest *common.JobManifest) (result *common.ApplyResult, err error) {
+	return jobMgr.applyManifest(manifest, &applyOptions{createOnly: true})
+}
+
+// 每个新建/修改占1个操作，每个删除占2个操作，超过etcdMaxTxnOps时返回ERR_TOO_MANY_CHANGES，不会提交一部分
+func (jobMgr *JobMgr) applyManifest(manifest *common.JobManifest, options *applyOptions) (result *common.ApplyResult, err error) {
+	var (
+		getResp     *clientv3.GetResponse
+		kvPair      *mvccpb.KeyValue
+		current     map[string]*mvccpb.KeyValue // 任务全名 -> etcd中的kv
+		oldJob      *common.Job
+		job         *common.Job
+		inManifest  map[string]bool
+		namespaces  map[string]bool // 清单涉及的命名空间
+		jobCounts   map[string]int  // 命名空间 -> 应用后的任务数
+		creates     map[string]bool // 有新建任务的命名空间
+		namespace   string
+		name        string
+		fields      []*common.JobFieldChange
+		jobValue    []byte
+		now         int64
+		change      *common.JobChange
+		compares    []clientv3.Cmp
+		ops         []clientv3.Op
+		jobKey      string
+		txnResp     *clientv3.TxnResponse
+		actionOrder map[string]int
+		createTime  int64
+		updateTime  int64
+	)
+	// 先校验整个清单，有任何错误都不写入
+	inManifest = make(map[string]bool)
+	namespaces = make(map[string]bool)
+	if manifest.Namespace != "" {
+		if err = common.ValidateNamespace(manifest.Namespace); err != nil {
+			return
+		}
+		namespaces[manifest.Namespace] = true
+	}
+	for _, job = range manifest.Jobs {
+		if job.Name == "" {
+			err = common.ERR_JOB_NAME_REQUIRED
+			return
+		}
+		if job.Namespace == "" {
+			job.Namespace = manifest.Namespace
+		}
+		if err = validateJob(job); err != nil {
+			err = fmt.Errorf("任务 %s: %w", job.Name, err)
+			return
+		}
+		if inManifest[job.FullName()] {
+			err = fmt.Errorf("%w: %s", common.ERR_DUPLICATE_JOB_NAME, job.FullName())
+			return
+		}
+		inManifest[job.FullName()] = true
+		namespaces[job.Namespace] = true
+	}
+
+	// 当前所有任务
+	if getResp, err = jobMgr.kv.Get(context.TODO(), common.JOB_SAVE_DIR, clientv3.WithPrefix()); err != nil {
+		return
+	}
+	current = make(map[string]*mvccpb.KeyValue)
+	jobCounts = make(map[string]int)
+	for _, kvPair = range getResp.Kvs {
+		name = common.ExtractJobName(string(kvPair.Key))
+		current[name] = kvPair
+		namespace, _ = common.SplitJobFullName(name)
+		jobCounts[namespace]++
+	}
+	creates = make(map[string]bool)
+
+	result = &common.ApplyResult{
+		Changes:       make([]*common.JobChange, 0),
+		NotInManifest: make([]string, 0),
+		Revision:      getResp.Header.Revision,
+	}
+	compares = make([]clientv3.Cmp, 0)
+	ops = make([]clientv3.Op, 0)
+	now = time.Now().UnixNano() / 1000 / 1000
+	for _, job = range manifest.Jobs {
+		jobKey = common.JOB_SAVE_DIR + job.FullName()
+		// 新建，要求提交时key仍然不存在
+		if kvPair = current[job.FullName()]; kvPair == nil {
+			if options.preserveTimes {
+				keepJobTimes(job, nil, now)
+			} else {
+				stampJob(job, nil, now)
+			}
+			if jobValue, err = json.Marshal(job); err != nil {
+				return
+			}
+			result.Changes = append(result.Changes, &common.JobChange{Action: common.JOB_CHANGE_CREATE, Name: job.FullName(), NewJob: job})
+			jobCounts[job.Namespace]++
+			creates[job.Namespace] = true
+			compares = append(compares, clientv3.Compare(clientv3.CreateRevision(jobKey), "=", 0))
+			ops = append(ops, clientv3.OpPut(jobKey, string(jobValue)))
+			continue
+		}
+		if options.createOnly {
+			result.Existing = append(result.Existing, job.FullName())
+			continue
+		}
+		// etcd中的任务无法解析时当作全部字段都变化
+		if oldJob, err = common.UnpackJob(kvPair.Value); err != nil {
+			oldJob = &common.Job{}
+		}
+		// 时间由master维护，清单中的时间不参与比较
+		createTime, updateTime = job.CreateTime, job.UpdateTime
+		job.CreateTime = oldJob.CreateTime
+		job.UpdateTime = oldJob.UpdateTime
+		if fields, err = common.DiffJob(oldJob, job); err != nil {
+			return
+		}
+		if len(fields) == 0 {
+			result.Unchanged++
+			continue
+		}
+		if options.preserveTimes {
+			job.CreateTime, job.UpdateTime = createTime, updateTime
+			keepJobTimes(job, oldJob, now)
+		} else {
+			stampJob(job, oldJob, now)
+		}
+		if jobValue, err = json.Marshal(job); err != nil {
+			return
+		}
+		// 修改，要求提交时没有被其他人改过
+		result.Changes = append(result.Changes, &common.JobChange{Action: common.JOB_CHANGE_UPDATE, Name: job.FullName(), Fields: fields, OldJob: oldJob, NewJob: job})
+		compares = append(compares, clientv3.Compare(clientv3.ModRevision(jobKey), "=", kvPair.ModRevision))
+		ops = append(ops, clientv3.OpPut(jobKey, string(jobValue)))
+	}
+	for name, kvPair = range current {
+		// 只处理清单涉及的命名空间，其他命名空间的任务不受影响
+		namespace, _ = common.SplitJobFullName(name)
+		if inManifest[name] || !namespaces[namespace] {
+			continue
+		}
+		if !options.prune {
+			result.NotInManifest = append(result.NotInManifest, name)
+			continue
+		}
+		jobKey = common.JOB_SAVE_DIR + name
+		change = &common.JobChange{Action: common.JOB_CHANGE_DELETE, Name: name}
+		if oldJob, err = common.UnpackJob(kvPair.Value); err == nil {
+			change.OldJob = oldJob
+		}
+		err = nil
+		result.Changes = append(result.Changes, change)
+		compares = append(compares, clientv3.Compare(clientv3.ModRevision(jobKey), "=", kvPair.ModRevision))
+		// 和DeleteJob一样，最近一次执行结果也一起删掉
+		ops = append(ops, clientv3.OpDelete(jobKey), clientv3.OpDelete(common.JOB_RESULT_DIR+name))
+		jobCounts[namespace]--
+	}
+
+	// 有新建任务的命名空间不能超过任务数配额
+	// 要求提交时命名空间中没有其他任务被新建或修改，避免并发新建超出配额
+	for namespace = range creates {
+		if err = checkJobQuota(namespace, jobCounts[namespace]); err != nil {
+			return
+		}
+		if G_config.NamespaceQuota(namespace) != nil {
+			compares = append(compares, clientv3.Compare(clientv3.ModRevision(common.JOB_SAVE_DIR+namespace+"/"), "<", getResp.Header.Revision+1).WithPrefix())
+		}
+	}
+
+	// 按 create / update / delete 和任务名称排序，方便阅读
+	actionOrder = map[string]int{common.JOB_CHANGE_CREATE: 0, common.JOB_CHANGE_UPDATE: 1, common.JOB_CHANGE_DELETE: 2}
+	sort.Slice(result.Changes, func(i, j int) bool {
+		if actionOrder[result.Changes[i].Action] != actionOrder[result.Changes[j].Action] {
+			return actionOrder[result.Changes[i].Action] < actionOrder[result.Changes[j].Action]
+		}
+		return result.Changes[i].Name < result.Changes[j].Name
+	})
+	sort.Strings(result.NotInManifest)
+
+	if options.dryRun {
+		return
+	}
+	if len(ops) == 0 {
+		result.Applied = true
+		return
+	}
+	// etcd会拒绝超过--max-txn-ops的事务，提前给出明确的错误
+	if len(ops) > G_config.EtcdMaxTxnOps || len(compares) > G_config.EtcdMaxTxnOps {
+		err = fmt.Errorf("%w（需要%d个操作，上限%d）", common.ERR_TOO_MANY_CHANGES, len(ops), G_config.EtcdMaxTxnOps)
+		return
+	}
+	// 所有变更在一个事务中提交，期间有任务被其他人修改则整体失败
+	if txnResp, err = jobMgr.kv.Txn(context.TODO()).If(compares...).Then(ops...).Commit(); err != nil {
+		return
+	}
+	if !txnResp.Succeeded {
+		err = common.ERR_APPLY_CONFLICT
+		return
+	}
+	result.Applied = true
+	result.Revision = txnResp.Header.Revision
+	// 和删除任务一样，被清理的任务日志也一起删掉；事务已经提交，删除日志失败只记录，等保留策略清理
+	for _, change = range result.Changes {
+		if change.Action != common.JOB_CHANGE_DELETE {
+			continue
+		}
+		if _, err = G_logMgr.PurgeLog(change.Name); err != nil {
+			jobMgrLog.WithError(err).WithField("job", change.Name).Warn("删除被清理任务的日志失败")
+			err = nil
+		}
+	}
+	return
+}
+
+// 导出任务，namespace为空时导出所有命名空间，一次Get读出同一个revision的快照
+func (jobMgr *JobMgr) ExportJobs(namespace string) (archive *common.JobArchive, err error) {
+	var (
+		dirKey  string
+		getResp *clientv3.GetResponse
+		kvPair  *mvccpb.KeyValue
+		job     *common.Job
+	)
+	dirKey = common.JOB_SAVE_DIR
+	if namespace != "" {
+		dirKey = common.JOB_SAVE_DIR + namespace + "/"
+	}
+	if getResp, err = jobMgr.kv.Get(context.TODO(), dirKey, clientv3.WithPrefix()); err != nil {
+		return
+	}
+	archive = &common.JobArchive{
+		Version:    common.JOB_ARCHIVE_VERSION,
+		ExportTime: time.Now().UnixNano() / 1000 / 1000,
+		Revision:   getResp.Header.Revision,
+		Jobs:       make([]*common.JobArchiveEntry, 0, len(getResp.Kvs)),
+	}
+	for _, kvPair = range getResp.Kvs {
+		if job, err = common.UnpackJob(kvPair.Value); err != nil {
+			err = nil
+			continue // 忽视反序列化错误
+		}
+		archive.Jobs = append(archive.Jobs, &common.JobArchiveEntry{
+			Job:            job,
+			CreateRevision: kvPair.CreateRevision,
+			ModRevision:    kvPair.ModRevision,
+			Version:        kvPair.Version,
+		})
+	}
+	return
+}
+
+// 从备份恢复任务，不删除备份中没有的任务
+// 已存在且内容不同的任务按mode处理：skip保留原任务，overwrite覆盖，fail整体失败
+// 基于ApplyManifest实现，所有写入在一个事务中完成，任务的创建和修改时间沿用备份中的时间
+func (jobMgr *JobMgr) ImportJobs(archive *common.JobArchive, mode string) (result *common.JobImportResult, err error) {
+	var (
+		entry       *common.JobArchiveEntry
+		manifest    *common.JobManifest
+		applyResult *common.ApplyResult
+		change      *common.JobChange
+		existing    map[string]bool // 已存在且内容不同的任务，任务全名
+		conflicts   []string
+		jobs        []*common.Job
+		job         *common.Job
+	)
+	switch mode {
+	case common.JOB_IMPORT_MODE_SKIP, common.JOB_IMPORT_MODE_OVERWRITE, common.JOB_IMPORT_MODE_FAIL:
+	default:
+		err = common.ERR_INVALID_IMPORT_MODE
+		return
+	}
+	manifest = &common.JobManifest{Version: common.JOB_MANIFEST_VERSION, Jobs: make([]*common.Job, 0, len(archive.Jobs))}
+	for _, entry = range archive.Jobs {
+		if entry.Job != nil {
+			manifest.Jobs = append(manifest.Jobs, entry.Job)
+		}
+	}
+
+	// 先计算变更，找出冲突的任务
+	if applyResult, err = jobMgr.applyManifest(manifest, &applyOptions{dryRun: true, preserveTimes: true}); err != nil {
+		return
+	}
+	existing = make(map[string]bool)
+	conflicts = make([]string, 0)
+	for _, change = range applyResult.Changes {
+		if change.Action == common.JOB_CHANGE_UPDATE {
+			existing[change.Name] = true
+			conflicts = append(conflicts, change.Name)
+		}
+	}
+	if mode == common.JOB_IMPORT_MODE_FAIL && len(conflicts) > 0 {
+		err = fmt.Errorf("%w: %s", common.ERR_JOB_IMPORT_CONFLICT, strings.Join(conflicts, ", "))
+		return
+	}
+	if mode == common.JOB_IMPORT_MODE_SKIP {
+		jobs = make([]*common.Job, 0, len(manifest.Jobs))
+		for _, job = range manifest.Jobs {
+			if !existing[job.FullName()] {
+				jobs = append(jobs, job)
+			}
+		}
+		manifest.Jobs = jobs
+	}
+
+	// 两次调用之间有任务被修改时，事务的版本检查会让写入失败
+	if applyResult, err = jobMgr.applyManifest(manifest, &applyOptions{preserveTimes: true}); err != nil {
+		return
+	}
+	result = &common.JobImportResult{
+		Mode:        mode,
+		Created:     make([]string, 0),
+		Overwritten: make([]string, 0),
+		Skipped:     make([]string, 0),
+		Unchanged:   applyResult.Unchanged,
+		Revision:    applyResult.Revision,
+	}
+	for _, change = range applyResult.Changes {
+		switch change.Action {
+		case common.JOB_CHANGE_CREATE:
+			result.Created = append(result.Created, change.Name)
+		case common.JOB_CHANGE_UPDATE:
+			result.Overwritten = append(result.Overwritten, change.Name)
+		}
+	}
+	if mode == common.JOB_IMPORT_MODE_SKIP {
+		result.Skipped = conflicts
+	}
+	return
+}
+
 var (
 	// 单例
 	G_jobMgr *JobMgr
+
+	jobMgrLog = logger.Component("jobMgr")
 )
 
 // 初始化任务管理器
@@ -140,7 +720,7 @@ func InitJobMgr() (err error) {
 		return
 	}
 	// 得到KV和lease的API子集
-	kv = clientv3.NewKV(client)
+	kv = &metricKV{KV: clientv3.NewKV(client)} // 统计etcd错误次数
 	lease = clientv3.NewLease(client)
 
 	// 赋值单例
@@ -0,0 +1,46 @@
+package master
+
+import (
+	"../common"
+	"testing"
+)
+
+func TestJobTimes(t *testing.T) {
+	var (
+		oldJob = &common.Job{CreateTime: 100, UpdateTime: 200}
+		cases  []struct {
+			name       string
+			job        *common.Job
+			oldJob     *common.Job
+			keep       bool
+			createTime int64
+			updateTime int64
+		}
+		i int
+	)
+	cases = []struct {
+		name       string
+		job        *common.Job
+		oldJob     *common.Job
+		keep       bool
+		createTime int64
+		updateTime int64
+	}{
+		{"新建", &common.Job{CreateTime: 1, UpdateTime: 2}, nil, false, 1000, 1000},
+		{"修改沿用原来的创建时间", &common.Job{}, oldJob, false, 100, 1000},
+		{"恢复备份沿用备份的时间", &common.Job{CreateTime: 10, UpdateTime: 20}, oldJob, true, 10, 20},
+		{"备份中没有修改时间", &common.Job{CreateTime: 10}, nil, true, 10, 10},
+		{"老备份没有时间按新建处理", &common.Job{}, nil, true, 1000, 1000},
+		{"老备份没有时间按修改处理", &common.Job{}, oldJob, true, 100, 1000},
+	}
+	for i = range cases {
+		if cases[i].keep {
+			keepJobTimes(cases[i].job, cases[i].oldJob, 1000)
+		} else {
+			stampJob(cases[i].job, cases[i].oldJob, 1000)
+		}
+		if cases[i].job.CreateTime != cases[i].createTime || cases[i].job.UpdateTime != cases[i].updateTime {
+			t.Errorf("%s: 时间 %d/%d，期望 %d/%d", cases[i].name, cases[i].job.CreateTime, cases[i].job.UpdateTime, cases[i].createTime, cases[i].updateTime)
+		}
+	}
+}
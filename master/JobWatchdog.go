@@ -0,0 +1,201 @@
+package master
+
+import (
+	"../common"
+	"../logger"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 任务成功心跳检查（dead man's switch）
+// 任务配置了expectSuccessWithin后，超过这个时间没有成功执行就告警，
+// 覆盖暂停、所有节点都跳过、cron表达式配错等不会产生失败日志的情况
+// 每个master都检查，接口可以查询检查状态，告警通过通知模块只由leader发送
+type JobWatchdog struct {
+	lock      sync.RWMutex
+	states    map[string]*common.JobWatchdogState // 任务全名 -> 检查状态
+	firstSeen map[string]int64                    // 第一次检查到该任务的时间，毫秒，没有创建时间的老任务从这个时间开始计算
+	seeded    bool                                // 已经完成第一轮检查，之后的状态变化才通知
+}
+
+// 最近一次成功执行的日志
+func (watchdog *JobWatchdog) lastSuccessLog(name string) (jobLog *common.JobLog, err error) {
+	var (
+		logArr []*common.JobLog
+	)
+	if logArr, err = G_logMgr.store.Query(&common.JobLogQuery{
+		Filter:    common.JobLogFilter{JobName: name, Status: common.LOG_STATUS_SUCCESS},
+		SortField: common.LOG_SORT_START_TIME,
+		SortOrder: -1,
+		Limit:     1,
+	}); err != nil || len(logArr) == 0 {
+		return
+	}
+	jobLog = logArr[0]
+	return
+}
+
+// 检查一轮
+func (watchdog *JobWatchdog) check() {
+	var (
+		jobList   []*common.Job
+		job       *common.Job
+		jobLog    *common.JobLog
+		states    map[string]*common.JobWatchdogState
+		state     *common.JobWatchdogState
+		oldState  *common.JobWatchdogState
+		now       int64
+		seen      bool
+		jobExists map[string]bool
+		name      string
+		err       error
+	)
+	if jobList, err = G_jobMgr.ListJob(""); err != nil {
+		watchdogLog.WithError(err).Warn("检查任务心跳时获取任务列表失败")
+		return
+	}
+	now = time.Now().UnixNano() / 1000 / 1000
+	states = make(map[string]*common.JobWatchdogState)
+	jobExists = make(map[string]bool)
+	for _, job = range jobList {
+		jobExists[job.FullName()] = true
+		if job.ExpectSuccessWithin <= 0 {
+			continue
+		}
+		watchdog.lock.RLock()
+		oldState = watchdog.states[job.FullName()]
+		watchdog.lock.RUnlock()
+		if _, seen = watchdog.firstSeen[job.FullName()]; !seen {
+			watchdog.firstSeen[job.FullName()] = now
+		}
+		if jobLog, err = watchdog.lastSuccessLog(job.FullName()); err != nil {
+			watchdogLog.WithError(err).WithField("job", job.FullName()).Warn("查询最近一次成功日志失败")
+			// 查询失败时保留上一次的状态
+			if oldState != nil {
+				states[job.FullName()] = oldState
+			}
+			continue
+		}
+
+		state = watchdogState(job, jobLog, watchdog.firstSeen[job.FullName()], now)
+		states[job.FullName()] = state
+
+		// 重启后第一轮只记录状态，重启前已经告警过的任务不再重复告警
+		if !watchdog.seeded {
+			if state.Overdue {
+				watchdogLog.WithField("job", job.FullName()).Info("启动时任务已超时未成功，不重复告警")
+			}
+			continue
+		}
+		// 状态变化时通知
+		if state.Overdue && (oldState == nil || !oldState.Overdue) {
+			watchdog.notify(job, common.NOTIFY_EVENT_OVERDUE, state, nil)
+		} else if !state.Overdue && oldState != nil && oldState.Overdue {
+			watchdog.notify(job, common.NOTIFY_EVENT_OVERDUE_RECOVERY, state, jobLog)
+		}
+	}
+	// 任务已删除
+	for name = range watchdog.firstSeen {
+		if !jobExists[name] {
+			delete(watchdog.firstSeen, name)
+		}
+	}
+
+	watchdog.lock.Lock()
+	watchdog.states = states
+	watchdog.lock.Unlock()
+	watchdog.seeded = true
+}
+
+// 根据最近一次成功的日志计算任务的检查状态
+// 从最近一次成功开始算，从来没成功过则从任务创建时开始算，没有创建时间的老任务从第一次检查到它开始算
+func watchdogState(job *common.Job, lastSuccess *common.JobLog, firstSeen int64, now int64) (state *common.JobWatchdogState) {
+	var (
+		since int64
+	)
+	state = &common.JobWatchdogState{
+		ExpectSuccessWithin: job.ExpectSuccessWithin,
+		CheckTime:           now,
+	}
+	if since = job.CreateTime; since <= 0 {
+		since = firstSeen
+	}
+	if lastSuccess != nil {
+		state.LastSuccessTime = lastSuccess.EndTime
+		if lastSuccess.EndTime > since {
+			since = lastSuccess.EndTime
+		}
+	}
+	if now-since > job.ExpectSuccessWithin*1000 {
+		state.Overdue = true
+		state.OverdueSince = since + job.ExpectSuccessWithin*1000
+	}
+	return
+}
+
+// 通过通知模块告警
+func (watchdog *JobWatchdog) notify(job *common.Job, event string, state *common.JobWatchdogState, jobLog *common.JobLog) {
+	var (
+		message     string
+		lastSuccess string
+		targetNames []string
+	)
+	switch event {
+	case common.NOTIFY_EVENT_OVERDUE:
+		lastSuccess = "从未成功"
+		if state.LastSuccessTime > 0 {
+			lastSuccess = "最近一次成功于 " + formatMillis(state.LastSuccessTime)
+		}
+		message = fmt.Sprintf("任务 %s 已超过 %s 没有成功执行，%s", job.FullName(), time.Duration(job.ExpectSuccessWithin)*time.Second, lastSuccess)
+	case common.NOTIFY_EVENT_OVERDUE_RECOVERY:
+		message = fmt.Sprintf("任务 %s 已重新成功执行", job.FullName())
+	}
+	watchdogLog.WithField("job", job.FullName()).Warn(message)
+	if job.Notify != nil {
+		targetNames = job.Notify.Targets
+	}
+	G_notifyMgr.Notify(&common.Notification{
+		Event:   event,
+		JobName: job.FullName(),
+		Message: message,
+		Log:     jobLog,
+		Time:    state.CheckTime,
+	}, targetNames)
+}
+
+// 任务的检查状态，没有配置expectSuccessWithin或者还没检查过则返回nil
+// 每轮检查都生成新的状态整体替换，已发布的状态不会再被修改，可以直接返回
+func (watchdog *JobWatchdog) State(name string) (state *common.JobWatchdogState) {
+	watchdog.lock.RLock()
+	defer watchdog.lock.RUnlock()
+	return watchdog.states[name]
+}
+
+// 检查协程
+func (watchdog *JobWatchdog) checkLoop() {
+	var (
+		checkTicker *time.Ticker
+	)
+	checkTicker = time.NewTicker(time.Duration(G_config.WatchdogInterval) * time.Millisecond)
+	for {
+		watchdog.check()
+		<-checkTicker.C
+	}
+}
+
+var (
+	G_jobWatchdog *JobWatchdog
+
+	watchdogLog = logger.Component("watchdog")
+)
+
+// 初始化任务心跳检查，依赖任务管理器、日志管理器和通知管理器
+func InitJobWatchdog() (err error) {
+	G_jobWatchdog = &JobWatchdog{
+		states:    make(map[string]*common.JobWatchdogState),
+		firstSeen: make(map[string]int64),
+	}
+	go G_jobWatchdog.checkLoop()
+	return
+}
@@ -0,0 +1,39 @@
+package master
+
+import (
+	"../common"
+	"testing"
+)
+
+func TestWatchdogState(t *testing.T) {
+	var (
+		cases = []struct {
+			name         string
+			createTime   int64
+			lastSuccess  *common.JobLog
+			firstSeen    int64
+			now          int64
+			overdue      bool
+			overdueSince int64
+		}{
+			{"新任务还没到期", 100000, nil, 500000, 150000, false, 0},
+			{"从未成功从创建时间算", 100000, nil, 500000, 170000, true, 160000},
+			{"重启后不从第一次检查算", 100000, nil, 900000, 900000, true, 160000},
+			{"没有创建时间的老任务从第一次检查算", 0, nil, 500000, 550000, false, 0},
+			{"没有创建时间的老任务超时", 0, nil, 500000, 570000, true, 560000},
+			{"最近成功过", 100000, &common.JobLog{EndTime: 300000}, 0, 350000, false, 0},
+			{"最近一次成功之后超时", 100000, &common.JobLog{EndTime: 300000}, 0, 361000, true, 360000},
+		}
+		state *common.JobWatchdogState
+		i     int
+	)
+	for i = range cases {
+		state = watchdogState(&common.Job{Name: "job1", CreateTime: cases[i].createTime, ExpectSuccessWithin: 60}, cases[i].lastSuccess, cases[i].firstSeen, cases[i].now)
+		if state.Overdue != cases[i].overdue || state.OverdueSince != cases[i].overdueSince || state.CheckTime != cases[i].now {
+			t.Errorf("%s: 得到 %+v", cases[i].name, state)
+		}
+		if cases[i].lastSuccess != nil && state.LastSuccessTime != cases[i].lastSuccess.EndTime {
+			t.Errorf("%s: 最近一次成功时间 %d", cases[i].name, state.LastSuccessTime)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package master
+
+import (
+	"../common"
+	"../logger"
+	"context"
+	"fmt"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/clientv3/concurrency"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// master选主
+// 多个master同时运行时，执行结果通知和任务心跳告警只能由一个master发送，否则会重复通知
+// 通过etcd的选举竞争 /cron/election/master，当选的master在会话租约失效前一直是leader
+type Leader struct {
+	client  *clientv3.Client
+	id      string // 本master的标识 主机名-进程号
+	leading int32  // 当前是否是leader，通知模块并发读取
+
+	lock     sync.Mutex
+	election *concurrency.Election // 当选后保存，退出时主动放弃
+}
+
+// 当前是否是leader
+func (leader *Leader) IsLeader() bool {
+	return atomic.LoadInt32(&leader.leading) == 1
+}
+
+// 选举协程：当选后一直保持，会话租约失效（和etcd断开超过electionTtl）后重新参加选举
+func (leader *Leader) campaignLoop() {
+	var (
+		session    *concurrency.Session
+		election   *concurrency.Election
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+		err        error
+	)
+	for {
+		if session, err = concurrency.NewSession(leader.client, concurrency.WithTTL(G_config.ElectionTTL)); err != nil {
+			leaderLog.WithError(err).Warn("创建选举会话失败，稍后重试")
+			time.Sleep(time.Second)
+			continue
+		}
+		election = concurrency.NewElection(session, common.MASTER_ELECTION_KEY)
+		// 阻塞直到当选，等待期间会话失效则放弃这次选举
+		ctx, cancelFunc = context.WithCancel(context.TODO())
+		go func(done <-chan struct{}, ctx context.Context, cancelFunc context.CancelFunc) {
+			select {
+			case <-done:
+				cancelFunc()
+			case <-ctx.Done():
+			}
+		}(session.Done(), ctx, cancelFunc)
+		err = election.Campaign(ctx, leader.id)
+		cancelFunc()
+		if err != nil {
+			leaderLog.WithError(err).Warn("参加选举失败，稍后重试")
+			session.Close()
+			time.Sleep(time.Second)
+			continue
+		}
+
+		leader.lock.Lock()
+		leader.election = election
+		leader.lock.Unlock()
+		atomic.StoreInt32(&leader.leading, 1)
+		leaderLog.WithField("id", leader.id).Info("当选leader，开始发送通知")
+
+		<-session.Done()
+		atomic.StoreInt32(&leader.leading, 0)
+		leader.lock.Lock()
+		leader.election = nil
+		leader.lock.Unlock()
+		leaderLog.WithField("id", leader.id).Warn("失去leader身份，停止发送通知")
+	}
+}
+
+// 退出时主动放弃leader身份，其他master不用等租约过期就能接替
+func (leader *Leader) resign(ctx context.Context) {
+	var (
+		err error
+	)
+	leader.lock.Lock()
+	defer leader.lock.Unlock()
+	if leader.election == nil {
+		return
+	}
+	atomic.StoreInt32(&leader.leading, 0)
+	if err = leader.election.Resign(ctx); err != nil {
+		leaderLog.WithError(err).Warn("放弃leader身份失败，等待租约过期")
+	}
+	leader.election = nil
+}
+
+var (
+	G_leader *Leader
+
+	leaderLog = logger.Component("leader")
+)
+
+// 初始化选主，在通知管理器和任务心跳检查之前
+func InitLeader() (err error) {
+	var (
+		config   clientv3.Config
+		client   *clientv3.Client
+		hostname string
+	)
+	config = clientv3.Config{
+		Endpoints:   G_config.EtcdEndPoints,
+		DialTimeout: time.Duration(G_config.EtcdDialTimeout) * time.Millisecond,
+	}
+	if client, err = clientv3.New(config); err != nil {
+		return
+	}
+	if hostname, err = os.Hostname(); err != nil {
+		return
+	}
+	G_leader = &Leader{
+		client: client,
+		id:     fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+	go G_leader.campaignLoop()
+	return
+}
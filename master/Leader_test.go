@@ -0,0 +1,27 @@
+package master
+
+import (
+	"../common"
+	"testing"
+)
+
+func TestNotifyOnlyOnLeader(t *testing.T) {
+	var (
+		oldLeader = G_leader
+		notifyMgr = &NotifyMgr{
+			targetNames: []string{"ops"},
+			notifyChan:  make(chan *notifyTask, 10),
+		}
+	)
+	defer func() { G_leader = oldLeader }()
+	G_leader = &Leader{}
+	notifyMgr.Notify(&common.Notification{JobName: "a/job1"}, nil)
+	if len(notifyMgr.notifyChan) != 0 {
+		t.Fatal("不是leader不应该发送通知")
+	}
+	G_leader.leading = 1
+	notifyMgr.Notify(&common.Notification{JobName: "a/job1"}, nil)
+	if len(notifyMgr.notifyChan) != 1 {
+		t.Fatal("leader应该发送通知")
+	}
+}
@@ -0,0 +1,100 @@
+package master
+
+import (
+	"../common"
+	"../logger"
+	"context"
+	"go.etcd.io/etcd/clientv3"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// 进程退出中，readyz返回失败
+	shuttingDown int32
+
+	// 退出时关闭，通知事件流等长连接结束
+	shutdownChan = make(chan struct{})
+
+	lifecycleLog = logger.Component("lifecycle")
+)
+
+// 存活检查：进程能响应就是存活的
+// get /healthz
+func handleHealthz(resp http.ResponseWriter, req *http.Request) {
+	var (
+		bytes []byte
+		err   error
+	)
+	if bytes, err = common.BuildResponse(0, "success", nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 就绪检查：etcd和日志存储都可达，并且没有在退出，不就绪时返回503
+// get /readyz
+func handleReadyz(resp http.ResponseWriter, req *http.Request) {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+		checks     map[string]string
+		ready      bool
+		err        error
+		bytes      []byte
+	)
+	ctx, cancelFunc = context.WithTimeout(context.TODO(), 2*time.Second)
+	defer cancelFunc()
+
+	checks = make(map[string]string)
+	ready = true
+	if atomic.LoadInt32(&shuttingDown) == 1 {
+		checks["shutdown"] = "正在退出"
+		ready = false
+	}
+	checks["etcd"] = "ok"
+	if _, err = G_jobMgr.kv.Get(ctx, common.JOB_SAVE_DIR, clientv3.WithPrefix(), clientv3.WithCountOnly()); err != nil {
+		checks["etcd"] = err.Error()
+		ready = false
+	}
+	checks["logStore"] = "ok"
+	if err = G_logMgr.store.Ping(ctx); err != nil {
+		checks["logStore"] = err.Error()
+		ready = false
+	}
+
+	if !ready {
+		resp.WriteHeader(http.StatusServiceUnavailable)
+		if bytes, err = common.BuildResponse(-1, "not ready", checks); err == nil {
+			resp.Write(bytes)
+		}
+		return
+	}
+	if bytes, err = common.BuildResponse(0, "success", checks); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 优雅退出：停止接收新请求，等待正在处理的请求结束，再关闭日志存储
+func Shutdown() {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+		err        error
+	)
+	atomic.StoreInt32(&shuttingDown, 1)
+	close(shutdownChan)
+	ctx, cancelFunc = context.WithTimeout(context.TODO(), time.Duration(G_config.ShutdownTimeout)*time.Millisecond)
+	defer cancelFunc()
+	if err = G_apiServer.httpServer.Shutdown(ctx); err != nil {
+		lifecycleLog.WithError(err).Warn("等待请求处理结束超时")
+	}
+	if G_grpcServer != nil {
+		G_grpcServer.shutdown(ctx)
+	}
+	G_leader.resign(ctx)
+	if err = G_logMgr.store.Close(); err != nil {
+		lifecycleLog.WithError(err).Warn("关闭日志存储失败")
+	}
+	lifecycleLog.Info("退出完成")
+}
@@ -2,47 +2,229 @@ package master
 
 import (
 	"../common"
-	"context"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"../logstore"
+	"math"
+	"sort"
 	"time"
 )
 
 type LogMgr struct {
-	client        *mongo.Client
-	logCollection *mongo.Collection
+	store logstore.LogStore // 日志存储，由配置决定
 }
 
-func (logMgr *LogMgr) ListLog(name string, skip int64, limit int64) (logArr []*common.JobLog, err error) {
+// 按条件分页查询日志，同时返回符合条件的总条数
+func (logMgr *LogMgr) ListLog(filter *common.JobLogFilter, sortParam string, skip int64, limit int64) (logPage *common.JobLogPage, err error) {
 	var (
-		filter  *common.JobLogFilter
-		logSort *common.SortLogByStartTime
-		cursor  *mongo.Cursor
-		jobLog  *common.JobLog
+		sortField string
+		sortOrder int
 	)
-	logArr = make([]*common.JobLog, 0) // 初始化 len()=0
-	// 过滤条件
-	filter = &common.JobLogFilter{JobName: name}
-	// 按照任务开始时间倒排
-	logSort = &common.SortLogByStartTime{SortOrder: -1}
+	if err = filter.Validate(); err != nil {
+		return
+	}
+	// 默认按照任务开始时间倒排
+	if sortField, sortOrder, err = common.ParseLogSort(sortParam); err != nil {
+		return
+	}
+	logPage = &common.JobLogPage{}
+	if logPage.Total, err = logMgr.store.Count(filter); err != nil {
+		return
+	}
+	logPage.Logs, err = logMgr.store.Query(&common.JobLogQuery{
+		Filter:    *filter,
+		SortField: sortField,
+		SortOrder: sortOrder,
+		Skip:      skip,
+		Limit:     limit,
+	})
+	return
+}
+
+// 广播任务按调度时间点(planTime)聚合，每个时间点返回各个worker的执行结果
+func (logMgr *LogMgr) ListBroadcastTicks(name string, skip int64, limit int64) (tickArr []*common.BroadcastTick, err error) {
+	var (
+		query     *common.JobLogQuery
+		logArr    []*common.JobLog
+		jobLog    *common.JobLog
+		tick      *common.BroadcastTick
+		tickCount int64 // 已经遇到的时间点个数
+	)
+	tickArr = make([]*common.BroadcastTick, 0)
+	// 和接口的默认值一致
+	if skip < 0 {
+		skip = 0
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	// 同一次调度在所有节点上的planTime相同，按planTime倒序分页读取日志，相邻的同planTime日志归为一个时间点
+	// 存储在planTime相同时还会按节点或主键排序，跨页的时间点不会重复或者丢失
+	query = &common.JobLogQuery{
+		Filter:    common.JobLogFilter{JobName: name},
+		SortField: common.LOG_SORT_PLAN_TIME,
+		SortOrder: -1,
+		Limit:     500,
+	}
+	for {
+		if logArr, err = logMgr.store.Query(query); err != nil {
+			return
+		}
+		for _, jobLog = range logArr {
+			if tick == nil || tick.PlanTime != jobLog.PlanTime {
+				// 已经凑够了limit个时间点，最后一个时间点也已经读完整
+				if int64(len(tickArr)) >= limit {
+					return
+				}
+				tickCount++
+				tick = &common.BroadcastTick{PlanTime: jobLog.PlanTime}
+				if tickCount > skip {
+					tickArr = append(tickArr, tick)
+				}
+			}
+			tick.Workers = append(tick.Workers, &common.BroadcastWorkerResult{
+				Worker:    jobLog.Worker,
+				Err:       jobLog.Err,
+				StartTime: jobLog.StartTime,
+				EndTime:   jobLog.EndTime,
+			})
+			if jobLog.Err == "" {
+				tick.SuccessCount++
+			} else {
+				tick.FailCount++
+			}
+		}
+		// 没有更多日志了
+		if int64(len(logArr)) < query.Limit {
+			return
+		}
+		query.Skip += query.Limit
+	}
+}
 
-	// 发起查询
-	if cursor, err = logMgr.logCollection.Find(context.TODO(), filter, &options.FindOptions{
-		Limit: &limit,
-		Skip:  &skip,
-		Sort:  logSort,
+// 统计任务的日志量
+func (logMgr *LogMgr) JobLogStats(name string, retention *common.LogRetention) (stats *common.JobLogStats, err error) {
+	var (
+		logArr []*common.JobLog
+	)
+	stats = &common.JobLogStats{
+		JobName:   name,
+		Retention: retention,
+	}
+	if stats.Count, err = logMgr.store.Count(&common.JobLogFilter{JobName: name}); err != nil || stats.Count == 0 {
+		return
+	}
+	// 最早和最新的一条日志
+	if logArr, err = logMgr.store.Query(&common.JobLogQuery{
+		Filter:    common.JobLogFilter{JobName: name},
+		SortField: common.LOG_SORT_START_TIME,
+		SortOrder: 1,
+		Limit:     1,
 	}); err != nil {
 		return
 	}
-	defer cursor.Close(context.TODO()) // 延迟释放游标
+	if len(logArr) != 0 {
+		stats.OldestTime = logArr[0].StartTime
+	}
+	if logArr, err = logMgr.store.Query(&common.JobLogQuery{
+		Filter:    common.JobLogFilter{JobName: name},
+		SortField: common.LOG_SORT_START_TIME,
+		SortOrder: -1,
+		Limit:     1,
+	}); err != nil {
+		return
+	}
+	if len(logArr) != 0 {
+		stats.NewestTime = logArr[0].StartTime
+	}
+	return
+}
+
+// 删除任务的全部日志
+func (logMgr *LogMgr) PurgeLog(name string) (deleted int64, err error) {
+	// 任务名为空会删除所有任务的日志
+	if name == "" {
+		err = common.ERR_JOB_NAME_REQUIRED
+		return
+	}
+	return logMgr.store.DeleteBefore(name, math.MaxInt64)
+}
+
+// 按保留策略清理单个任务的日志
+func (logMgr *LogMgr) PruneLog(name string, retention *common.LogRetention) (deleted int64, err error) {
+	var (
+		logArr []*common.JobLog
+		count  int64
+	)
+	// 超过最长保留时间
+	if retention.MaxAge > 0 {
+		if deleted, err = logMgr.store.DeleteBefore(name, time.Now().Add(-time.Duration(retention.MaxAge)*time.Second).UnixNano()/1000/1000); err != nil {
+			return
+		}
+	}
+	// 超过最多保留条数：找到第maxCount+1新的日志，删除开始时间比它早的日志
+	// 和它同一毫秒开始的日志可能在保留范围内，先留下，下一轮清理时再删
+	if retention.MaxCount > 0 {
+		if logArr, err = logMgr.store.Query(&common.JobLogQuery{
+			Filter:    common.JobLogFilter{JobName: name},
+			SortField: common.LOG_SORT_START_TIME,
+			SortOrder: -1,
+			Skip:      retention.MaxCount,
+			Limit:     1,
+		}); err != nil || len(logArr) == 0 {
+			return
+		}
+		if count, err = logMgr.store.DeleteBefore(name, logArr[0].StartTime); err != nil {
+			return
+		}
+		deleted += count
+	}
+	return
+}
 
-	for cursor.Next(context.TODO()) {
-		jobLog = &common.JobLog{}
-		// 反序列化
-		if err = cursor.Decode(jobLog); err != nil {
-			continue // 有日志不合格
+// 统计任务从since(毫秒)开始的执行情况，在日志存储中聚合
+func (logMgr *LogMgr) JobStats(name string, since int64) (stats *common.JobStats, err error) {
+	var (
+		statsArr []*common.JobStats
+	)
+	if statsArr, err = logMgr.store.Stats(&common.JobLogFilter{JobName: name, StartFrom: since}); err != nil {
+		return
+	}
+	if len(statsArr) == 0 {
+		return &common.JobStats{JobName: name, SuccessRate: 1}, nil
+	}
+	return statsArr[0], nil
+}
+
+// 集群中表现最差的任务：成功率低的在前，成功率相同时95分位耗时长的在前
+// 按命名空间一次聚合所有任务，只保留nameArr中的任务，没有日志的任务成功率记为1
+func (logMgr *LogMgr) WorstJobStats(namespace string, nameArr []string, since int64, top int) (statsArr []*common.JobStats, err error) {
+	var (
+		allStats []*common.JobStats
+		statsMap map[string]*common.JobStats
+		stats    *common.JobStats
+		name     string
+	)
+	if allStats, err = logMgr.store.Stats(&common.JobLogFilter{Namespace: namespace, StartFrom: since}); err != nil {
+		return
+	}
+	statsMap = make(map[string]*common.JobStats, len(allStats))
+	for _, stats = range allStats {
+		statsMap[stats.JobName] = stats
+	}
+	statsArr = make([]*common.JobStats, 0, len(nameArr))
+	for _, name = range nameArr {
+		if stats = statsMap[name]; stats == nil {
+			stats = &common.JobStats{JobName: name, SuccessRate: 1}
+		}
+		statsArr = append(statsArr, stats)
+	}
+	sort.SliceStable(statsArr, func(i, j int) bool {
+		if statsArr[i].SuccessRate != statsArr[j].SuccessRate {
+			return statsArr[i].SuccessRate < statsArr[j].SuccessRate
 		}
-		logArr = append(logArr, jobLog)
+		return statsArr[i].DurationP95 > statsArr[j].DurationP95
+	})
+	if top > 0 && len(statsArr) > top {
+		statsArr = statsArr[:top]
 	}
 	return
 }
@@ -53,17 +235,19 @@ var (
 
 func InitLogMgr() (err error) {
 	var (
-		client *mongo.Client
+		store logstore.LogStore
 	)
-	// 1.建立连接
-	ctx, _ := context.WithTimeout(context.Background(), time.Duration(G_config.MongodbConnectTimeout)*time.Millisecond)
-	if client, err = mongo.Connect(ctx, &options.ClientOptions{Hosts: []string{G_config.MongodbUri}}); err != nil {
+	// 按配置创建日志存储
+	if store, err = logstore.NewLogStore(G_config.LogStoreConfig()); err != nil {
 		return
 	}
+	// 统计日志存储的错误次数
+	store = logstore.WithErrorHook(store, func(err error) {
+		metricLogStoreErrors.WithLabelValues(G_config.LogStore).Inc()
+	})
 
 	G_logMgr = &LogMgr{
-		client:        client,
-		logCollection: client.Database("my_db").Collection("my_collection"),
+		store: store,
 	}
 
 	return
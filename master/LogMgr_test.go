@@ -0,0 +1,70 @@
+package master
+
+import (
+	"../common"
+	"../logstore"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 广播任务的日志超过一页时，跨页的时间点不能重复或者丢失
+func TestListBroadcastTicks(t *testing.T) {
+	var (
+		dir     string
+		store   *logstore.FileStore
+		logMgr  *LogMgr
+		logArr  []*common.JobLog
+		jobLog  *common.JobLog
+		tickArr []*common.BroadcastTick
+		i       int
+		err     error
+	)
+	if dir, err = ioutil.TempDir("", "logmgr"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if store, err = logstore.NewFileStore(&logstore.Config{Path: filepath.Join(dir, "job.log")}); err != nil {
+		t.Fatal(err)
+	}
+	// 3个节点执行了300次，共900条日志，每一页500条的边界落在一个时间点中间
+	for i = 0; i < 900; i++ {
+		jobLog = &common.JobLog{
+			JobName:  "a/job1",
+			ExecId:   fmt.Sprint(i / 3),
+			Worker:   fmt.Sprint("w", i%3),
+			PlanTime: int64(1000 + i/3),
+		}
+		if i%3 == 2 {
+			jobLog.Err = "exit status 1"
+		}
+		logArr = append(logArr, jobLog)
+	}
+	if err = store.Append(logArr); err != nil {
+		t.Fatal(err)
+	}
+	logMgr = &LogMgr{store: store}
+
+	if tickArr, err = logMgr.ListBroadcastTicks("a/job1", 0, 300); err != nil {
+		t.Fatal(err)
+	}
+	if len(tickArr) != 300 {
+		t.Fatalf("返回了%d个时间点，期望300", len(tickArr))
+	}
+	for i = range tickArr {
+		if tickArr[i].PlanTime != int64(1299-i) || len(tickArr[i].Workers) != 3 || tickArr[i].SuccessCount != 2 || tickArr[i].FailCount != 1 {
+			t.Fatalf("第%d个时间点 = %+v", i, tickArr[i])
+		}
+	}
+
+	// 跳过跨页的时间点
+	if tickArr, err = logMgr.ListBroadcastTicks("a/job1", 166, 2); err != nil || len(tickArr) != 2 || tickArr[0].PlanTime != 1133 || len(tickArr[0].Workers) != 3 {
+		t.Errorf("分页 = %+v, %v", tickArr, err)
+	}
+	// limit为0时使用默认值
+	if tickArr, err = logMgr.ListBroadcastTicks("a/job1", 0, 0); err != nil || len(tickArr) != 20 {
+		t.Errorf("limit为0时返回了%d个时间点, %v", len(tickArr), err)
+	}
+}
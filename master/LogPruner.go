@@ -0,0 +1,113 @@
+package master
+
+import (
+	"../common"
+	"../logger"
+	"time"
+)
+
+// 按保留策略定期清理过期日志
+type LogPruner struct {
+	globalRetention *common.LogRetention // master.json中的全局保留策略
+}
+
+// 任务生效的保留策略：任务上配置了就用任务的，否则用全局的，-1表示不限制，生效后记为0
+func (logPruner *LogPruner) EffectiveRetention(job *common.Job) (retention *common.LogRetention) {
+	retention = &common.LogRetention{
+		MaxAge:   logPruner.globalRetention.MaxAge,
+		MaxCount: logPruner.globalRetention.MaxCount,
+	}
+	if job != nil && job.Retention != nil {
+		if job.Retention.MaxAge != 0 {
+			retention.MaxAge = job.Retention.MaxAge
+		}
+		if job.Retention.MaxCount != 0 {
+			retention.MaxCount = job.Retention.MaxCount
+		}
+	}
+	if retention.MaxAge < 0 {
+		retention.MaxAge = 0
+	}
+	if retention.MaxCount < 0 {
+		retention.MaxCount = 0
+	}
+	return
+}
+
+// 清理一轮
+func (logPruner *LogPruner) prune() {
+	var (
+		jobList []*common.Job
+		job     *common.Job
+		jobSet  map[string]bool
+		nameArr []string
+		name    string
+		deleted int64
+		total   int64
+		err     error
+	)
+	if jobList, err = G_jobMgr.ListJob(""); err != nil {
+		prunerLog.WithError(err).Error("清理日志时获取任务列表失败")
+		return
+	}
+	// 逐个任务按各自的策略清理
+	jobSet = make(map[string]bool, len(jobList))
+	for _, job = range jobList {
+		jobSet[job.FullName()] = true
+		if deleted, err = G_logMgr.PruneLog(job.FullName(), logPruner.EffectiveRetention(job)); err != nil {
+			prunerLog.WithError(err).WithField("job", job.FullName()).Error("清理任务日志失败")
+			continue
+		}
+		total += deleted
+	}
+	// 已删除任务遗留的日志（删除时指定了保留日志）：按全局策略清理，不影响不限制保留的任务
+	if logPruner.globalRetention.MaxAge > 0 || logPruner.globalRetention.MaxCount > 0 {
+		if nameArr, err = G_logMgr.store.JobNames(); err != nil {
+			prunerLog.WithError(err).Error("清理遗留日志时获取任务名称失败")
+			nameArr = nil
+		}
+		for _, name = range nameArr {
+			if jobSet[name] {
+				continue
+			}
+			if deleted, err = G_logMgr.PruneLog(name, logPruner.globalRetention); err != nil {
+				prunerLog.WithError(err).WithField("job", name).Error("清理遗留日志失败")
+				continue
+			}
+			total += deleted
+		}
+	}
+	if total > 0 {
+		prunerLog.WithField("deleted", total).Info("清理过期日志")
+	}
+}
+
+// 清理协程
+func (logPruner *LogPruner) pruneLoop() {
+	var (
+		pruneTicker *time.Ticker
+	)
+	pruneTicker = time.NewTicker(time.Duration(G_config.LogPruneInterval) * time.Millisecond)
+	for {
+		logPruner.prune()
+		<-pruneTicker.C
+	}
+}
+
+var (
+	G_logPruner *LogPruner
+
+	prunerLog = logger.Component("logPruner")
+)
+
+// 初始化日志清理，依赖任务管理器和日志管理器
+func InitLogPruner() (err error) {
+	G_logPruner = &LogPruner{
+		globalRetention: &common.LogRetention{
+			MaxAge:   G_config.LogRetentionMaxAge,
+			MaxCount: G_config.LogRetentionMaxCount,
+		},
+	}
+	go G_logPruner.pruneLoop()
+	return
+}
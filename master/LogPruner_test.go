@@ -0,0 +1,78 @@
+package master
+
+import (
+	"../common"
+	"../logstore"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEffectiveRetention(t *testing.T) {
+	var (
+		logPruner = &LogPruner{globalRetention: &common.LogRetention{MaxAge: 3600, MaxCount: 100}}
+		cases     = []struct {
+			name      string
+			retention *common.LogRetention
+			maxAge    int64
+			maxCount  int64
+		}{
+			{"没有配置用全局的", nil, 3600, 100},
+			{"0用全局的", &common.LogRetention{}, 3600, 100},
+			{"覆盖全局的", &common.LogRetention{MaxAge: 60, MaxCount: 1000}, 60, 1000},
+			{"只覆盖条数", &common.LogRetention{MaxCount: 10}, 3600, 10},
+			{"不限制时间", &common.LogRetention{MaxAge: common.LOG_RETENTION_UNLIMITED}, 0, 100},
+			{"都不限制", &common.LogRetention{MaxAge: common.LOG_RETENTION_UNLIMITED, MaxCount: common.LOG_RETENTION_UNLIMITED}, 0, 0},
+		}
+		retention *common.LogRetention
+		i         int
+	)
+	for i = range cases {
+		retention = logPruner.EffectiveRetention(&common.Job{Name: "job1", Retention: cases[i].retention})
+		if retention.MaxAge != cases[i].maxAge || retention.MaxCount != cases[i].maxCount {
+			t.Errorf("%s: 得到 %+v，期望 maxAge=%d maxCount=%d", cases[i].name, retention, cases[i].maxAge, cases[i].maxCount)
+		}
+	}
+	if retention = logPruner.EffectiveRetention(nil); retention.MaxAge != 3600 || retention.MaxCount != 100 {
+		t.Errorf("已删除的任务应该用全局策略: %+v", retention)
+	}
+}
+
+func TestPruneLogKeepsSameMillisecond(t *testing.T) {
+	var (
+		dir     string
+		store   *logstore.FileStore
+		logMgr  *LogMgr
+		deleted int64
+		logArr  []*common.JobLog
+		err     error
+	)
+	if dir, err = ioutil.TempDir("", "logpruner"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if store, err = logstore.NewFileStore(&logstore.Config{Path: filepath.Join(dir, "job.log")}); err != nil {
+		t.Fatal(err)
+	}
+	// 保留最新2条，第2新和第3新的日志在同一毫秒开始，不能把第2新的删掉
+	if err = store.Append([]*common.JobLog{
+		{JobName: "a/job1", ExecId: "1", StartTime: 100},
+		{JobName: "a/job1", ExecId: "2", StartTime: 200},
+		{JobName: "a/job1", ExecId: "3", StartTime: 200},
+		{JobName: "a/job1", ExecId: "4", StartTime: 300},
+		{JobName: "a/job2", ExecId: "5", StartTime: 100},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	logMgr = &LogMgr{store: store}
+	if deleted, err = logMgr.PruneLog("a/job1", &common.LogRetention{MaxCount: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if logArr, err = store.Query(&common.JobLogQuery{SortField: common.LOG_SORT_START_TIME, SortOrder: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 || len(logArr) != 4 || logArr[0].ExecId != "5" {
+		t.Errorf("删除了%d条，剩余%+v", deleted, logArr)
+	}
+}
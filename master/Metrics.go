@@ -0,0 +1,171 @@
+package master
+
+import (
+	"../common"
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.etcd.io/etcd/clientv3"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// prometheus监控指标，通过ApiServer的 /metrics 暴露
+var (
+	// api请求次数
+	metricApiRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "crontab_master_api_requests_total",
+		Help: "Number of API requests handled by the master.",
+	}, []string{"path", "code"})
+
+	// api请求耗时
+	metricApiDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "crontab_master_api_request_duration_seconds",
+		Help:    "Latency of API requests handled by the master.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"path"})
+
+	// etcd请求失败次数
+	metricEtcdErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "crontab_master_etcd_errors_total",
+		Help: "Number of failed etcd requests.",
+	})
+
+	// 日志存储请求失败次数
+	metricLogStoreErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "crontab_master_log_store_errors_total",
+		Help: "Number of failed log store requests.",
+	}, []string{"store"})
+
+	// 通知发送次数 success / failed
+	metricNotifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "crontab_master_notifications_total",
+		Help: "Number of notifications sent by target and result.",
+	}, []string{"target", "result"})
+)
+
+// 记录应答状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	recorder.status = status
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// http.ResponseController通过Unwrap找到原始的ResponseWriter，用于事件流的Flush
+func (recorder *statusRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+// 统计接口的请求次数和耗时
+func instrumentHandler(path string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(resp http.ResponseWriter, req *http.Request) {
+		var (
+			recorder  *statusRecorder
+			startTime time.Time
+		)
+		recorder = &statusRecorder{ResponseWriter: resp, status: http.StatusOK}
+		startTime = time.Now()
+		handler(recorder, req)
+		metricApiDuration.WithLabelValues(path).Observe(time.Since(startTime).Seconds())
+		metricApiRequests.WithLabelValues(path, strconv.Itoa(recorder.status)).Inc()
+	}
+}
+
+// 记录etcd错误
+func countEtcdError(err error) error {
+	if err != nil {
+		metricEtcdErrors.Inc()
+	}
+	return err
+}
+
+// 统计etcd请求失败次数的KV
+type metricKV struct {
+	clientv3.KV
+}
+
+func (kv *metricKV) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (resp *clientv3.PutResponse, err error) {
+	resp, err = kv.KV.Put(ctx, key, val, opts...)
+	countEtcdError(err)
+	return
+}
+
+func (kv *metricKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (resp *clientv3.GetResponse, err error) {
+	resp, err = kv.KV.Get(ctx, key, opts...)
+	countEtcdError(err)
+	return
+}
+
+func (kv *metricKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (resp *clientv3.DeleteResponse, err error) {
+	resp, err = kv.KV.Delete(ctx, key, opts...)
+	countEtcdError(err)
+	return
+}
+
+func (kv *metricKV) Txn(ctx context.Context) clientv3.Txn {
+	return &metricTxn{Txn: kv.KV.Txn(ctx)}
+}
+
+// 统计事务提交失败次数
+type metricTxn struct {
+	clientv3.Txn
+}
+
+func (txn *metricTxn) If(cs ...clientv3.Cmp) clientv3.Txn {
+	txn.Txn = txn.Txn.If(cs...)
+	return txn
+}
+
+func (txn *metricTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	txn.Txn = txn.Txn.Then(ops...)
+	return txn
+}
+
+func (txn *metricTxn) Else(ops ...clientv3.Op) clientv3.Txn {
+	txn.Txn = txn.Txn.Else(ops...)
+	return txn
+}
+
+func (txn *metricTxn) Commit() (resp *clientv3.TxnResponse, err error) {
+	resp, err = txn.Txn.Commit()
+	countEtcdError(err)
+	return
+}
+
+// 当前任务数，采集时实时从etcd统计
+func countJobs() float64 {
+	var (
+		getResp *clientv3.GetResponse
+		err     error
+	)
+	if G_jobMgr == nil {
+		return 0
+	}
+	if getResp, err = G_jobMgr.kv.Get(context.TODO(), common.JOB_SAVE_DIR, clientv3.WithPrefix(), clientv3.WithCountOnly()); err != nil {
+		return 0
+	}
+	return float64(getResp.Count)
+}
+
+// 注册监控指标
+func InitMetrics() (err error) {
+	prometheus.MustRegister(metricApiRequests, metricApiDuration, metricEtcdErrors, metricLogStoreErrors, metricNotifications)
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "crontab_master_jobs",
+		Help: "Number of jobs saved in etcd.",
+	}, countJobs))
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "crontab_master_leader",
+		Help: "Whether this master is the leader sending notifications (1) or not (0).",
+	}, func() float64 {
+		if G_leader == nil || !G_leader.IsLeader() {
+			return 0
+		}
+		return 1
+	}))
+	return
+}
@@ -0,0 +1,122 @@
+package master
+
+import (
+	"../common"
+	"../logger"
+	"context"
+	"encoding/json"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+	"strings"
+)
+
+var (
+	namespaceLog = logger.Component("namespace")
+)
+
+// 把升级前的任务 /cron/jobs/任务名 迁移到默认命名空间 /cron/jobs/default/任务名
+// 先修改日志中的任务名称再迁移etcd中的任务，中途失败重启后可以继续；多个master同时启动由事务保证只迁移一次
+func migrateLegacyJobs() (err error) {
+	var (
+		getResp    *clientv3.GetResponse
+		kvPair     *mvccpb.KeyValue
+		name       string
+		job        *common.Job
+		jobValue   []byte
+		newKey     string
+		resultResp *clientv3.GetResponse
+		ops        []clientv3.Op
+		txnResp    *clientv3.TxnResponse
+		renamed    int64
+	)
+	if getResp, err = G_jobMgr.kv.Get(context.TODO(), common.JOB_SAVE_DIR, clientv3.WithPrefix()); err != nil {
+		return
+	}
+	for _, kvPair = range getResp.Kvs {
+		if name = common.ExtractJobName(string(kvPair.Key)); strings.Contains(name, "/") {
+			continue
+		}
+		if job, err = common.UnpackJob(kvPair.Value); err != nil {
+			namespaceLog.WithError(err).WithField("job", name).Warn("任务格式错误，跳过迁移")
+			err = nil
+			continue
+		}
+		job.Namespace = common.DEFAULT_NAMESPACE
+		if jobValue, err = json.Marshal(job); err != nil {
+			return
+		}
+		newKey = common.JOB_SAVE_DIR + job.FullName()
+
+		// 没有命名空间的任务名本来就属于默认命名空间，先改日志不会影响查询结果
+		if renamed, err = G_logMgr.store.RenameJob(name, job.FullName()); err != nil {
+			return
+		}
+
+		// 最近一次执行结果跟着任务一起迁移
+		// 新老key在worker看来是同一个任务，先删后写，worker按顺序收到删除和保存事件后任务仍在调度
+		ops = []clientv3.Op{clientv3.OpDelete(string(kvPair.Key)), clientv3.OpPut(newKey, string(jobValue))}
+		if resultResp, err = G_jobMgr.kv.Get(context.TODO(), common.JOB_RESULT_DIR+name); err != nil {
+			return
+		}
+		if len(resultResp.Kvs) != 0 {
+			ops = append(ops,
+				clientv3.OpDelete(common.JOB_RESULT_DIR+name),
+				clientv3.OpPut(common.JOB_RESULT_DIR+job.FullName(), string(resultResp.Kvs[0].Value)))
+		}
+		// 新key已存在或者老任务在此期间被修改都不迁移
+		if txnResp, err = G_jobMgr.kv.Txn(context.TODO()).
+			If(clientv3.Compare(clientv3.CreateRevision(newKey), "=", 0),
+				clientv3.Compare(clientv3.ModRevision(string(kvPair.Key)), "=", kvPair.ModRevision)).
+			Then(ops...).
+			Commit(); err != nil {
+			return
+		}
+		if !txnResp.Succeeded {
+			namespaceLog.WithField("job", name).Warn("默认命名空间中已有同名任务或者任务被修改，跳过迁移")
+			continue
+		}
+		namespaceLog.WithField("job", job.FullName()).WithField("logs", renamed).Info("任务已迁移到默认命名空间")
+	}
+	return
+}
+
+// 把配置中的命名空间配额写入 /cron/namespaces/，删除配置中已经没有的配额
+func syncNamespaceQuotas() (err error) {
+	var (
+		namespaceConfig *NamespaceConfig
+		configured      map[string]bool
+		quotaValue      []byte
+		getResp         *clientv3.GetResponse
+		kvPair          *mvccpb.KeyValue
+	)
+	configured = make(map[string]bool)
+	for _, namespaceConfig = range G_config.Namespaces {
+		configured[namespaceConfig.Name] = true
+		if quotaValue, err = json.Marshal(G_config.NamespaceQuota(namespaceConfig.Name)); err != nil {
+			return
+		}
+		if _, err = G_jobMgr.kv.Put(context.TODO(), common.JOB_NAMESPACE_DIR+namespaceConfig.Name, string(quotaValue)); err != nil {
+			return
+		}
+	}
+	if getResp, err = G_jobMgr.kv.Get(context.TODO(), common.JOB_NAMESPACE_DIR, clientv3.WithPrefix(), clientv3.WithKeysOnly()); err != nil {
+		return
+	}
+	for _, kvPair = range getResp.Kvs {
+		if configured[common.ExtractNamespaceName(string(kvPair.Key))] {
+			continue
+		}
+		if _, err = G_jobMgr.kv.Delete(context.TODO(), string(kvPair.Key)); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// 初始化命名空间：迁移老任务，同步配额，依赖任务管理器和日志管理器
+func InitNamespaces() (err error) {
+	if err = migrateLegacyJobs(); err != nil {
+		return
+	}
+	return syncNamespaceQuotas()
+}
@@ -0,0 +1,274 @@
+package master
+
+import (
+	"../common"
+	"../logger"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+	"time"
+)
+
+// 通知管理器
+// worker把每次执行结果写到 /cron/result/任务名，master统一监听并按任务的通知规则发送，多个worker不会重复通知
+// 多个master都监听执行结果、维护连续失败次数，只有leader发送，leader切换时新leader的状态是最新的
+type NotifyMgr struct {
+	client  *clientv3.Client
+	kv      clientv3.KV
+	watcher clientv3.Watcher
+
+	targets     map[string]NotifyTarget // 渠道名称 -> 渠道
+	targetNames []string                // 配置中的渠道顺序
+	notifyChan  chan *notifyTask
+
+	// 任务名称 -> 当前连续失败次数，只在监听协程中读写
+	consecutiveFailures map[string]int
+}
+
+// 待发送的通知
+type notifyTask struct {
+	notification *common.Notification
+	targetNames  []string
+}
+
+// 监听任务执行结果
+func (notifyMgr *NotifyMgr) watchResults() {
+	var (
+		getResp    *clientv3.GetResponse
+		watchChan  clientv3.WatchChan
+		watchResp  clientv3.WatchResponse
+		watchEvent *clientv3.Event
+		jobLog     *common.JobLog
+		err        error
+	)
+	for {
+		// 只处理之后的新结果，已经存在的结果在上次运行时处理过了
+		if getResp, err = notifyMgr.kv.Get(context.TODO(), common.JOB_RESULT_DIR, clientv3.WithPrefix(), clientv3.WithCountOnly()); err != nil {
+			notifyLog.WithError(err).Warn("监听执行结果失败，稍后重试")
+			time.Sleep(time.Second)
+			continue
+		}
+		watchChan = notifyMgr.watcher.Watch(context.TODO(), common.JOB_RESULT_DIR, clientv3.WithRev(getResp.Header.Revision+1), clientv3.WithPrefix())
+		for watchResp = range watchChan {
+			if err = watchResp.Err(); err != nil {
+				notifyLog.WithError(err).Warn("监听执行结果中断，重新监听")
+				break
+			}
+			for _, watchEvent = range watchResp.Events {
+				switch watchEvent.Type {
+				case mvccpb.PUT:
+					jobLog = &common.JobLog{}
+					if err = json.Unmarshal(watchEvent.Kv.Value, jobLog); err != nil {
+						continue
+					}
+					notifyMgr.handleResult(jobLog)
+				case mvccpb.DELETE: // 任务被删除
+					delete(notifyMgr.consecutiveFailures, common.ExtractResultName(string(watchEvent.Kv.Key)))
+				}
+			}
+		}
+	}
+}
+
+// 任务当前的连续失败次数，master刚启动时从日志存储中恢复
+func (notifyMgr *NotifyMgr) loadConsecutiveFailures(jobLog *common.JobLog) (count int) {
+	var (
+		logArr  []*common.JobLog
+		lastLog *common.JobLog
+		ok      bool
+		err     error
+	)
+	if count, ok = notifyMgr.consecutiveFailures[jobLog.JobName]; ok {
+		return
+	}
+	// 最近的100条日志足够判断，当前这次执行的日志可能已经写入，需要跳过
+	if logArr, err = G_logMgr.store.Query(&common.JobLogQuery{
+		Filter:    common.JobLogFilter{JobName: jobLog.JobName},
+		SortField: common.LOG_SORT_START_TIME,
+		SortOrder: -1,
+		Limit:     100,
+	}); err != nil {
+		return
+	}
+	for _, lastLog = range logArr {
+		if lastLog.ExecId != "" && lastLog.ExecId == jobLog.ExecId {
+			continue
+		}
+		if lastLog.Err == "" {
+			break
+		}
+		count++
+	}
+	return
+}
+
+// 按任务的通知规则处理一次执行结果
+func (notifyMgr *NotifyMgr) handleResult(jobLog *common.JobLog) {
+	var (
+		job   *common.Job
+		rules *common.JobNotify
+		count int
+		err   error
+	)
+	count = notifyMgr.loadConsecutiveFailures(jobLog)
+	// 成功
+	if jobLog.Err == "" {
+		notifyMgr.consecutiveFailures[jobLog.JobName] = 0
+	} else {
+		notifyMgr.consecutiveFailures[jobLog.JobName] = count + 1
+	}
+
+	// 任务已删除或者没有配置通知规则
+	if job, err = G_jobMgr.GetJob(jobLog.JobName); err != nil || job.Notify == nil {
+		return
+	}
+	rules = job.Notify
+
+	if jobLog.Err == "" {
+		if count > 0 && rules.OnRecovery {
+			notifyMgr.notifyResult(job, common.NOTIFY_EVENT_RECOVERY, jobLog, count)
+		}
+		return
+	}
+	count++
+	if jobLog.TimedOut && rules.OnTimeout {
+		notifyMgr.notifyResult(job, common.NOTIFY_EVENT_TIMEOUT, jobLog, count)
+	} else if rules.OnFailure {
+		notifyMgr.notifyResult(job, common.NOTIFY_EVENT_FAILURE, jobLog, count)
+	}
+	// 连续失败只在正好达到N次时通知一次
+	if rules.ConsecutiveFailures > 0 && count == rules.ConsecutiveFailures {
+		notifyMgr.notifyResult(job, common.NOTIFY_EVENT_CONSECUTIVE_FAILURES, jobLog, count)
+	}
+}
+
+// 生成执行结果相关的通知
+func (notifyMgr *NotifyMgr) notifyResult(job *common.Job, event string, jobLog *common.JobLog, count int) {
+	var (
+		message string
+	)
+	switch event {
+	case common.NOTIFY_EVENT_FAILURE:
+		message = fmt.Sprintf("任务 %s 在节点 %s 上执行失败：%s", job.FullName(), jobLog.Worker, jobLog.Err)
+	case common.NOTIFY_EVENT_TIMEOUT:
+		message = fmt.Sprintf("任务 %s 在节点 %s 上执行超时（%d秒）", job.FullName(), jobLog.Worker, job.Timeout)
+	case common.NOTIFY_EVENT_CONSECUTIVE_FAILURES:
+		message = fmt.Sprintf("任务 %s 已连续失败 %d 次，最近一次错误：%s", job.FullName(), count, jobLog.Err)
+	case common.NOTIFY_EVENT_RECOVERY:
+		message = fmt.Sprintf("任务 %s 已恢复正常，此前连续失败 %d 次", job.FullName(), count)
+	}
+	notifyMgr.Notify(&common.Notification{
+		Event:               event,
+		JobName:             job.FullName(),
+		Message:             message,
+		ConsecutiveFailures: count,
+		Log:                 jobLog,
+		Time:                time.Now().UnixNano() / 1000 / 1000,
+	}, job.Notify.Targets)
+}
+
+// 发送通知，targetNames为空则发往所有渠道
+func (notifyMgr *NotifyMgr) Notify(notification *common.Notification, targetNames []string) {
+	// 不是leader不发送，避免多个master重复通知
+	if !G_leader.IsLeader() {
+		return
+	}
+	if len(targetNames) == 0 {
+		targetNames = notifyMgr.targetNames
+	}
+	if len(targetNames) == 0 {
+		return
+	}
+	select {
+	case notifyMgr.notifyChan <- &notifyTask{notification: notification, targetNames: targetNames}:
+	default:
+		// 通知积压太多，丢弃
+		notifyLog.WithFields(logrus.Fields{
+			"job":   notification.JobName,
+			"event": notification.Event,
+		}).Warn("通知队列已满，丢弃通知")
+	}
+}
+
+// 发送协程，逐个渠道发送
+func (notifyMgr *NotifyMgr) sendLoop() {
+	var (
+		task       *notifyTask
+		targetName string
+		target     NotifyTarget
+		ok         bool
+		err        error
+	)
+	for task = range notifyMgr.notifyChan {
+		for _, targetName = range task.targetNames {
+			if target, ok = notifyMgr.targets[targetName]; !ok {
+				continue // 配置中已经删掉了该渠道
+			}
+			if err = target.Send(task.notification); err != nil {
+				metricNotifications.WithLabelValues(targetName, "failed").Inc()
+				notifyLog.WithError(err).WithFields(logrus.Fields{
+					"job":    task.notification.JobName,
+					"event":  task.notification.Event,
+					"target": targetName,
+				}).Warn("发送通知失败")
+				continue
+			}
+			metricNotifications.WithLabelValues(targetName, "success").Inc()
+		}
+	}
+}
+
+var (
+	G_notifyMgr *NotifyMgr
+
+	notifyLog = logger.Component("notify")
+)
+
+// 初始化通知管理器，依赖任务管理器和日志管理器
+func InitNotifyMgr() (err error) {
+	var (
+		config       clientv3.Config
+		client       *clientv3.Client
+		targetConfig *NotifyTargetConfig
+		target       NotifyTarget
+		targets      map[string]NotifyTarget
+		targetNames  []string
+	)
+	// 创建通知渠道
+	targets = make(map[string]NotifyTarget)
+	targetNames = make([]string, 0)
+	for _, targetConfig = range G_config.NotifyTargets {
+		if target, err = NewNotifyTarget(targetConfig, time.Duration(G_config.NotifyTimeout)*time.Millisecond); err != nil {
+			return
+		}
+		targets[targetConfig.Name] = target
+		targetNames = append(targetNames, targetConfig.Name)
+	}
+
+	// 初始化配置
+	config = clientv3.Config{
+		Endpoints:   G_config.EtcdEndPoints, // etcd地址
+		DialTimeout: time.Duration(G_config.EtcdDialTimeout) * time.Millisecond,
+	}
+	// 建立连接
+	if client, err = clientv3.New(config); err != nil {
+		return
+	}
+
+	G_notifyMgr = &NotifyMgr{
+		client:              client,
+		kv:                  &metricKV{KV: clientv3.NewKV(client)},
+		watcher:             clientv3.NewWatcher(client),
+		targets:             targets,
+		targetNames:         targetNames,
+		notifyChan:          make(chan *notifyTask, 1000),
+		consecutiveFailures: make(map[string]int),
+	}
+
+	go G_notifyMgr.watchResults()
+	go G_notifyMgr.sendLoop()
+	return
+}
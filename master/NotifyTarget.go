@@ -0,0 +1,192 @@
+package master
+
+import (
+	"../common"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 通知渠道
+type NotifyTarget interface {
+	Send(notification *common.Notification) error
+}
+
+// 通用webhook：按模板生成body后POST
+type WebhookTarget struct {
+	url      string
+	headers  map[string]string
+	template *template.Template // 为空则发送通知本身的json
+	client   *http.Client
+}
+
+func (target *WebhookTarget) Send(notification *common.Notification) (err error) {
+	var (
+		body   bytes.Buffer
+		result []byte
+	)
+	if target.template == nil {
+		if result, err = json.Marshal(notification); err != nil {
+			return
+		}
+		body.Write(result)
+	} else if err = target.template.Execute(&body, notification); err != nil {
+		return
+	}
+	return postJSON(target.client, target.url, target.headers, body.Bytes())
+}
+
+// Slack兼容的incoming webhook：{"text": "..."}
+type SlackTarget struct {
+	url    string
+	client *http.Client
+}
+
+func (target *SlackTarget) Send(notification *common.Notification) (err error) {
+	var (
+		body []byte
+	)
+	if body, err = json.Marshal(map[string]string{"text": notification.Message}); err != nil {
+		return
+	}
+	return postJSON(target.client, target.url, nil, body)
+}
+
+// SMTP邮件
+type EmailTarget struct {
+	addr string
+	auth smtp.Auth // 为空则不认证
+	from string
+	to   []string
+}
+
+func (target *EmailTarget) Send(notification *common.Notification) (err error) {
+	var (
+		msg bytes.Buffer
+	)
+	msg.WriteString("From: " + target.from + "\r\n")
+	msg.WriteString("To: " + strings.Join(target.to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", "[crontab] "+notification.Message) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(notificationText(notification))
+	return smtp.SendMail(target.addr, target.auth, target.from, target.to, msg.Bytes())
+}
+
+// 邮件正文
+func notificationText(notification *common.Notification) string {
+	var (
+		text strings.Builder
+	)
+	text.WriteString(notification.Message + "\r\n\r\n")
+	text.WriteString("任务：" + notification.JobName + "\r\n")
+	if notification.Log != nil {
+		text.WriteString("节点：" + notification.Log.Worker + "\r\n")
+		text.WriteString("执行ID：" + notification.Log.ExecId + "\r\n")
+		text.WriteString("开始时间：" + formatMillis(notification.Log.StartTime) + "\r\n")
+		text.WriteString("结束时间：" + formatMillis(notification.Log.EndTime) + "\r\n")
+		if notification.Log.Err != "" {
+			text.WriteString("错误：" + notification.Log.Err + "\r\n")
+		}
+		if notification.Log.Output != "" {
+			text.WriteString("\r\n输出（末尾部分）：\r\n" + notification.Log.Output + "\r\n")
+		}
+	}
+	return text.String()
+}
+
+// 毫秒时间戳格式化
+func formatMillis(millis int64) string {
+	return time.Unix(0, millis*int64(time.Millisecond)).Format("2006-01-02 15:04:05")
+}
+
+// POST一个json，非2xx应答视为失败
+func postJSON(client *http.Client, url string, headers map[string]string, body []byte) (err error) {
+	var (
+		req   *http.Request
+		resp  *http.Response
+		key   string
+		value string
+	)
+	if req, err = http.NewRequest("POST", url, bytes.NewReader(body)); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value = range headers {
+		req.Header.Set(key, value)
+	}
+	if resp, err = client.Do(req); err != nil {
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err = fmt.Errorf("通知发送失败，状态码：%d", resp.StatusCode)
+	}
+	return
+}
+
+// webhook模板中可用的函数
+var notifyTemplateFuncs = template.FuncMap{
+	// 输出json编码后的值，用于把字符串安全地嵌入json
+	"json": func(value interface{}) (string, error) {
+		var (
+			result []byte
+			err    error
+		)
+		if result, err = json.Marshal(value); err != nil {
+			return "", err
+		}
+		return string(result), nil
+	},
+	// 毫秒时间戳格式化
+	"formatTime": formatMillis,
+}
+
+// 按配置创建通知渠道
+func NewNotifyTarget(config *NotifyTargetConfig, timeout time.Duration) (target NotifyTarget, err error) {
+	var (
+		tmpl *template.Template
+		auth smtp.Auth
+		host string
+	)
+	switch config.Type {
+	case common.NOTIFY_TARGET_WEBHOOK:
+		if config.Template != "" {
+			if tmpl, err = template.New(config.Name).Funcs(notifyTemplateFuncs).Parse(config.Template); err != nil {
+				return
+			}
+		}
+		target = &WebhookTarget{
+			url:      config.Url,
+			headers:  config.Headers,
+			template: tmpl,
+			client:   &http.Client{Timeout: timeout},
+		}
+	case common.NOTIFY_TARGET_SLACK:
+		target = &SlackTarget{
+			url:    config.Url,
+			client: &http.Client{Timeout: timeout},
+		}
+	case common.NOTIFY_TARGET_EMAIL:
+		if config.SmtpUser != "" {
+			host = strings.Split(config.SmtpAddr, ":")[0]
+			auth = smtp.PlainAuth("", config.SmtpUser, config.SmtpPassword, host)
+		}
+		target = &EmailTarget{
+			addr: config.SmtpAddr,
+			auth: auth,
+			from: config.From,
+			to:   config.To,
+		}
+	default:
+		err = common.ERR_UNKNOWN_NOTIFY_TARGET
+	}
+	return
+}
@@ -15,21 +15,71 @@ type WorkerMgr struct {
 	lease  clientv3.Lease
 }
 
-func (workerMgr *WorkerMgr) ListWorkers() (workerArr []string, err error) {
+// 解析注册信息，老版本worker注册的value为空，key就是它的ip
+func buildWorkerInfo(kv *mvccpb.KeyValue) (workerInfo *common.WorkerInfo) {
 	var (
-		getResp  *clientv3.GetResponse
-		kv       *mvccpb.KeyValue
-		workerIP string
+		err error
 	)
-	workerArr = make([]string, 0)
+	if workerInfo, err = common.UnpackWorkerInfo(kv.Value); err != nil {
+		workerInfo = &common.WorkerInfo{}
+	}
+	workerInfo.ID = common.ExtractWorkerID(string(kv.Key))
+	if workerInfo.IP == "" {
+		workerInfo.IP = workerInfo.ID
+	}
+	return
+}
+
+func (workerMgr *WorkerMgr) ListWorkers() (workerArr []*common.WorkerInfo, err error) {
+	var (
+		getResp *clientv3.GetResponse
+		kv      *mvccpb.KeyValue
+	)
+	workerArr = make([]*common.WorkerInfo, 0)
 	if getResp, err = workerMgr.kv.Get(context.TODO(), common.JOB_WORKER_DIR, clientv3.WithPrefix()); err != nil {
 		return
 	}
-	// 解析每个节点的ip
+	// 解析每个节点的注册信息
 	for _, kv = range getResp.Kvs {
-		workerIP = common.ExtractWorkerIP(string(kv.Key))
-		workerArr = append(workerArr, workerIP)
+		workerArr = append(workerArr, buildWorkerInfo(kv))
+	}
+	return
+}
+
+// 查询单个节点的注册信息
+func (workerMgr *WorkerMgr) GetWorker(workerId string) (workerInfo *common.WorkerInfo, err error) {
+	var (
+		getResp *clientv3.GetResponse
+	)
+	if getResp, err = workerMgr.kv.Get(context.TODO(), common.JOB_WORKER_DIR+workerId); err != nil {
+		return
+	}
+	if len(getResp.Kvs) == 0 {
+		err = common.ERR_WORKER_NOT_FOUND
+		return
 	}
+	workerInfo = buildWorkerInfo(getResp.Kvs[0])
+	return
+}
+
+// 设置节点维护标记 cordon/drain，worker监听到后不再抢新任务
+// 返回节点当前的注册信息，其中的state和runningJobs就是排空进度
+func (workerMgr *WorkerMgr) CordonWorker(workerId string, flag string) (workerInfo *common.WorkerInfo, err error) {
+	// 节点必须在线
+	if workerInfo, err = workerMgr.GetWorker(workerId); err != nil {
+		return
+	}
+	// 维护标记不绑定租约，直到uncordon
+	// worker的节点ID是配置的或者持久化在workerIdFile中，重启后ID不变，标记依然有效
+	if _, err = workerMgr.kv.Put(context.TODO(), common.JOB_CORDON_DIR+workerId, flag); err != nil {
+		return
+	}
+	return
+}
+
+// 清除节点维护标记，恢复调度
+func (workerMgr *WorkerMgr) UncordonWorker(workerId string) (err error) {
+	_, err = workerMgr.kv.Delete(context.TODO(), common.JOB_CORDON_DIR+workerId)
 	return
 }
 
@@ -54,7 +104,7 @@ func InitWorkerMgr() (err error) {
 		return
 	}
 	// 得到KV和lease的API子集
-	kv = clientv3.NewKV(client)
+	kv = &metricKV{KV: clientv3.NewKV(client)} // 统计etcd错误次数
 	lease = clientv3.NewLease(client)
 
 	G_workerMgr = &WorkerMgr{
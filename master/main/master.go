@@ -1,11 +1,15 @@
 package main
 
 import (
-	"fmt"
+	"os"
+	"os/signal"
 	"runtime"
-	"time"
+	"syscall"
+)
+import (
+	"../../logger"
+	"../../master"
 )
-import "../../master"
 
 func initEnv() {
 	// 线程池中线程与 CPU 核心数量的对应关系
@@ -14,7 +18,9 @@ func initEnv() {
 
 func main() {
 	var (
-		err error
+		err        error
+		signalChan chan os.Signal
+		sig        os.Signal
 	)
 	// 初始化线程
 	initEnv()
@@ -22,6 +28,14 @@ func main() {
 	if err = master.InitConfig("master/main/master.json"); err != nil {
 		goto ERR
 	}
+	// 进程日志
+	if err = logger.InitLogger(master.G_config.LoggerConfig()); err != nil {
+		goto ERR
+	}
+	// 监控指标
+	if err = master.InitMetrics(); err != nil {
+		goto ERR
+	}
 	// 服务发现
 	if err = master.InitWorkerMgr(); err != nil {
 		goto ERR
@@ -34,15 +48,45 @@ func main() {
 	if err = master.InitJobMgr(); err != nil {
 		goto ERR
 	}
+	// 命名空间：迁移老任务，同步配额，依赖任务管理器和日志管理器
+	if err = master.InitNamespaces(); err != nil {
+		goto ERR
+	}
+	// 选主，多个master时只有leader发送通知
+	if err = master.InitLeader(); err != nil {
+		goto ERR
+	}
+	// 失败通知，依赖任务管理器、日志管理器和选主
+	if err = master.InitNotifyMgr(); err != nil {
+		goto ERR
+	}
+	// 任务成功心跳检查，依赖通知管理器
+	if err = master.InitJobWatchdog(); err != nil {
+		goto ERR
+	}
+	// 日志清理，依赖任务管理器
+	if err = master.InitLogPruner(); err != nil {
+		goto ERR
+	}
 	// 启动Api HTTP服务，API Server会调用任务管理器提供的etcd服务
 	if err = master.InitApiServer(err); err != nil {
 		goto ERR
 	}
+	// 启动gRPC服务，和Api HTTP服务使用不同的端口
+	if err = master.InitGrpcServer(); err != nil {
+		goto ERR
+	}
 
+	// 等待退出信号，收到后优雅退出
+	signalChan = make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	sig = <-signalChan
+	logger.Component("main").WithField("signal", sig.String()).Info("收到退出信号，开始退出")
+	master.Shutdown()
 	// 不要走标签ERR，正常退出
-	time.Sleep(time.Second * 1000)
 	return
 
 ERR:
-	fmt.Println(err)
+	logger.Component("main").WithError(err).Error("启动失败")
+	os.Exit(1)
 }
@@ -1,6 +1,8 @@
 package worker
 
 import (
+	"../logger"
+	"../logstore"
 	"encoding/json"
 	"io/ioutil"
 )
@@ -12,15 +14,59 @@ var (
 
 // master.json配置文件
 type Config struct {
-	ApiPort               int      `json:"apiPort"`
-	ApiReadTimeout        int      `json:"apiReadTimeout"`
-	ApiWriteTimeout       int      `json:"apiWriteTimeout"`
-	EtcdEndPoints         []string `json:"etcdEndPoints"`
-	EtcdDialTimeout       int      `json:"etcdDialTimeout"`
-	MongodbUri            string   `json:"mongodbUri"`
-	MongodbConnectTimeout int      `json:"mongodbConnectTimeout"`
-	JobLogBatchSize       int      `json:"jobLogBatchSize"`
-	JobLogCommitTimeout   int      `json:"jobLogCommitTimeout"`
+	ApiPort               int               `json:"apiPort"`
+	ApiReadTimeout        int               `json:"apiReadTimeout"`
+	ApiWriteTimeout       int               `json:"apiWriteTimeout"`
+	EtcdEndPoints         []string          `json:"etcdEndPoints"`
+	EtcdDialTimeout       int               `json:"etcdDialTimeout"`
+	MongodbUri            string            `json:"mongodbUri"`
+	MongodbConnectTimeout int               `json:"mongodbConnectTimeout"`
+	LogStore              string            `json:"logStore"` // 日志存储类型 mongodb / sqlite / file
+	MongodbDatabase       string            `json:"mongodbDatabase"`
+	MongodbCollection     string            `json:"mongodbCollection"`
+	LogStorePath          string            `json:"logStorePath"` // sqlite和file存储的文件路径
+	JobLogBatchSize       int               `json:"jobLogBatchSize"`
+	JobLogCommitTimeout   int               `json:"jobLogCommitTimeout"`
+	JobLogSpoolDir        string            `json:"jobLogSpoolDir"`      // 日志预写文件目录，每个worker使用其中以节点ID命名的子目录
+	JobLogRetryInterval   int               `json:"jobLogRetryInterval"` // 日志补发的重试间隔，毫秒
+	WorkerId              string            `json:"workerId"`            // 节点ID，为空则使用 主机名-随机后缀
+	WorkerIdFile          string            `json:"workerIdFile"`        // 自动生成的节点ID保存在这个文件中，重启后沿用；运行时加锁，同目录的其他worker改用 文件名.1、.2 …
+	AdvertiseAddr         string            `json:"advertiseAddr"`       // 对外公布的地址，为空则自动探测网卡ip
+	WorkerLabels          map[string]string `json:"workerLabels"`        // 节点标签，随注册信息上报
+	MaxConcurrentJobs     int               `json:"maxConcurrentJobs"`   // 最大并发任务数，0表示不限制
+	HeartbeatInterval     int               `json:"heartbeatInterval"`   // 注册信息刷新间隔，毫秒
+	MetricsPort           int               `json:"metricsPort"`         // 监控指标 /metrics 和健康检查 /healthz /readyz 的端口，0表示不开启
+	LoggerLevel           string            `json:"loggerLevel"`         // 进程日志级别 debug / info / warn / error
+	LoggerFormat          string            `json:"loggerFormat"`        // 进程日志格式 logfmt / json
+	LoggerFile            string            `json:"loggerFile"`          // 进程日志文件，为空则输出到标准输出
+	LoggerMaxSize         int               `json:"loggerMaxSize"`       // 单个日志文件最大大小，MB，超过后切割
+	LoggerMaxBackups      int               `json:"loggerMaxBackups"`    // 最多保留的旧日志文件个数
+	LoggerMaxAge          int               `json:"loggerMaxAge"`        // 旧日志文件最多保留的天数
+	ShutdownTimeout       int               `json:"shutdownTimeout"`     // 退出时等待正在执行的任务结束的最长时间，毫秒，超过后强杀
+}
+
+// 日志存储配置
+func (conf *Config) LogStoreConfig() *logstore.Config {
+	return &logstore.Config{
+		Type:                  conf.LogStore,
+		MongodbUri:            conf.MongodbUri,
+		MongodbConnectTimeout: conf.MongodbConnectTimeout,
+		MongodbDatabase:       conf.MongodbDatabase,
+		MongodbCollection:     conf.MongodbCollection,
+		Path:                  conf.LogStorePath,
+	}
+}
+
+// 进程日志配置
+func (conf *Config) LoggerConfig() *logger.Config {
+	return &logger.Config{
+		Level:      conf.LoggerLevel,
+		Format:     conf.LoggerFormat,
+		File:       conf.LoggerFile,
+		MaxSize:    conf.LoggerMaxSize,
+		MaxBackups: conf.LoggerMaxBackups,
+		MaxAge:     conf.LoggerMaxAge,
+	}
 }
 
 // 加载配置
@@ -38,6 +84,21 @@ func InitConfig(finename string) (err error) {
 	if err = json.Unmarshal(content, conf); err != nil {
 		return
 	}
+	if conf.HeartbeatInterval <= 0 {
+		conf.HeartbeatInterval = 5000
+	}
+	if conf.JobLogSpoolDir == "" {
+		conf.JobLogSpoolDir = "worker/spool"
+	}
+	if conf.WorkerIdFile == "" {
+		conf.WorkerIdFile = "worker/worker.id"
+	}
+	if conf.JobLogRetryInterval <= 0 {
+		conf.JobLogRetryInterval = 1000
+	}
+	if conf.ShutdownTimeout <= 0 {
+		conf.ShutdownTimeout = 30 * 1000
+	}
 	// 赋值单例
 	G_config = conf
 	return nil
@@ -2,6 +2,7 @@ package worker
 
 import (
 	"../common"
+	"context"
 	"math/rand"
 	"os/exec"
 	"time"
@@ -17,11 +18,15 @@ type Executor struct {
 func (executor *Executor) ExecuteJob(info *common.JobExecuteInfo) {
 	go func() {
 		var (
-			cmd     *exec.Cmd
-			err     error
-			output  []byte
-			result  *common.JobExecuteResult
-			jobLock *JobLock
+			cmd        *exec.Cmd
+			err        error
+			output     []byte
+			result     *common.JobExecuteResult
+			jobLock    *JobLock
+			runSlot    *RunSlot
+			runState   *RunState
+			cmdCtx     context.Context
+			cancelFunc context.CancelFunc
 		)
 		// 任务执行结果
 		result = &common.JobExecuteResult{
@@ -29,32 +34,73 @@ func (executor *Executor) ExecuteJob(info *common.JobExecuteInfo) {
 			Output:      make([]byte, 0),
 		}
 
-		// 初始化分布式锁
-		jobLock = G_jobMgr.CreateJobLock(info.Job.Name)
-
 		result.StartTime = time.Now()
 
-		// 如果抢到了锁，就执行shell
-		// 如果没抢到锁，就跳过执行
+		// 抢锁之前先占用本节点的执行槽位，槽位已满就不去抢锁，让有空闲槽位的节点抢到锁执行
+		// 调度时槽位还有空闲，但多个任务同时调度时可能已满，跳过本次执行，和没抢到锁一样不记日志
+		if G_scheduler.acquireSlot() {
+			defer G_scheduler.releaseSlot()
+		} else {
+			err = common.ERR_NO_FREE_SLOT
+		}
+
+		// 广播任务每个worker都要执行，不需要抢全局锁
+		if err == nil && !info.Job.IsBroadcast() {
+			// 初始化分布式锁
+			jobLock = G_jobMgr.CreateJobLock(info.Job.FullName())
+
+			// 如果抢到了锁，就执行shell
+			// 如果没抢到锁，就跳过执行
 
-		// 随机睡眠0-1秒，可以保证不是总被一个worker强到锁
-		time.Sleep(time.Duration(rand.Intn(1000)) * time.Millisecond)
-		err = jobLock.TryLock()
-		defer jobLock.UnLock() // 执行完毕之后，将锁释放，不再续约
-		if err != nil {        // 上锁失败
+			// 随机睡眠0-1秒，可以保证不是总被一个worker强到锁
+			time.Sleep(time.Duration(rand.Intn(1000)) * time.Millisecond)
+			err = jobLock.TryLock()
+			defer jobLock.UnLock() // 执行完毕之后，将锁释放，不再续约
+		}
+		// 再占用命名空间的并发槽位，超出上限跳过本次执行
+		if err == nil {
+			runSlot = G_jobMgr.CreateRunSlot(info.Job.Namespace)
+			err = runSlot.TryAcquire()
+			defer runSlot.Release()
+		}
+		// 渲染命令模板，失败记为执行失败，日志中记录原始命令
+		result.Command = info.Job.Command
+		if err == nil {
+			if result.Command, err = common.RenderCommand(info); err != nil {
+				result.Command = info.Job.Command
+			}
+		}
+		if err != nil { // 上锁失败、超出并发上限或者命令渲染失败
 			result.Err = err
 			result.EndTime = time.Now()
 		} else { // 上锁成功
 			// 重置任务启动时间
 			result.StartTime = time.Now()
+			metricJobStarted.WithLabelValues(info.Job.FullName()).Inc()
+			// 登记为正在执行，执行结束后删除
+			runState = G_jobMgr.CreateRunState(info)
+			runState.Register(result.StartTime)
+			defer runState.Unregister()
+			// 配置了超时时间，到期后杀死子进程
+			cmdCtx = info.CancelCtx
+			if info.Job.Timeout > 0 {
+				cmdCtx, cancelFunc = context.WithTimeout(info.CancelCtx, time.Duration(info.Job.Timeout)*time.Second)
+			}
 			// 执行shell命令
-			cmd = exec.CommandContext(info.CancelCtx, "/bin/bash", "-c", info.Job.Command)
+			cmd = exec.CommandContext(cmdCtx, "/bin/bash", "-c", result.Command)
 			//time.Sleep(10*time.Second)
 			// 执行并捕获输出
 			output, err = cmd.CombinedOutput()
 			result.EndTime = time.Now()
 			result.Output = output
 			result.Err = err
+			if cancelFunc != nil {
+				// 不是被强杀，而是到了超时时间
+				if info.CancelCtx.Err() == nil && cmdCtx.Err() == context.DeadlineExceeded {
+					result.Err = common.ERR_JOB_TIMEOUT
+				}
+				cancelFunc()
+			}
 		}
 		// 将任务执行的结果返回给scheduler，scheduler会从executingTable中删除记录
 		G_scheduler.PushJobResult(result)
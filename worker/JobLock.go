@@ -2,11 +2,15 @@ package worker
 
 import (
 	"../common"
+	"../logger"
 	"context"
-	"fmt"
 	"go.etcd.io/etcd/clientv3"
 )
 
+var (
+	lockLog = logger.Component("lock")
+)
+
 // 分布式锁
 type JobLock struct {
 	// etcd客户端
@@ -55,11 +59,13 @@ func (jobLock *JobLock) TryLock() (err error) {
 			case keepResp = <-keepRespChan:
 				// 接受keepRespChan通道中的数据
 				if keepResp == nil {
-					fmt.Println("租约失效")
+					// 不是主动释放锁导致的，说明锁的租约丢了
+					if cancelCtx.Err() == nil {
+						metricLeaseKeepAliveFailures.WithLabelValues("lock").Inc()
+						lockLog.WithField("job", jobLock.jobName).Warn("任务锁租约失效")
+					}
 					goto END
-				} else { // 每秒续租一次，所以会有一次应答
-					fmt.Println("收到自动续租的应答: ", keepResp.ID)
-				}
+				} // 每秒续租一次，续租应答不再打印
 			}
 		}
 	END:
@@ -2,9 +2,13 @@ package worker
 
 import (
 	"../common"
+	"../logger"
 	"context"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/mvcc/mvccpb"
+	"sync"
 	"time"
 )
 
@@ -14,6 +18,8 @@ type JobMgr struct {
 	kv      clientv3.KV
 	lease   clientv3.Lease
 	watcher clientv3.Watcher
+
+	pendingResults sync.WaitGroup // 正在上报的执行结果
 }
 
 // 启动监听任务 + 监听任务变化
@@ -61,12 +67,10 @@ func (jobMgr *JobMgr) watchJobs() (err error) {
 					}
 					// 构建一个Event事件
 					jobEvent = common.BuildJobEvent(common.JOB_EVENT_SAVE, job)
-				case mvccpb.DELETE: // 任务被删除了 delete /cron/jobs/job10
+				case mvccpb.DELETE: // 任务被删除了 delete /cron/jobs/default/job10
 					jobName = common.ExtractJobName(string(watchEvent.Kv.Key))
 					// 构建一个Event事件
-					job = &common.Job{
-						Name: jobName,
-					}
+					job = common.JobFromFullName(jobName)
 					jobEvent = common.BuildJobEvent(common.JOB_EVENT_DELETE, job)
 				}
 				// 推送任务
@@ -78,12 +82,71 @@ func (jobMgr *JobMgr) watchJobs() (err error) {
 	return
 }
 
+// 上报任务执行结果到 /cron/result/任务名，master监听后按通知规则发送通知
+// 写etcd可能较慢，不阻塞调度协程
+func (jobMgr *JobMgr) PutJobResult(jobLog *common.JobLog) {
+	var (
+		result common.JobLog
+	)
+	// 输出可能很大，只保留末尾部分
+	result = *jobLog
+	if len(result.Output) > JOB_RESULT_OUTPUT_TAIL {
+		result.Output = result.Output[len(result.Output)-JOB_RESULT_OUTPUT_TAIL:]
+	}
+	jobMgr.pendingResults.Add(1)
+	go func() {
+		var (
+			value []byte
+			err   error
+		)
+		defer jobMgr.pendingResults.Done()
+		if value, err = json.Marshal(&result); err != nil {
+			return
+		}
+		if _, err = jobMgr.kv.Put(context.TODO(), common.JOB_RESULT_DIR+result.JobName, string(value)); err != nil {
+			jobMgrLog.WithError(err).WithFields(logrus.Fields{
+				"job":    result.JobName,
+				"execId": result.ExecId,
+			}).Warn("上报执行结果失败")
+		}
+	}()
+}
+
+// 等待执行结果上报完成，最多等待timeout
+func (jobMgr *JobMgr) WaitResults(timeout time.Duration) {
+	var (
+		doneChan chan struct{}
+	)
+	doneChan = make(chan struct{})
+	go func() {
+		jobMgr.pendingResults.Wait()
+		close(doneChan)
+	}()
+	select {
+	case <-doneChan:
+	case <-time.After(timeout):
+		jobMgrLog.Warn("等待执行结果上报超时")
+	}
+}
+
 // 创建任务执行锁
 func (jobMgr *JobMgr) CreateJobLock(jobName string) (jobLock *JobLock) {
 	jobLock = InitJobLock(jobName, jobMgr.kv, jobMgr.lease)
 	return
 }
 
+// 创建命名空间的并发执行槽位
+func (jobMgr *JobMgr) CreateRunSlot(namespace string) (runSlot *RunSlot) {
+	runSlot = InitRunSlot(namespace, jobMgr.kv, jobMgr.lease)
+	return
+}
+
+// 创建执行状态登记
+func (jobMgr *JobMgr) CreateRunState(info *common.JobExecuteInfo) (runState *RunState) {
+	runState = InitRunState(info, jobMgr.kv, jobMgr.lease)
+	return
+}
+
 // 监听强杀任务通知
 func (jobMgr *JobMgr) watchKiller() (err error) {
 	var (
@@ -105,9 +168,7 @@ func (jobMgr *JobMgr) watchKiller() (err error) {
 				switch watchEvent.Type {
 				case mvccpb.PUT: //杀死某个任务的事件
 					jobName = common.ExtractKillerName(string(watchEvent.Kv.Key))
-					job = &common.Job{
-						Name: jobName,
-					}
+					job = common.JobFromFullName(jobName)
 					jobEvent = common.BuildJobEvent(common.JOB_EVENT_KILL, job)
 					// 推送任务
 					G_scheduler.PushJobEvent(jobEvent)
@@ -119,9 +180,52 @@ func (jobMgr *JobMgr) watchKiller() (err error) {
 	return
 }
 
+const (
+	// 上报执行结果时保留的输出长度，字节
+	JOB_RESULT_OUTPUT_TAIL = 4096
+)
+
+// 监听手动执行通知
+func (jobMgr *JobMgr) watchRunner() {
+	var (
+		watchChan  clientv3.WatchChan
+		watchResp  clientv3.WatchResponse
+		watchEvent *clientv3.Event
+		jobName    string
+		runRequest *common.JobRunRequest
+		jobEvent   *common.JobEvent
+		err        error
+	)
+	go func() {
+		// 监听/cron/run/目录的后续变化
+		watchChan = jobMgr.watcher.Watch(context.TODO(), common.JOB_RUN_DIR, clientv3.WithPrefix())
+		for watchResp = range watchChan {
+			for _, watchEvent = range watchResp.Events {
+				// 只处理put，过期删除的事件忽略
+				if watchEvent.Type != mvccpb.PUT {
+					continue
+				}
+				jobName = common.ExtractRunName(string(watchEvent.Kv.Key))
+				jobEvent = common.BuildJobEvent(common.JOB_EVENT_RUN, common.JobFromFullName(jobName))
+				// 老版本master写入的是空值，没有覆盖参数
+				if len(watchEvent.Kv.Value) != 0 {
+					runRequest = &common.JobRunRequest{}
+					if err = json.Unmarshal(watchEvent.Kv.Value, runRequest); err != nil {
+						jobMgrLog.WithError(err).WithField("job", jobName).Warn("手动执行参数格式错误，使用默认参数")
+					}
+					jobEvent.Params = runRequest.Params
+				}
+				G_scheduler.PushJobEvent(jobEvent)
+			}
+		}
+	}()
+}
+
 var (
 	// 单例
 	G_jobMgr *JobMgr
+
+	jobMgrLog = logger.Component("jobMgr")
 )
 
 // 初始化任务管理器
@@ -160,6 +264,8 @@ func InitJobMgr() (err error) {
 	G_jobMgr.watchJobs()
 	// 启动监听killer
 	G_jobMgr.watchKiller()
+	// 启动监听手动执行
+	G_jobMgr.watchRunner()
 
 	return
 }
@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"../common"
+	"../logger"
+	"context"
+	"go.etcd.io/etcd/clientv3"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// 进程退出中，readyz返回失败
+	shuttingDown int32
+
+	lifecycleLog = logger.Component("lifecycle")
+)
+
+// 存活检查：进程能响应就是存活的
+func handleHealthz(resp http.ResponseWriter, req *http.Request) {
+	var (
+		bytes []byte
+		err   error
+	)
+	if bytes, err = common.BuildResponse(0, "success", nil); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 就绪检查：etcd和日志存储都可达，并且没有在退出
+func handleReadyz(resp http.ResponseWriter, req *http.Request) {
+	var (
+		ctx        context.Context
+		cancelFunc context.CancelFunc
+		checks     map[string]string
+		ready      bool
+		err        error
+		bytes      []byte
+	)
+	ctx, cancelFunc = context.WithTimeout(context.TODO(), 2*time.Second)
+	defer cancelFunc()
+
+	checks = make(map[string]string)
+	ready = true
+	if atomic.LoadInt32(&shuttingDown) == 1 {
+		checks["shutdown"] = "正在退出"
+		ready = false
+	}
+	// 启动过程中模块可能还没有初始化
+	if G_jobMgr == nil || G_logSink == nil {
+		checks["init"] = "正在启动"
+		ready = false
+	} else {
+		checks["etcd"] = "ok"
+		if _, err = G_jobMgr.kv.Get(ctx, common.JOB_SAVE_DIR, clientv3.WithPrefix(), clientv3.WithCountOnly()); err != nil {
+			checks["etcd"] = err.Error()
+			ready = false
+		}
+		checks["logStore"] = "ok"
+		if err = G_logSink.store.Ping(ctx); err != nil {
+			checks["logStore"] = err.Error()
+			ready = false
+		}
+	}
+
+	if !ready {
+		resp.WriteHeader(http.StatusServiceUnavailable)
+		if bytes, err = common.BuildResponse(-1, "not ready", checks); err == nil {
+			resp.Write(bytes)
+		}
+		return
+	}
+	if bytes, err = common.BuildResponse(0, "success", checks); err == nil {
+		resp.Write(bytes)
+	}
+}
+
+// 优雅退出：注销节点 -> 停止调度 -> 等待任务结束 -> 日志落盘
+func Shutdown() {
+	var (
+		timeout time.Duration
+	)
+	atomic.StoreInt32(&shuttingDown, 1)
+	timeout = time.Duration(G_config.ShutdownTimeout) * time.Millisecond
+
+	// 先注销，master和其他worker立刻能看到该节点下线
+	G_register.Deregister()
+	lifecycleLog.Info("节点已注销，等待正在执行的任务结束")
+
+	// 不再调度新任务，等待正在执行的任务结束，超时后强杀
+	G_scheduler.Stop(timeout)
+
+	// 执行结果上报和日志落盘
+	G_jobMgr.WaitResults(5 * time.Second)
+	G_logSink.Close()
+	lifecycleLog.Info("退出完成")
+}
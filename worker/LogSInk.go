@@ -2,23 +2,145 @@ package worker
 
 import (
 	"../common"
-	"context"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"../logger"
+	"../logstore"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var (
+	logSinkLog = logger.Component("logSink")
+)
+
 // 存储日志
 type LogSink struct {
-	client         *mongo.Client
-	logCollection  *mongo.Collection
+	store          logstore.LogStore // 日志存储，由配置决定
 	logChan        chan *common.JobLog
 	autoCommitChan chan *common.LogBatch
+	spool          *LogSpool     // 本地预写文件
+	flushChan      chan struct{} // 通知补发协程有新的段文件
+	droppedLogs    int64         // 丢弃的日志条数
+	flushErrors    int64         // 写日志存储失败的次数
+	flushLock      sync.Mutex    // 补发协程和退出时的补发互斥，避免重复写入
+	closeChan      chan struct{} // 通知写日志协程退出
+	closedChan     chan struct{} // 写日志协程已把剩余日志写入预写文件
+}
+
+// 写入日志存储
+func (logSink *LogSink) insertLogs(batch *common.LogBatch) (err error) {
+	return logSink.store.Append(batch.Logs)
 }
 
-// 批量插入日志
+// 批量保存日志：先写本地预写文件，再由补发协程写入日志存储
 func (logSink *LogSink) saveLogs(batch *common.LogBatch) {
-	logSink.logCollection.InsertMany(context.TODO(), batch.Logs)
+	var (
+		err error
+	)
+	if _, err = logSink.spool.Write(batch); err != nil {
+		// 落盘失败（磁盘满等），退化为直接写日志存储
+		logSinkLog.WithError(err).Error("日志写入预写文件失败，直接写入日志存储")
+		if err = logSink.insertLogs(batch); err != nil {
+			atomic.AddInt64(&logSink.flushErrors, 1)
+			atomic.AddInt64(&logSink.droppedLogs, int64(len(batch.Logs)))
+		}
+		return
+	}
+	select {
+	case logSink.flushChan <- struct{}{}:
+	default:
+		// 补发协程已经有待处理的通知
+	}
+}
+
+// 隔离无法补发的段文件，避免一个坏段卡住后面所有的日志
+func (logSink *LogSink) quarantine(segment string, cause error) (err error) {
+	if err = logSink.spool.Quarantine(segment); err != nil {
+		return
+	}
+	logSinkLog.WithError(cause).WithField("segment", segment+spoolBadExt).Error("预写文件无法写入日志存储，已隔离")
+	return
+}
+
+// 按写入顺序补发所有段文件，日志存储不可用时停下，等待下次重试
+// 读不出来或者被日志存储拒绝的段文件隔离后继续补发后面的段
+func (logSink *LogSink) flushSpool() (err error) {
+	var (
+		segment string
+		batch   *common.LogBatch
+	)
+	logSink.flushLock.Lock()
+	defer logSink.flushLock.Unlock()
+	for _, segment = range logSink.spool.Pending() {
+		if batch, err = logSink.spool.Read(segment); err != nil {
+			if err = logSink.quarantine(segment, err); err != nil {
+				return
+			}
+			continue
+		}
+		if len(batch.Logs) != 0 {
+			if err = logSink.insertLogs(batch); err != nil {
+				atomic.AddInt64(&logSink.flushErrors, 1)
+				if !errors.Is(err, common.ERR_LOG_REJECTED) {
+					return
+				}
+				if err = logSink.quarantine(segment, err); err != nil {
+					return
+				}
+				continue
+			}
+		}
+		if err = logSink.spool.Remove(segment); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// 补发协程，日志存储不可用时按指数退避重试
+func (logSink *LogSink) flushLoop() {
+	var (
+		err        error
+		retryAfter time.Duration
+		minRetry   time.Duration
+		maxRetry   time.Duration
+	)
+	minRetry = time.Duration(G_config.JobLogRetryInterval) * time.Millisecond
+	maxRetry = 60 * time.Second
+	retryAfter = minRetry
+	for {
+		if err = logSink.flushSpool(); err != nil {
+			logSinkLog.WithError(err).WithField("retryAfter", retryAfter.String()).Warn("日志写入存储失败，稍后重试")
+			// 退避期间不响应新批次的通知，直接等待
+			time.Sleep(retryAfter)
+			if retryAfter *= 2; retryAfter > maxRetry {
+				retryAfter = maxRetry
+			}
+			continue
+		}
+		retryAfter = minRetry
+		// 等待新的段文件，或者定期检查一次
+		select {
+		case <-logSink.flushChan:
+		case <-time.After(minRetry):
+		}
+	}
+}
+
+// 日志模块的运行状态
+func (logSink *LogSink) Stats() (stats *common.LogSinkStats) {
+	stats = &common.LogSinkStats{
+		QueueLen:    len(logSink.logChan),
+		DroppedLogs: atomic.LoadInt64(&logSink.droppedLogs),
+		FlushErrors: atomic.LoadInt64(&logSink.flushErrors),
+	}
+	stats.SpoolSegments, stats.SpoolLogs = logSink.spool.Depth()
+	return
 }
 
 // 日志存储协程
@@ -33,7 +155,7 @@ func (logSink *LogSink) writeLoop() {
 		select {
 		case log = <-logSink.logChan:
 			// 将log写入
-			// 每次插入需要等待mongodb的一次请求往返，耗时可能因为网络花费较长的时间
+			// 每次插入需要等待日志存储的一次请求往返，耗时可能因为网络花费较长的时间
 			if logBatch == nil {
 				logBatch = &common.LogBatch{} // 初始化
 
@@ -67,16 +189,44 @@ func (logSink *LogSink) writeLoop() {
 			}
 			logSink.saveLogs(timeoutBatch)
 			logBatch = nil
+		case <-logSink.closeChan: // 进程退出，队列中剩余的日志全部落盘
+			if logBatch == nil {
+				logBatch = &common.LogBatch{}
+			} else {
+				commitTimer.Stop()
+			}
+			for len(logSink.logChan) != 0 {
+				logBatch.Logs = append(logBatch.Logs, <-logSink.logChan)
+			}
+			if len(logBatch.Logs) != 0 {
+				logSink.saveLogs(logBatch)
+			}
+			close(logSink.closedChan)
+			return
 		}
 	}
 }
 
+// 退出前把日志写入存储，写不进去的保留在预写文件中，下次启动后补发
+func (logSink *LogSink) Close() {
+	var (
+		err error
+	)
+	close(logSink.closeChan)
+	<-logSink.closedChan
+	if err = logSink.flushSpool(); err != nil {
+		logSinkLog.WithError(err).Warn("退出时日志未能全部写入存储，已保留在预写文件中")
+	}
+	logSink.store.Close()
+}
+
 // 发送日志
 func (logSink *LogSink) Append(jobLog *common.JobLog) {
 	select {
 	case logSink.logChan <- jobLog:
 	default:
 		// 队列满了就丢弃
+		atomic.AddInt64(&logSink.droppedLogs, 1)
 	}
 }
 
@@ -84,24 +234,65 @@ var (
 	G_logSink *LogSink
 )
 
+// 老版本的段文件直接放在预写目录下，移到当前worker的子目录中补发
+func adoptLegacySpool(rootDir string, spoolDir string) (err error) {
+	var (
+		fileArr []os.FileInfo
+		file    os.FileInfo
+	)
+	if fileArr, err = ioutil.ReadDir(rootDir); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	if err = os.MkdirAll(spoolDir, 0755); err != nil {
+		return
+	}
+	for _, file = range fileArr {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), spoolSegmentExt) {
+			continue
+		}
+		if err = os.Rename(filepath.Join(rootDir, file.Name()), filepath.Join(spoolDir, file.Name())); err != nil && !os.IsNotExist(err) {
+			return
+		}
+		err = nil
+	}
+	return
+}
+
 func InitLogSink() (err error) {
 	var (
-		client *mongo.Client
+		store    logstore.LogStore
+		spool    *LogSpool
+		spoolDir string
 	)
-	// 1.建立连接
-	ctx, _ := context.WithTimeout(context.Background(), time.Duration(G_config.MongodbConnectTimeout)*time.Millisecond)
-	if client, err = mongo.Connect(ctx, &options.ClientOptions{Hosts: []string{G_config.MongodbUri}}); err != nil {
+	// 加载本地预写文件，每个worker用自己的子目录，同一台主机上的多个worker不会补发彼此的段文件
+	spoolDir = filepath.Join(G_config.JobLogSpoolDir, G_register.workerId)
+	if err = adoptLegacySpool(G_config.JobLogSpoolDir, spoolDir); err != nil {
+		return
+	}
+	if spool, err = InitLogSpool(spoolDir); err != nil {
+		return
+	}
+	// 按配置创建日志存储
+	if store, err = logstore.NewLogStore(G_config.LogStoreConfig()); err != nil {
 		return
 	}
 
 	G_logSink = &LogSink{
-		client:         client,
-		logCollection:  client.Database("my_db").Collection("my_collection"),
+		store:          store,
 		logChan:        make(chan *common.JobLog, 1000),
 		autoCommitChan: make(chan *common.LogBatch, 1000),
+		spool:          spool,
+		flushChan:      make(chan struct{}, 1),
+		closeChan:      make(chan struct{}),
+		closedChan:     make(chan struct{}),
 	}
 
-	// 启动一个mongodb处理协程
+	// 启动一个日志处理协程
 	go G_logSink.writeLoop()
+	// 启动补发协程，上次没写进日志存储的日志会先补发
+	go G_logSink.flushLoop()
 	return
 }
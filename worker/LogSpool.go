@@ -0,0 +1,206 @@
+package worker
+
+import (
+	"../common"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 日志本地预写文件，日志存储不可用时日志先落盘，恢复后再补发
+// 每个批次写成一个段文件 <序号>.log，一行一条json日志，文件名按序号递增，补发时按文件名顺序发送
+type LogSpool struct {
+	dir string // 段文件目录
+
+	lock      sync.Mutex
+	seq       int64          // 下一个段文件的序号
+	segments  map[string]int // 待补发的段文件 -> 日志条数
+	spoolLogs int            // 待补发的日志总条数
+}
+
+// 段文件后缀
+const spoolSegmentExt = ".log"
+
+// 隔离的段文件追加的后缀
+const spoolBadExt = ".bad"
+
+// 批次写入一个新的段文件，写完fsync，保证进程崩溃后不丢
+func (spool *LogSpool) Write(batch *common.LogBatch) (segment string, err error) {
+	var (
+		file    *os.File
+		writer  *bufio.Writer
+		log     *common.JobLog
+		line    []byte
+		tmpPath string
+	)
+	spool.lock.Lock()
+	segment = fmt.Sprintf("%020d%s", spool.seq, spoolSegmentExt)
+	spool.seq++
+	spool.lock.Unlock()
+
+	// 先写临时文件，再rename，避免补发协程读到写了一半的段
+	tmpPath = filepath.Join(spool.dir, segment+".tmp")
+	if file, err = os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644); err != nil {
+		return
+	}
+	writer = bufio.NewWriter(file)
+	for _, log = range batch.Logs {
+		if line, err = json.Marshal(log); err != nil {
+			goto FAIL
+		}
+		writer.Write(line)
+		writer.WriteByte('\n')
+	}
+	if err = writer.Flush(); err != nil {
+		goto FAIL
+	}
+	if err = file.Sync(); err != nil {
+		goto FAIL
+	}
+	file.Close()
+	if err = os.Rename(tmpPath, filepath.Join(spool.dir, segment)); err != nil {
+		os.Remove(tmpPath)
+		return
+	}
+
+	spool.lock.Lock()
+	spool.segments[segment] = len(batch.Logs)
+	spool.spoolLogs += len(batch.Logs)
+	spool.lock.Unlock()
+	return
+
+FAIL:
+	file.Close()
+	os.Remove(tmpPath)
+	return
+}
+
+// 待补发的段文件，按写入顺序排列
+func (spool *LogSpool) Pending() (segmentArr []string) {
+	var (
+		segment string
+	)
+	spool.lock.Lock()
+	defer spool.lock.Unlock()
+	segmentArr = make([]string, 0, len(spool.segments))
+	for segment = range spool.segments {
+		segmentArr = append(segmentArr, segment)
+	}
+	sort.Strings(segmentArr)
+	return
+}
+
+// 读取一个段文件中的日志，格式错误的行直接跳过
+func (spool *LogSpool) Read(segment string) (batch *common.LogBatch, err error) {
+	var (
+		file    *os.File
+		scanner *bufio.Scanner
+		jobLog  *common.JobLog
+	)
+	if file, err = os.Open(filepath.Join(spool.dir, segment)); err != nil {
+		return
+	}
+	defer file.Close()
+
+	batch = &common.LogBatch{}
+	scanner = bufio.NewScanner(file)
+	// 任务输出可能很长，放大单行的缓冲区
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		jobLog = &common.JobLog{}
+		if err = json.Unmarshal(scanner.Bytes(), jobLog); err != nil {
+			continue
+		}
+		batch.Logs = append(batch.Logs, jobLog)
+	}
+	err = scanner.Err()
+	return
+}
+
+// 无法补发的段文件改名为 .bad 隔离，不再重试，留给人工排查
+func (spool *LogSpool) Quarantine(segment string) (err error) {
+	if err = os.Rename(filepath.Join(spool.dir, segment), filepath.Join(spool.dir, segment+spoolBadExt)); err != nil && !os.IsNotExist(err) {
+		return
+	}
+	err = nil
+	spool.lock.Lock()
+	spool.spoolLogs -= spool.segments[segment]
+	delete(spool.segments, segment)
+	spool.lock.Unlock()
+	return
+}
+
+// 段文件已成功写入日志存储，删除
+func (spool *LogSpool) Remove(segment string) (err error) {
+	if err = os.Remove(filepath.Join(spool.dir, segment)); err != nil && !os.IsNotExist(err) {
+		return
+	}
+	err = nil
+	spool.lock.Lock()
+	spool.spoolLogs -= spool.segments[segment]
+	delete(spool.segments, segment)
+	spool.lock.Unlock()
+	return
+}
+
+// 待补发的段文件数和日志条数
+func (spool *LogSpool) Depth() (segmentCount int, logCount int) {
+	spool.lock.Lock()
+	defer spool.lock.Unlock()
+	return len(spool.segments), spool.spoolLogs
+}
+
+// 统计段文件中的日志条数
+func countSpoolLines(path string) (count int) {
+	var (
+		content []byte
+		err     error
+	)
+	if content, err = ioutil.ReadFile(path); err != nil {
+		return
+	}
+	return strings.Count(string(content), "\n")
+}
+
+// 初始化预写目录，加载上次进程退出时没有补发完的段文件
+func InitLogSpool(dir string) (spool *LogSpool, err error) {
+	var (
+		fileArr []os.FileInfo
+		file    os.FileInfo
+		name    string
+		count   int
+	)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	if fileArr, err = ioutil.ReadDir(dir); err != nil {
+		return
+	}
+	spool = &LogSpool{
+		dir:      dir,
+		seq:      time.Now().UnixNano(), // 序号从当前时间开始，保证排在旧段文件之后
+		segments: make(map[string]int),
+	}
+	for _, file = range fileArr {
+		name = file.Name()
+		// 崩溃时残留的临时文件，数据没有fsync，直接清理
+		if strings.HasSuffix(name, ".tmp") {
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		if file.IsDir() || !strings.HasSuffix(name, spoolSegmentExt) {
+			continue
+		}
+		count = countSpoolLines(filepath.Join(dir, name))
+		spool.segments[name] = count
+		spool.spoolLogs += count
+	}
+	return
+}
@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"../common"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogSpoolQuarantine(t *testing.T) {
+	var (
+		dir      string
+		spool    *LogSpool
+		good     string
+		bad      string
+		batch    *common.LogBatch
+		segments int
+		logs     int
+		pending  []string
+		err      error
+	)
+	if dir, err = ioutil.TempDir("", "spool"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if spool, err = InitLogSpool(dir); err != nil {
+		t.Fatal(err)
+	}
+	if bad, err = spool.Write(&common.LogBatch{Logs: []*common.JobLog{{JobName: "a"}, {JobName: "b"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if good, err = spool.Write(&common.LogBatch{Logs: []*common.JobLog{{JobName: "c"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if pending = spool.Pending(); len(pending) != 2 || pending[0] != bad || pending[1] != good {
+		t.Fatalf("段文件顺序不对: %v", pending)
+	}
+
+	if err = spool.Quarantine(bad); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, bad+spoolBadExt)); err != nil {
+		t.Fatalf("隔离的段文件不存在: %v", err)
+	}
+	if segments, logs = spool.Depth(); segments != 1 || logs != 1 {
+		t.Fatalf("隔离后的深度 %d/%d，期望 1/1", segments, logs)
+	}
+	// 已经不存在的段文件也能隔离
+	if err = spool.Quarantine("missing.log"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载时跳过隔离的段文件
+	if spool, err = InitLogSpool(dir); err != nil {
+		t.Fatal(err)
+	}
+	if pending = spool.Pending(); len(pending) != 1 || pending[0] != good {
+		t.Fatalf("重新加载的段文件不对: %v", pending)
+	}
+	if batch, err = spool.Read(good); err != nil || len(batch.Logs) != 1 || batch.Logs[0].JobName != "c" {
+		t.Fatalf("读取段文件失败: %v %v", batch, err)
+	}
+}
+
+func TestAdoptLegacySpool(t *testing.T) {
+	var (
+		dir      string
+		spoolDir string
+		fileArr  []os.FileInfo
+		err      error
+	)
+	if dir, err = ioutil.TempDir("", "spool"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "00000000000000000001.log"), []byte("{}\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "00000000000000000002.log.bad"), []byte("{}\n"), 0644)
+	spoolDir = filepath.Join(dir, "host-abcdef")
+
+	if err = adoptLegacySpool(dir, spoolDir); err != nil {
+		t.Fatal(err)
+	}
+	if fileArr, err = ioutil.ReadDir(spoolDir); err != nil || len(fileArr) != 1 || fileArr[0].Name() != "00000000000000000001.log" {
+		t.Fatalf("老版本的段文件没有移到子目录: %v %v", fileArr, err)
+	}
+	// 预写目录不存在时什么都不做
+	if err = adoptLegacySpool(filepath.Join(dir, "missing"), spoolDir); err != nil {
+		t.Fatal(err)
+	}
+}
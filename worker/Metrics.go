@@ -0,0 +1,143 @@
+package worker
+
+import (
+	"../common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// prometheus监控指标，通过 metricsPort 端口的 /metrics 暴露
+var (
+	// 任务到期被调度的次数
+	metricJobScheduled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "crontab_worker_job_scheduled_total",
+		Help: "Number of times a job was due on this worker.",
+	}, []string{"job"})
+
+	// 任务真正开始执行的次数（抢到锁或广播任务）
+	metricJobStarted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "crontab_worker_job_started_total",
+		Help: "Number of job runs started on this worker.",
+	}, []string{"job"})
+
+	// 任务执行结果 succeeded / failed / timed_out / killed / lock_lost
+	metricJobFinished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "crontab_worker_job_finished_total",
+		Help: "Number of finished job runs by result.",
+	}, []string{"job", "result"})
+
+	// 任务执行耗时
+	metricJobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "crontab_worker_job_duration_seconds",
+		Help:    "Execution time of job runs.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 4, 10), // 0.1秒 ~ 7小时
+	}, []string{"job"})
+
+	// 调度延迟：实际调度时间 - 计划调度时间
+	metricJobScheduleLag = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "crontab_worker_job_schedule_lag_seconds",
+		Help:    "Delay between the planned and the actual schedule time.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 10), // 1毫秒 ~ 4分钟
+	}, []string{"job"})
+
+	// 租约续租失败次数 register 服务注册 / lock 任务锁
+	metricLeaseKeepAliveFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "crontab_worker_lease_keepalive_failures_total",
+		Help: "Number of lost etcd leases.",
+	}, []string{"lease"})
+)
+
+const (
+	// 任务执行结果
+	JOB_RESULT_SUCCEEDED = "succeeded"
+	JOB_RESULT_FAILED    = "failed"
+	JOB_RESULT_TIMED_OUT = "timed_out"
+	JOB_RESULT_KILLED    = "killed"
+	JOB_RESULT_LOCK_LOST = "lock_lost"
+
+	JOB_RESULT_QUOTA_EXCEEDED = "quota_exceeded"
+	JOB_RESULT_NO_FREE_SLOT   = "no_free_slot"
+)
+
+// 任务执行结果分类
+func jobResultType(result *common.JobExecuteResult) string {
+	if result.Err == common.ERR_LOCK_ALREADY_REQUIRED {
+		return JOB_RESULT_LOCK_LOST
+	}
+	if result.Err == common.ERR_RUN_QUOTA_EXCEEDED {
+		return JOB_RESULT_QUOTA_EXCEEDED
+	}
+	if result.Err == common.ERR_NO_FREE_SLOT {
+		return JOB_RESULT_NO_FREE_SLOT
+	}
+	if result.Err == common.ERR_JOB_TIMEOUT {
+		return JOB_RESULT_TIMED_OUT
+	}
+	if result.ExecuteInfo.CancelCtx.Err() != nil { // 被强杀
+		return JOB_RESULT_KILLED
+	}
+	if result.Err != nil {
+		return JOB_RESULT_FAILED
+	}
+	return JOB_RESULT_SUCCEEDED
+}
+
+// 日志模块的指标，采集时实时读取
+func logSinkGauge(value func(stats *common.LogSinkStats) float64) func() float64 {
+	return func() float64 {
+		if G_logSink == nil {
+			return 0
+		}
+		return value(G_logSink.Stats())
+	}
+}
+
+// 注册监控指标，并启动 /metrics 以及健康检查的http服务
+func InitMetrics() (err error) {
+	var (
+		mux      *http.ServeMux
+		listener net.Listener
+	)
+	prometheus.MustRegister(metricJobScheduled, metricJobStarted, metricJobFinished,
+		metricJobDuration, metricJobScheduleLag, metricLeaseKeepAliveFailures)
+	prometheus.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "crontab_worker_log_queue_length",
+			Help: "Number of logs waiting in the LogSink queue.",
+		}, logSinkGauge(func(stats *common.LogSinkStats) float64 { return float64(stats.QueueLen) })),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "crontab_worker_log_spool_logs",
+			Help: "Number of logs in the local spool waiting to be flushed.",
+		}, logSinkGauge(func(stats *common.LogSinkStats) float64 { return float64(stats.SpoolLogs) })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "crontab_worker_log_dropped_total",
+			Help: "Number of logs dropped by the LogSink.",
+		}, logSinkGauge(func(stats *common.LogSinkStats) float64 { return float64(stats.DroppedLogs) })),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "crontab_worker_running_jobs",
+			Help: "Number of jobs currently executing on this worker.",
+		}, func() float64 {
+			if G_scheduler == nil {
+				return 0
+			}
+			return float64(G_scheduler.ExecutingJobCount())
+		}),
+	)
+
+	// 没有配置端口则不对外暴露
+	if G_config.MetricsPort <= 0 {
+		return
+	}
+	mux = http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", handleHealthz) // 存活检查
+	mux.HandleFunc("/readyz", handleReadyz)   // 就绪检查
+	if listener, err = net.Listen("tcp", ":"+strconv.Itoa(G_config.MetricsPort)); err != nil {
+		return
+	}
+	go http.Serve(listener, mux)
+	return
+}
@@ -2,18 +2,159 @@ package worker
 
 import (
 	"../common"
+	"../logger"
+	"bufio"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
-// 注册当前节点到etcd /cron/workers/IP地址
+// 注册当前节点到etcd /cron/workers/workerID
 type Register struct {
-	client  *clientv3.Client
-	kv      clientv3.KV
-	lease   clientv3.Lease
-	localIP string // 本机ip
+	client    *clientv3.Client
+	kv        clientv3.KV
+	lease     clientv3.Lease
+	watcher   clientv3.Watcher
+	workerId  string    // 节点ID，作为注册的key
+	localIP   string    // 本机对外地址
+	hostname  string    // 主机名
+	startTime time.Time // 进程启动时间
+	memTotal  uint64    // 内存总量
+	idFile    *os.File  // 自动生成节点ID时持有锁的ID文件，防止同目录的其他worker用同一个ID
+
+	flagLock    sync.RWMutex
+	cordonFlag  string        // 维护标记，空/cordon/drain，由master写入
+	refreshChan chan struct{} // 通知立即刷新注册信息
+
+	stopChan    chan struct{} // 通知注册协程注销节点
+	stoppedChan chan struct{} // 注册协程已退出
+}
+
+// 是否禁止调度新任务（cordon和drain都不再接收新任务）
+func (register *Register) IsCordoned() bool {
+	register.flagLock.RLock()
+	defer register.flagLock.RUnlock()
+	return register.cordonFlag != ""
+}
+
+// 当前节点的调度状态
+func (register *Register) state(runningJobs int) string {
+	register.flagLock.RLock()
+	defer register.flagLock.RUnlock()
+	switch register.cordonFlag {
+	case common.WORKER_FLAG_CORDON:
+		return common.WORKER_STATE_CORDONED
+	case common.WORKER_FLAG_DRAIN:
+		if runningJobs > 0 {
+			return common.WORKER_STATE_DRAINING
+		}
+		return common.WORKER_STATE_DRAINED
+	}
+	return common.WORKER_STATE_ACTIVE
+}
+
+// 更新维护标记，并立即刷新注册信息让master看到最新状态
+func (register *Register) setCordonFlag(flag string) {
+	register.flagLock.Lock()
+	register.cordonFlag = flag
+	register.flagLock.Unlock()
+	select {
+	case register.refreshChan <- struct{}{}:
+	default:
+		// 已经有一次待处理的刷新
+	}
+}
+
+// 监听master写入的维护标记 /cron/cordon/workerID
+func (register *Register) watchCordon() (err error) {
+	var (
+		cordonKey  string
+		getResp    *clientv3.GetResponse
+		watchChan  clientv3.WatchChan
+		watchResp  clientv3.WatchResponse
+		watchEvent *clientv3.Event
+	)
+	cordonKey = common.JOB_CORDON_DIR + register.workerId
+	// 先读取当前标记，节点重启后保持维护状态
+	if getResp, err = register.kv.Get(context.TODO(), cordonKey); err != nil {
+		return
+	}
+	if len(getResp.Kvs) != 0 {
+		register.setCordonFlag(string(getResp.Kvs[0].Value))
+	}
+
+	go func() {
+		// 从GET时刻的后续版本开始监听变化
+		watchChan = register.watcher.Watch(context.TODO(), cordonKey, clientv3.WithRev(getResp.Header.Revision+1))
+		for watchResp = range watchChan {
+			for _, watchEvent = range watchResp.Events {
+				switch watchEvent.Type {
+				case mvccpb.PUT: // cordon / drain
+					register.setCordonFlag(string(watchEvent.Kv.Value))
+				case mvccpb.DELETE: // uncordon
+					register.setCordonFlag("")
+				}
+			}
+		}
+	}()
+	return
+}
+
+// 构造当前节点的注册信息
+func (register *Register) buildWorkerInfo() (workerInfo *common.WorkerInfo) {
+	workerInfo = &common.WorkerInfo{
+		ID:             register.workerId,
+		IP:             register.localIP,
+		Hostname:       register.hostname,
+		Pid:            os.Getpid(),
+		Version:        common.VERSION,
+		StartTime:      register.startTime.UnixNano() / 1000 / 1000,
+		Labels:         G_config.WorkerLabels,
+		CpuNum:         runtime.NumCPU(),
+		MemTotal:       register.memTotal,
+		MaxConcurrency: G_config.MaxConcurrentJobs,
+		UpdateTime:     time.Now().UnixNano() / 1000 / 1000,
+	}
+	// 注册先于调度器、日志模块初始化，它们可能还不存在
+	if G_scheduler != nil {
+		workerInfo.RunningJobs = G_scheduler.ExecutingJobCount()
+	}
+	if G_logSink != nil {
+		workerInfo.LogSink = G_logSink.Stats()
+	}
+	workerInfo.FreeSlots = -1
+	if workerInfo.MaxConcurrency > 0 {
+		if workerInfo.FreeSlots = workerInfo.MaxConcurrency - workerInfo.RunningJobs; workerInfo.FreeSlots < 0 {
+			workerInfo.FreeSlots = 0
+		}
+	}
+	workerInfo.State = register.state(workerInfo.RunningJobs)
+	return
+}
+
+// 将注册信息写到etcd，与租约绑定
+func (register *Register) putWorkerInfo(ctx context.Context, regKey string, leaseId clientv3.LeaseID) (err error) {
+	var (
+		regValue []byte
+	)
+	if regValue, err = json.Marshal(register.buildWorkerInfo()); err != nil {
+		return
+	}
+	_, err = register.kv.Put(ctx, regKey, string(regValue), clientv3.WithLease(leaseId))
+	return
 }
 
 // 自动注册到etcd
@@ -26,11 +167,13 @@ func (register *Register) keepOnline() {
 		keepAliveResp  *clientv3.LeaseKeepAliveResponse
 		cancelCtx      context.Context
 		cancelFunc     context.CancelFunc
+		refreshTicker  *time.Ticker
 	)
 
 	for {
-		regKey = common.JOB_WORKER_DIR + register.localIP
+		regKey = common.JOB_WORKER_DIR + register.workerId
 		cancelFunc = nil
+		refreshTicker = nil
 		// 创建10秒租约
 		if leaseGrantResp, err = register.lease.Grant(context.TODO(), 10); err != nil {
 			// 如果创建租约失败，则休息后重试
@@ -44,42 +187,86 @@ func (register *Register) keepOnline() {
 		cancelCtx, cancelFunc = context.WithCancel(context.TODO())
 
 		// 注册到etcd
-		if _, err = register.kv.Put(cancelCtx, regKey, "", clientv3.WithLease(leaseGrantResp.ID)); err != nil {
+		if err = register.putWorkerInfo(cancelCtx, regKey, leaseGrantResp.ID); err != nil {
 			goto RETRY
 		}
 
 		// 如果服务正常，就在这里卡住了，因为一直会有续租
 
+		// 定期刷新注册信息（正在执行的任务数等）
+		refreshTicker = time.NewTicker(time.Duration(G_config.HeartbeatInterval) * time.Millisecond)
+
 		// 处理续租应答
 		for {
 			select {
 			case keepAliveResp = <-keepAliveChan:
 				if keepAliveResp == nil { //续租失败，超过了租约的时间，key就会消失
+					metricLeaseKeepAliveFailures.WithLabelValues("register").Inc()
+					registerLog.Warn("注册租约失效，重新注册")
+					goto RETRY
+				}
+			case <-refreshTicker.C:
+				if err = register.putWorkerInfo(cancelCtx, regKey, leaseGrantResp.ID); err != nil {
 					goto RETRY
 				}
+			case <-register.refreshChan: // 维护标记变化
+				if err = register.putWorkerInfo(cancelCtx, regKey, leaseGrantResp.ID); err != nil {
+					goto RETRY
+				}
+			case <-register.stopChan: // 进程退出，撤销租约，注册信息随之删除
+				refreshTicker.Stop()
+				cancelFunc()
+				register.lease.Revoke(context.TODO(), leaseGrantResp.ID)
+				close(register.stoppedChan)
+				return
 			}
 		}
 
 	RETRY:
-		time.Sleep(1 * time.Second)
+		if err != nil {
+			registerLog.WithError(err).Warn("注册节点失败，稍后重试")
+		}
+		if refreshTicker != nil {
+			refreshTicker.Stop()
+		}
 		if cancelFunc != nil {
 			cancelFunc()
 		}
+		select {
+		case <-time.After(1 * time.Second):
+		case <-register.stopChan: // 注册失败期间退出，租约会自动过期
+			close(register.stoppedChan)
+			return
+		}
 	}
 
 }
 
+// 注销节点，等待注册协程退出
+func (register *Register) Deregister() {
+	close(register.stopChan)
+	<-register.stoppedChan
+}
+
+const (
+	// 同一个ID文件最多尝试的后缀个数，即同一目录下最多启动的worker数
+	maxWorkerIdFiles = 64
+)
+
 var (
 	G_register *Register
+
+	registerLog = logger.Component("register")
 )
 
-// 获取本机网卡ip
-func getLocalIP() (ipv4 string, err error) {
+// 获取本机网卡ip，优先ipv4，没有ipv4时使用ipv6
+func getLocalIP() (localIP string, err error) {
 	var (
 		addrs   []net.Addr
 		addr    net.Addr
 		ipNet   *net.IPNet // ip地址
 		isIpNet bool
+		ipv6    string
 	)
 	if addrs, err = net.InterfaceAddrs(); err != nil {
 		return
@@ -89,24 +276,130 @@ func getLocalIP() (ipv4 string, err error) {
 		// ipv4 ipv6 进行反解为IPNet类型
 		if ipNet, isIpNet = addr.(*net.IPNet); isIpNet && !ipNet.IP.IsLoopback() {
 			// 这个网络地址是ip地址
-			// 跳过ipv6
 			if ipNet.IP.To4() != nil {
-				ipv4 = ipNet.IP.String()
+				localIP = ipNet.IP.String()
 				return
 			}
+			// 记下第一个全局ipv6地址，跳过fe80::这类链路本地地址
+			if ipv6 == "" && ipNet.IP.IsGlobalUnicast() {
+				ipv6 = ipNet.IP.String()
+			}
 		}
 	}
+	if ipv6 != "" {
+		localIP = ipv6
+		return
+	}
 	err = common.ERR_NO_LOCAL_IP_FOUND
 	return
 }
 
+// 生成默认的节点ID：主机名-6位随机十六进制
+func genWorkerId(hostname string) (workerId string, err error) {
+	var (
+		suffix []byte
+	)
+	suffix = make([]byte, 3)
+	if _, err = rand.Read(suffix); err != nil {
+		return
+	}
+	workerId = hostname + "-" + hex.EncodeToString(suffix)
+	return
+}
+
+// 读取持久化的节点ID，第一次启动时生成并写入文件，保证重启后ID不变（维护标记、预写文件都按ID区分）
+// 进程运行期间一直持有ID文件的排他锁，返回的文件在进程退出时关闭
+// 同一目录下启动多个worker时，文件已被其他进程锁住就依次尝试 文件名.1、文件名.2 …，每个实例的ID各不相同且重启后不变
+func loadWorkerId(path string, hostname string) (workerId string, idFile *os.File, err error) {
+	var (
+		candidate string
+		locked    bool
+		i         int
+	)
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	for i = 0; i < maxWorkerIdFiles; i++ {
+		if candidate = path; i > 0 {
+			candidate = path + "." + strconv.Itoa(i)
+		}
+		if idFile, err = os.OpenFile(candidate, os.O_CREATE|os.O_RDWR, 0644); err != nil {
+			return
+		}
+		if locked, err = tryLockFile(idFile); err != nil || !locked {
+			idFile.Close()
+			if err != nil {
+				return "", nil, err
+			}
+			continue // 被其他worker占用，换下一个文件
+		}
+		if workerId, err = readWorkerId(idFile, hostname); err != nil {
+			idFile.Close()
+			return "", nil, err
+		}
+		return
+	}
+	return "", nil, fmt.Errorf("节点ID文件 %s 及其后缀文件都被其他进程占用", path)
+}
+
+// 从加锁的ID文件中读取节点ID，文件为空时生成新的ID写入
+func readWorkerId(idFile *os.File, hostname string) (workerId string, err error) {
+	var (
+		content []byte
+	)
+	if content, err = ioutil.ReadAll(idFile); err != nil {
+		return
+	}
+	if workerId = strings.TrimSpace(string(content)); workerId != "" {
+		return
+	}
+	if workerId, err = genWorkerId(hostname); err != nil {
+		return
+	}
+	if _, err = idFile.WriteAt([]byte(workerId+"\n"), 0); err != nil {
+		return
+	}
+	err = idFile.Sync()
+	return
+}
+
+// 读取内存总量 /proc/meminfo 中的 MemTotal，读取不到返回0
+func getMemTotal() (memTotal uint64) {
+	var (
+		file    *os.File
+		err     error
+		scanner *bufio.Scanner
+		fields  []string
+	)
+	if file, err = os.Open("/proc/meminfo"); err != nil {
+		return
+	}
+	defer file.Close()
+	scanner = bufio.NewScanner(file)
+	for scanner.Scan() {
+		// MemTotal:       16314460 kB
+		fields = strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			if memTotal, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+				return 0
+			}
+			return memTotal * 1024
+		}
+	}
+	return
+}
+
 func InitRegister() (err error) {
 	var (
-		config  clientv3.Config
-		client  *clientv3.Client
-		kv      clientv3.KV
-		lease   clientv3.Lease
-		localIP string
+		config   clientv3.Config
+		client   *clientv3.Client
+		kv       clientv3.KV
+		lease    clientv3.Lease
+		localIP  string
+		hostname string
+		workerId string
+		idFile   *os.File
+		watcher  clientv3.Watcher
 	)
 	// 初始化配置
 	config = clientv3.Config{
@@ -120,16 +413,48 @@ func InitRegister() (err error) {
 	// 得到KV和lease的API子集
 	kv = clientv3.NewKV(client)
 	lease = clientv3.NewLease(client)
+	watcher = clientv3.NewWatcher(client)
 
-	if localIP, err = getLocalIP(); err != nil {
+	// 优先使用配置的对外地址
+	if localIP = G_config.AdvertiseAddr; localIP == "" {
+		if localIP, err = getLocalIP(); err != nil {
+			return
+		}
+	}
+
+	if hostname, err = os.Hostname(); err != nil {
 		return
 	}
 
+	// 未配置节点ID时自动生成并持久化，保证同一台主机上的多个worker不冲突，重启后ID不变
+	if workerId = G_config.WorkerId; workerId == "" {
+		if workerId, idFile, err = loadWorkerId(G_config.WorkerIdFile, hostname); err != nil {
+			return
+		}
+	}
+	// 之后的每条日志都带上节点ID
+	logger.SetGlobalField("worker", workerId)
+
 	G_register = &Register{
-		client:  client,
-		kv:      kv,
-		lease:   lease,
-		localIP: localIP,
+		client:    client,
+		kv:        kv,
+		lease:     lease,
+		watcher:   watcher,
+		workerId:  workerId,
+		localIP:   localIP,
+		hostname:  hostname,
+		startTime: time.Now(),
+		memTotal:  getMemTotal(),
+		idFile:    idFile,
+
+		refreshChan: make(chan struct{}, 1),
+		stopChan:    make(chan struct{}),
+		stoppedChan: make(chan struct{}),
+	}
+
+	// 监听维护标记
+	if err = G_register.watchCordon(); err != nil {
+		return
 	}
 
 	// 服务注册
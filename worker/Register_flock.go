@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+package worker
+
+import (
+	"os"
+	"syscall"
+)
+
+// 尝试对文件加排他锁（flock），已被其他进程锁住时不等待，返回false；关闭文件时释放
+func tryLockFile(file *os.File) (locked bool, err error) {
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err == syscall.EWOULDBLOCK {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,89 @@
+package worker
+
+import (
+	"../common"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadWorkerId(t *testing.T) {
+	var (
+		dir     string
+		path    string
+		first   string
+		second  string
+		idFile  *os.File
+		other   *os.File
+		content []byte
+		err     error
+	)
+	if dir, err = ioutil.TempDir("", "worker-id"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path = filepath.Join(dir, "sub", "worker.id")
+
+	// 第一次生成并写入文件
+	if first, idFile, err = loadWorkerId(path, "host"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(first, "host-") || len(first) != len("host-")+6 {
+		t.Fatalf("生成的节点ID格式不对: %q", first)
+	}
+	if content, err = ioutil.ReadFile(path); err != nil || strings.TrimSpace(string(content)) != first {
+		t.Fatalf("节点ID没有持久化: %q %v", content, err)
+	}
+
+	// 同目录的另一个worker不能用同一个ID，改用后缀文件
+	if second, other, err = loadWorkerId(path, "host"); err != nil || second == first {
+		t.Fatalf("ID文件被占用时 = %q %v，期望新的ID", second, err)
+	}
+	if content, err = ioutil.ReadFile(path + ".1"); err != nil || strings.TrimSpace(string(content)) != second {
+		t.Fatalf("后缀文件没有持久化: %q %v", content, err)
+	}
+	other.Close()
+
+	// 重启后沿用
+	idFile.Close()
+	if second, idFile, err = loadWorkerId(path, "other"); err != nil || second != first {
+		t.Fatalf("重启后节点ID变了: %q -> %q %v", first, second, err)
+	}
+	idFile.Close()
+
+	// 手工写入的ID去掉首尾空白
+	ioutil.WriteFile(path, []byte("  manual-id \n"), 0644)
+	if second, idFile, err = loadWorkerId(path, "host"); err != nil || second != "manual-id" {
+		t.Fatalf("读取手工写入的节点ID失败: %q %v", second, err)
+	}
+	idFile.Close()
+}
+
+func TestBuildWorkerInfoSlots(t *testing.T) {
+	var (
+		oldConfig    = G_config
+		oldScheduler = G_scheduler
+		register     = &Register{workerId: "w1"}
+		workerInfo   *common.WorkerInfo
+	)
+	defer func() {
+		G_config = oldConfig
+		G_scheduler = oldScheduler
+	}()
+	G_config = &Config{MaxConcurrentJobs: 4}
+	// 抢锁前睡眠和没抢到锁的任务只计入runningJobs，不占槽位
+	G_scheduler = &Scheduler{runningJobs: 5, executingJobs: 1}
+	workerInfo = register.buildWorkerInfo()
+	if workerInfo.RunningJobs != 1 || workerInfo.FreeSlots != 3 {
+		t.Errorf("RunningJobs=%d FreeSlots=%d，期望1和3", workerInfo.RunningJobs, workerInfo.FreeSlots)
+	}
+
+	// 排空中的节点没有占用槽位的任务就是已排空
+	register.cordonFlag = common.WORKER_FLAG_DRAIN
+	G_scheduler = &Scheduler{runningJobs: 2}
+	if workerInfo = register.buildWorkerInfo(); workerInfo.State != common.WORKER_STATE_DRAINED {
+		t.Errorf("State=%s，期望%s", workerInfo.State, common.WORKER_STATE_DRAINED)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build windows
+// +build windows
+
+package worker
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	lockfileFailImmediately = 0x1 // LOCKFILE_FAIL_IMMEDIATELY
+	lockfileExclusiveLock   = 0x2 // LOCKFILE_EXCLUSIVE_LOCK
+	errorLockViolation      = syscall.Errno(33)
+)
+
+var (
+	procLockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+)
+
+// 尝试对文件加排他锁（LockFileEx），已被其他进程锁住时不等待，返回false；关闭文件时释放
+func tryLockFile(file *os.File) (locked bool, err error) {
+	var (
+		overlapped syscall.Overlapped
+		ret        uintptr
+	)
+	if ret, _, err = procLockFileEx.Call(file.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 0xFFFFFFFF, 0xFFFFFFFF, uintptr(unsafe.Pointer(&overlapped))); ret != 0 {
+		return true, nil
+	}
+	if err == errorLockViolation {
+		return false, nil
+	}
+	return false, err
+}
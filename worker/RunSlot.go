@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"../common"
+	"context"
+	"encoding/json"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+	"math/rand"
+	"strconv"
+)
+
+// 命名空间的并发执行槽位，整个集群共享
+// 槽位是 /cron/quota/命名空间/序号 的key，和任务锁一样用事务抢占，使用自动续租的租约
+type RunSlot struct {
+	kv    clientv3.KV
+	lease clientv3.Lease
+
+	namespace  string
+	cancelFunc context.CancelFunc // 用于终止自动续租
+	leaseID    clientv3.LeaseID
+	acquired   bool
+}
+
+// 读取命名空间配额并抢占一个空闲槽位，没有配置并发上限时直接成功
+func (runSlot *RunSlot) TryAcquire() (err error) {
+	var (
+		getResp        *clientv3.GetResponse
+		quota          common.NamespaceQuota
+		slotDir        string
+		taken          map[string]bool
+		kvPair         *mvccpb.KeyValue
+		leaseGrantResp *clientv3.LeaseGrantResponse
+		keepRespChan   <-chan *clientv3.LeaseKeepAliveResponse
+		cancelCtx      context.Context
+		cancelFunc     context.CancelFunc
+		slotKey        string
+		txnResp        *clientv3.TxnResponse
+		i              int
+	)
+	// 1.读取配额
+	if getResp, err = runSlot.kv.Get(context.TODO(), common.JOB_NAMESPACE_DIR+runSlot.namespace); err != nil {
+		return
+	}
+	if len(getResp.Kvs) == 0 {
+		return
+	}
+	if err = json.Unmarshal(getResp.Kvs[0].Value, &quota); err != nil {
+		return
+	}
+	if quota.MaxConcurrentRuns <= 0 {
+		return
+	}
+
+	// 2.已被占用的槽位，全满就不用创建租约了
+	slotDir = common.JOB_QUOTA_DIR + runSlot.namespace + "/"
+	if getResp, err = runSlot.kv.Get(context.TODO(), slotDir, clientv3.WithPrefix(), clientv3.WithKeysOnly()); err != nil {
+		return
+	}
+	if len(getResp.Kvs) >= quota.MaxConcurrentRuns {
+		return common.ERR_RUN_QUOTA_EXCEEDED
+	}
+	taken = make(map[string]bool)
+	for _, kvPair = range getResp.Kvs {
+		taken[string(kvPair.Key)] = true
+	}
+
+	// 3.创建租约，自动续租
+	if leaseGrantResp, err = runSlot.lease.Grant(context.TODO(), 5); err != nil {
+		return
+	}
+	cancelCtx, cancelFunc = context.WithCancel(context.TODO())
+	if keepRespChan, err = runSlot.lease.KeepAlive(cancelCtx, leaseGrantResp.ID); err != nil {
+		goto FAIL
+	}
+	go func() {
+		for range keepRespChan {
+		}
+	}()
+
+	// 4.从随机位置开始抢空闲槽位，减少多个worker之间的冲突
+	for _, i = range rand.Perm(quota.MaxConcurrentRuns) {
+		slotKey = slotDir + strconv.Itoa(i)
+		if taken[slotKey] {
+			continue
+		}
+		if txnResp, err = runSlot.kv.Txn(context.TODO()).
+			If(clientv3.Compare(clientv3.CreateRevision(slotKey), "=", 0)).
+			Then(clientv3.OpPut(slotKey, G_register.workerId, clientv3.WithLease(leaseGrantResp.ID))).
+			Commit(); err != nil {
+			goto FAIL
+		}
+		if txnResp.Succeeded {
+			runSlot.leaseID = leaseGrantResp.ID
+			runSlot.cancelFunc = cancelFunc
+			runSlot.acquired = true
+			return
+		}
+	}
+	err = common.ERR_RUN_QUOTA_EXCEEDED
+
+FAIL:
+	cancelFunc()
+	runSlot.lease.Revoke(context.TODO(), leaseGrantResp.ID)
+	return
+}
+
+// 释放槽位
+func (runSlot *RunSlot) Release() {
+	if runSlot.acquired {
+		runSlot.cancelFunc()
+		runSlot.lease.Revoke(context.TODO(), runSlot.leaseID)
+	}
+}
+
+// 创建命名空间的执行槽位
+func InitRunSlot(namespace string, kv clientv3.KV, lease clientv3.Lease) (runSlot *RunSlot) {
+	return &RunSlot{
+		kv:        kv,
+		lease:     lease,
+		namespace: common.NormalizeNamespace(namespace),
+	}
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"../common"
+	"../logger"
+	"context"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"go.etcd.io/etcd/clientv3"
+	"time"
+)
+
+var (
+	runStateLog = logger.Component("runState")
+)
+
+// 正在执行的任务登记在 /cron/running/任务名/execId，master据此推送run.started事件
+// 和任务锁一样使用自动续租的租约，worker异常退出后自动删除
+type RunState struct {
+	kv    clientv3.KV
+	lease clientv3.Lease
+
+	info       *common.JobExecuteInfo
+	runKey     string
+	cancelFunc context.CancelFunc // 用于终止自动续租
+	leaseID    clientv3.LeaseID
+	registered bool
+}
+
+// 登记为正在执行，失败只打日志，不影响任务执行
+func (runState *RunState) Register(startTime time.Time) {
+	var (
+		leaseGrantResp *clientv3.LeaseGrantResponse
+		keepRespChan   <-chan *clientv3.LeaseKeepAliveResponse
+		cancelCtx      context.Context
+		cancelFunc     context.CancelFunc
+		value          []byte
+		err            error
+	)
+	if value, err = json.Marshal(&common.JobRunState{
+		JobName:      runState.info.Job.FullName(),
+		ExecId:       runState.info.ExecId,
+		Worker:       G_register.workerId,
+		PlanTime:     runState.info.PlanTime.UnixNano() / 1000 / 1000,
+		ScheduleTime: runState.info.RealTime.UnixNano() / 1000 / 1000,
+		StartTime:    startTime.UnixNano() / 1000 / 1000,
+	}); err != nil {
+		goto FAIL
+	}
+	// 5秒租约，自动续租
+	if leaseGrantResp, err = runState.lease.Grant(context.TODO(), 5); err != nil {
+		goto FAIL
+	}
+	cancelCtx, cancelFunc = context.WithCancel(context.TODO())
+	if keepRespChan, err = runState.lease.KeepAlive(cancelCtx, leaseGrantResp.ID); err != nil {
+		goto REVOKE
+	}
+	// 消费续租应答
+	go func() {
+		for range keepRespChan {
+		}
+	}()
+	if _, err = runState.kv.Put(context.TODO(), runState.runKey, string(value), clientv3.WithLease(leaseGrantResp.ID)); err != nil {
+		goto REVOKE
+	}
+	runState.leaseID = leaseGrantResp.ID
+	runState.cancelFunc = cancelFunc
+	runState.registered = true
+	return
+
+REVOKE:
+	cancelFunc()
+	runState.lease.Revoke(context.TODO(), leaseGrantResp.ID)
+FAIL:
+	runStateLog.WithError(err).WithFields(logrus.Fields{
+		"job":    runState.info.Job.FullName(),
+		"execId": runState.info.ExecId,
+	}).Warn("登记执行状态失败")
+}
+
+// 执行结束，撤销租约，登记随之删除
+func (runState *RunState) Unregister() {
+	if runState.registered {
+		runState.cancelFunc()
+		runState.lease.Revoke(context.TODO(), runState.leaseID)
+	}
+}
+
+// 创建执行状态登记
+func InitRunState(info *common.JobExecuteInfo, kv clientv3.KV, lease clientv3.Lease) (runState *RunState) {
+	return &RunState{
+		kv:     kv,
+		lease:  lease,
+		info:   info,
+		runKey: common.JOB_RUNNING_DIR + info.Job.FullName() + "/" + info.ExecId,
+	}
+}
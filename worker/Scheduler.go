@@ -2,7 +2,9 @@ package worker
 
 import (
 	"../common"
-	"fmt"
+	"../logger"
+	"github.com/sirupsen/logrus"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,10 +17,20 @@ type Scheduler struct {
 	jobExecutingTable map[string]*common.JobExecuteInfo
 	// 任务回传结果
 	jobResultChan chan *common.JobExecuteResult
+	// 已调度还没有回传结果的任务数，包括抢锁前睡眠和没抢到锁的，供注册模块并发读取
+	runningJobs int32
+	// 占用执行槽位的任务数，执行协程抢锁前占用，没抢到锁或执行结束后释放，由执行协程并发增减
+	executingJobs int32
+	// 进程退出中，不再调度新任务
+	stopping int32
+	// 通知调度协程强杀所有正在执行的任务
+	killAllChan chan struct{}
 }
 
 var (
 	G_scheduler *Scheduler
+
+	schedulerLog = logger.Component("scheduler")
 )
 
 // 处理任务事件，将任务放在任务表中
@@ -35,16 +47,25 @@ func (scheduler *Scheduler) handleJobEvent(jobEvent *common.JobEvent) {
 		if jobSchedulerPlan, err = common.BuildJobSchedulerPlan(jobEvent.Job); err != nil {
 			return
 		}
-		scheduler.jobPlanTable[jobEvent.Job.Name] = jobSchedulerPlan
+		scheduler.jobPlanTable[jobEvent.Job.FullName()] = jobSchedulerPlan
 	case common.JOB_EVENT_DELETE:
 		// 如果计划表中有任务，就将任务删除
-		if jobSchedulerPlan, jobExisted = scheduler.jobPlanTable[jobEvent.Job.Name]; jobExisted {
-			delete(scheduler.jobPlanTable, jobEvent.Job.Name)
+		if jobSchedulerPlan, jobExisted = scheduler.jobPlanTable[jobEvent.Job.FullName()]; jobExisted {
+			delete(scheduler.jobPlanTable, jobEvent.Job.FullName())
+		}
+	case common.JOB_EVENT_RUN: // 手动执行，按当前时间调度一次，不改变下次调度时间
+		if jobSchedulerPlan, jobExisted = scheduler.jobPlanTable[jobEvent.Job.FullName()]; jobExisted {
+			scheduler.TryStartJob(&common.JobSchedulePlan{
+				Job:      jobSchedulerPlan.Job,
+				Expr:     jobSchedulerPlan.Expr,
+				NextTime: time.Now(),
+				Params:   jobEvent.Params,
+			})
 		}
 	case common.JOB_EVENT_KILL: // 强杀任务事件
 		// 取消掉command执行
 		// 判断任务是否在执行
-		if jobExecuteInfo, jobExecuting = scheduler.jobExecutingTable[jobEvent.Job.Name]; jobExecuting {
+		if jobExecuteInfo, jobExecuting = scheduler.jobExecutingTable[jobEvent.Job.FullName()]; jobExecuting {
 			jobExecuteInfo.CancelFunc() // 取消任务执行，触发command杀死子进程，任务得到退出
 		}
 	}
@@ -58,18 +79,38 @@ func (scheduler *Scheduler) TryStartJob(jobPlan *common.JobSchedulePlan) {
 		jobExecuteInfo *common.JobExecuteInfo
 		jobExecuting   bool
 	)
+	// 进程退出中
+	if atomic.LoadInt32(&scheduler.stopping) == 1 {
+		return
+	}
+	metricJobScheduled.WithLabelValues(jobPlan.Job.FullName()).Inc()
 	// 如果任务正在执行，跳过本次调度
-	if jobExecuteInfo, jobExecuting = scheduler.jobExecutingTable[jobPlan.Job.Name]; jobExecuting {
+	if jobExecuteInfo, jobExecuting = scheduler.jobExecutingTable[jobPlan.Job.FullName()]; jobExecuting {
 		// fmt.Println("正在执行：", jobPlan.Job.Name)
 		return
 	}
+	// 节点处于维护状态，不去抢锁，让其他节点执行
+	if G_register.IsCordoned() {
+		return
+	}
+	// 执行槽位已满，不去抢锁，让有空闲槽位的节点执行
+	if !scheduler.hasFreeSlot() {
+		schedulerLog.WithField("job", jobPlan.Job.FullName()).Warn("执行槽位已满，跳过本次调度")
+		return
+	}
 	// 构建任务执行状态信息
 	jobExecuteInfo = common.BuildJobExecuteInfo(jobPlan)
+	metricJobScheduleLag.WithLabelValues(jobPlan.Job.FullName()).Observe(jobExecuteInfo.RealTime.Sub(jobExecuteInfo.PlanTime).Seconds())
 	// 保存执行状态
-	scheduler.jobExecutingTable[jobPlan.Job.Name] = jobExecuteInfo
+	scheduler.jobExecutingTable[jobPlan.Job.FullName()] = jobExecuteInfo
+	atomic.StoreInt32(&scheduler.runningJobs, int32(len(scheduler.jobExecutingTable)))
 	// 执行任务
 	G_executor.ExecuteJob(jobExecuteInfo)
-	fmt.Println("执行任务：", jobExecuteInfo.Job.Name, jobExecuteInfo.PlanTime, jobExecuteInfo.RealTime)
+	schedulerLog.WithFields(logrus.Fields{
+		"job":      jobExecuteInfo.Job.FullName(),
+		"execId":   jobExecuteInfo.ExecId,
+		"planTime": jobExecuteInfo.PlanTime,
+	}).Debug("调度任务")
 }
 
 // 重新计算任务调度状态
@@ -108,10 +149,11 @@ func (scheduler *Scheduler) TrySchedule() (scheduleAfter time.Duration) {
 // 调度协程
 func (scheduler *Scheduler) scheduleLoop() {
 	var (
-		jobEvent      *common.JobEvent
-		scheduleAfter time.Duration
-		scheduleTimer *time.Timer
-		jobResult     *common.JobExecuteResult
+		jobEvent       *common.JobEvent
+		scheduleAfter  time.Duration
+		scheduleTimer  *time.Timer
+		jobResult      *common.JobExecuteResult
+		jobExecuteInfo *common.JobExecuteInfo
 	)
 	// 初始化一次，先计算一次，这个肯定是1s
 	scheduleAfter = scheduler.TrySchedule()
@@ -130,6 +172,10 @@ func (scheduler *Scheduler) scheduleLoop() {
 		case jobResult = <-scheduler.jobResultChan: // 监听任务执行结果
 			scheduler.handleJobResult(jobResult)
 			// 删除当前任务，重新调度，该任务的下一次时间就会添加上去
+		case <-scheduler.killAllChan: // 退出期限已到，强杀剩余任务
+			for _, jobExecuteInfo = range scheduler.jobExecutingTable {
+				jobExecuteInfo.CancelFunc()
+			}
 		}
 		// 调度一次任务（当有新任务的操作，需要重新计算 || 定时器到期了，需要进入任务表执行最新的任务）
 		scheduleAfter = scheduler.TrySchedule()
@@ -141,17 +187,46 @@ func (scheduler *Scheduler) scheduleLoop() {
 // 处理任务结果
 func (scheduler *Scheduler) handleJobResult(result *common.JobExecuteResult) {
 	var (
-		jobLog *common.JobLog
+		jobLog     *common.JobLog
+		resultType string
+		entry      *logrus.Entry
 	)
+	// 监控指标
+	resultType = jobResultType(result)
+	metricJobFinished.WithLabelValues(result.ExecuteInfo.Job.FullName(), resultType).Inc()
+	if !jobSkipped(resultType) {
+		metricJobDuration.WithLabelValues(result.ExecuteInfo.Job.FullName()).Observe(result.EndTime.Sub(result.StartTime).Seconds())
+	}
 	// 从执行表中删除任务
-	delete(scheduler.jobExecutingTable, result.ExecuteInfo.Job.Name)
-	fmt.Println("任务执行完成：", result.ExecuteInfo.Job.Name, string(result.Output), result.Err)
+	delete(scheduler.jobExecutingTable, result.ExecuteInfo.Job.FullName())
+	// 任务输出只写入执行日志，不打到进程日志里
+	entry = schedulerLog.WithFields(logrus.Fields{
+		"job":         result.ExecuteInfo.Job.FullName(),
+		"execId":      result.ExecuteInfo.ExecId,
+		"result":      resultType,
+		"duration":    result.EndTime.Sub(result.StartTime).String(),
+		"outputBytes": len(result.Output),
+	})
+	switch resultType {
+	case JOB_RESULT_SUCCEEDED:
+		entry.Info("任务执行完成")
+	case JOB_RESULT_LOCK_LOST:
+		entry.Debug("锁被其他节点占用，跳过执行")
+	case JOB_RESULT_QUOTA_EXCEEDED:
+		entry.Warn("超出命名空间的并发执行上限，跳过本次执行")
+	case JOB_RESULT_NO_FREE_SLOT:
+		entry.Warn("执行槽位已满，没有抢锁，跳过本次执行")
+	default:
+		entry.WithError(result.Err).Warn("任务执行失败")
+	}
 
-	// 生成执行日志
-	if result.Err != common.ERR_LOCK_ALREADY_REQUIRED { // 不包含锁被占用的情况
+	// 生成执行日志，锁被占用、超出并发上限和槽位已满时任务没有执行，不写日志也不上报结果，避免被当作失败发送通知
+	if !jobSkipped(resultType) {
 		jobLog = &common.JobLog{
-			JobName:      result.ExecuteInfo.Job.Name,
-			Command:      result.ExecuteInfo.Job.Command,
+			JobName:      result.ExecuteInfo.Job.FullName(),
+			Command:      result.Command,
+			ExecId:       result.ExecuteInfo.ExecId,
+			Worker:       G_register.workerId,
 			Output:       string(result.Output),
 			PlanTime:     result.ExecuteInfo.PlanTime.UnixNano() / 1000 / 1000,
 			ScheduleTime: result.ExecuteInfo.RealTime.UnixNano() / 1000 / 1000,
@@ -163,8 +238,80 @@ func (scheduler *Scheduler) handleJobResult(result *common.JobExecuteResult) {
 		} else {
 			jobLog.Err = ""
 		}
+		jobLog.TimedOut = result.Err == common.ERR_JOB_TIMEOUT
 		// 将日志写到mongodb
 		G_logSink.Append(jobLog)
+		// 上报执行结果，由master判断是否需要发送通知
+		G_jobMgr.PutJobResult(jobLog)
+	}
+	// 日志交给日志模块之后再更新执行数，退出时执行数归零就说明日志都已经提交
+	atomic.StoreInt32(&scheduler.runningJobs, int32(len(scheduler.jobExecutingTable)))
+}
+
+// 任务没有真正执行
+func jobSkipped(resultType string) bool {
+	return resultType == JOB_RESULT_LOCK_LOST || resultType == JOB_RESULT_QUOTA_EXCEEDED || resultType == JOB_RESULT_NO_FREE_SLOT
+}
+
+// 是否还有空闲的执行槽位，maxConcurrentJobs为0表示不限制
+// 调度时只是预判，以执行协程抢锁前的acquireSlot为准
+func (scheduler *Scheduler) hasFreeSlot() bool {
+	if G_config.MaxConcurrentJobs <= 0 {
+		return true
+	}
+	return int(atomic.LoadInt32(&scheduler.executingJobs)) < G_config.MaxConcurrentJobs
+}
+
+// 占用一个执行槽位，执行协程抢锁前调用，槽位已满返回false
+func (scheduler *Scheduler) acquireSlot() bool {
+	var (
+		executing int32
+	)
+	for {
+		executing = atomic.LoadInt32(&scheduler.executingJobs)
+		if G_config.MaxConcurrentJobs > 0 && int(executing) >= G_config.MaxConcurrentJobs {
+			return false
+		}
+		if atomic.CompareAndSwapInt32(&scheduler.executingJobs, executing, executing+1) {
+			return true
+		}
+	}
+}
+
+// 没抢到锁或者命令执行结束，释放执行槽位
+func (scheduler *Scheduler) releaseSlot() {
+	atomic.AddInt32(&scheduler.executingJobs, -1)
+}
+
+// 占用执行槽位的任务数，包括抢锁中的任务，没抢到锁的任务会立即释放槽位
+func (scheduler *Scheduler) ExecutingJobCount() int {
+	return int(atomic.LoadInt32(&scheduler.executingJobs))
+}
+
+// 当前正在执行的任务数
+func (scheduler *Scheduler) RunningJobCount() int {
+	return int(atomic.LoadInt32(&scheduler.runningJobs))
+}
+
+// 停止调度新任务，等待正在执行的任务结束，超过timeout后强杀
+func (scheduler *Scheduler) Stop(timeout time.Duration) {
+	var (
+		deadline time.Time
+	)
+	atomic.StoreInt32(&scheduler.stopping, 1)
+	deadline = time.Now().Add(timeout)
+	for scheduler.RunningJobCount() > 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if scheduler.RunningJobCount() == 0 {
+		return
+	}
+	schedulerLog.WithField("runningJobs", scheduler.RunningJobCount()).Warn("等待任务结束超时，强杀剩余任务")
+	scheduler.killAllChan <- struct{}{}
+	// 等待被杀掉的任务回传结果，记录日志
+	deadline = time.Now().Add(5 * time.Second)
+	for scheduler.RunningJobCount() > 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
 	}
 }
 
@@ -185,6 +332,7 @@ func InitScheduler() (err error) {
 		jobPlanTable:      make(map[string]*common.JobSchedulePlan),
 		jobExecutingTable: make(map[string]*common.JobExecuteInfo),
 		jobResultChan:     make(chan *common.JobExecuteResult, 1000), // 1000长度的队列
+		killAllChan:       make(chan struct{}),
 	}
 	// 启动调度协程
 	go G_scheduler.scheduleLoop() // 为什么启动协程呢？如果不启动，一直在这里阻塞，后边主程序的初始化工作就无法进行下去
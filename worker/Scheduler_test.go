@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"../common"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestExecutionSlots(t *testing.T) {
+	var (
+		oldConfig = G_config
+		scheduler *Scheduler
+		i         int
+	)
+	defer func() { G_config = oldConfig }()
+	G_config = &Config{MaxConcurrentJobs: 2}
+	// 执行表中抢锁前睡眠和没抢到锁的任务不占槽位
+	scheduler = &Scheduler{jobExecutingTable: make(map[string]*common.JobExecuteInfo)}
+	for i = 0; i < 5; i++ {
+		scheduler.jobExecutingTable[string(rune('a'+i))] = &common.JobExecuteInfo{}
+	}
+	if !scheduler.hasFreeSlot() {
+		t.Fatal("没有任务在运行，应该有空闲槽位")
+	}
+	if !scheduler.acquireSlot() || !scheduler.acquireSlot() {
+		t.Fatal("应该能占用2个槽位")
+	}
+	if scheduler.acquireSlot() || scheduler.hasFreeSlot() {
+		t.Fatal("槽位已满")
+	}
+	scheduler.releaseSlot()
+	if !scheduler.hasFreeSlot() || !scheduler.acquireSlot() {
+		t.Fatal("释放后应该能再占用")
+	}
+
+	G_config = &Config{MaxConcurrentJobs: 0}
+	if !scheduler.hasFreeSlot() || !scheduler.acquireSlot() {
+		t.Fatal("0表示不限制")
+	}
+}
+
+func TestAcquireSlotConcurrently(t *testing.T) {
+	var (
+		oldConfig = G_config
+		scheduler = &Scheduler{}
+		wg        sync.WaitGroup
+		acquired  int32
+		i         int
+	)
+	defer func() { G_config = oldConfig }()
+	G_config = &Config{MaxConcurrentJobs: 3}
+	for i = 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if scheduler.acquireSlot() {
+				atomic.AddInt32(&acquired, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if acquired != 3 || scheduler.executingJobs != 3 {
+		t.Errorf("并发占用了%d个槽位，计数%d，期望3", acquired, scheduler.executingJobs)
+	}
+}
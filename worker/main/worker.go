@@ -1,11 +1,15 @@
 package main
 
 import (
-	"fmt"
+	"os"
+	"os/signal"
 	"runtime"
-	"time"
+	"syscall"
+)
+import (
+	"../../logger"
+	"../../worker"
 )
-import "../../worker"
 
 func initEnv() {
 	// 线程池中线程与 CPU 核心数量的对应关系
@@ -14,7 +18,9 @@ func initEnv() {
 
 func main() {
 	var (
-		err error
+		err        error
+		signalChan chan os.Signal
+		sig        os.Signal
 	)
 	// 初始化线程
 	initEnv()
@@ -22,6 +28,14 @@ func main() {
 	if err = worker.InitConfig("worker/main/worker.json"); err != nil {
 		goto ERR
 	}
+	// 进程日志
+	if err = logger.InitLogger(worker.G_config.LoggerConfig()); err != nil {
+		goto ERR
+	}
+	// 监控指标
+	if err = worker.InitMetrics(); err != nil {
+		goto ERR
+	}
 	// 服务注册
 	if err = worker.InitRegister(); err != nil {
 		goto ERR
@@ -43,10 +57,16 @@ func main() {
 		goto ERR
 	}
 
+	// 等待退出信号，收到后优雅退出
+	signalChan = make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	sig = <-signalChan
+	logger.Component("main").WithField("signal", sig.String()).Info("收到退出信号，开始退出")
+	worker.Shutdown()
 	// 不要走标签ERR，正常退出
-	time.Sleep(time.Second * 1000)
 	return
 
 ERR:
-	fmt.Println(err)
+	logger.Component("main").WithError(err).Error("启动失败")
+	os.Exit(1)
 }